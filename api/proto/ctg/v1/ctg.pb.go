@@ -0,0 +1,441 @@
+// Сообщения генератора CTG в формате Protobuf (websocket.encoding = protobuf).
+// Каждое сообщение передаётся отдельным бинарным WebSocket фреймом без
+// префикса длины. Поля соответствуют JSON формату MessageData
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: ctg.proto
+
+package ctgv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type MessageData struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SensorId      string                 `protobuf:"bytes,1,opt,name=sensor_id,json=sensorId,proto3" json:"sensor_id,omitempty"`                 // sensorID
+	SecFromStart  float64                `protobuf:"fixed64,2,opt,name=sec_from_start,json=secFromStart,proto3" json:"sec_from_start,omitempty"` // secFromStart
+	Data          *SensorData            `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	Annotations   []*Annotation          `protobuf:"bytes,4,rep,name=annotations,proto3" json:"annotations,omitempty"`
+	Marks         []*Mark                `protobuf:"bytes,5,rep,name=marks,proto3" json:"marks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MessageData) Reset() {
+	*x = MessageData{}
+	mi := &file_ctg_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MessageData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MessageData) ProtoMessage() {}
+
+func (x *MessageData) ProtoReflect() protoreflect.Message {
+	mi := &file_ctg_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MessageData.ProtoReflect.Descriptor instead.
+func (*MessageData) Descriptor() ([]byte, []int) {
+	return file_ctg_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *MessageData) GetSensorId() string {
+	if x != nil {
+		return x.SensorId
+	}
+	return ""
+}
+
+func (x *MessageData) GetSecFromStart() float64 {
+	if x != nil {
+		return x.SecFromStart
+	}
+	return 0
+}
+
+func (x *MessageData) GetData() *SensorData {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *MessageData) GetAnnotations() []*Annotation {
+	if x != nil {
+		return x.Annotations
+	}
+	return nil
+}
+
+func (x *MessageData) GetMarks() []*Mark {
+	if x != nil {
+		return x.Marks
+	}
+	return nil
+}
+
+// Отсутствующее значение основного канала означает потерю сигнала (null в
+// JSON). Необязательные каналы могут отсутствовать совсем, поэтому потеря
+// сигнала на них передаётся флагом *_lost без значения
+type SensorData struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BpmChild      *float64               `protobuf:"fixed64,1,opt,name=bpm_child,json=bpmChild,proto3,oneof" json:"bpm_child,omitempty"` // bpmChild
+	Uterus        *float64               `protobuf:"fixed64,2,opt,name=uterus,proto3,oneof" json:"uterus,omitempty"`
+	Spasms        *float64               `protobuf:"fixed64,3,opt,name=spasms,proto3,oneof" json:"spasms,omitempty"`
+	BpmMother     *float64               `protobuf:"fixed64,4,opt,name=bpm_mother,json=bpmMother,proto3,oneof" json:"bpm_mother,omitempty"`        // bpmMother, только при generator.maternal_hr
+	BpmChild2     *float64               `protobuf:"fixed64,5,opt,name=bpm_child2,json=bpmChild2,proto3,oneof" json:"bpm_child2,omitempty"`        // bpmChild2, только в режиме двойни
+	BpmMotherLost bool                   `protobuf:"varint,6,opt,name=bpm_mother_lost,json=bpmMotherLost,proto3" json:"bpm_mother_lost,omitempty"` // bpmMother: null
+	BpmChild2Lost bool                   `protobuf:"varint,7,opt,name=bpm_child2_lost,json=bpmChild2Lost,proto3" json:"bpm_child2_lost,omitempty"` // bpmChild2: null
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SensorData) Reset() {
+	*x = SensorData{}
+	mi := &file_ctg_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SensorData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SensorData) ProtoMessage() {}
+
+func (x *SensorData) ProtoReflect() protoreflect.Message {
+	mi := &file_ctg_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SensorData.ProtoReflect.Descriptor instead.
+func (*SensorData) Descriptor() ([]byte, []int) {
+	return file_ctg_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SensorData) GetBpmChild() float64 {
+	if x != nil && x.BpmChild != nil {
+		return *x.BpmChild
+	}
+	return 0
+}
+
+func (x *SensorData) GetUterus() float64 {
+	if x != nil && x.Uterus != nil {
+		return *x.Uterus
+	}
+	return 0
+}
+
+func (x *SensorData) GetSpasms() float64 {
+	if x != nil && x.Spasms != nil {
+		return *x.Spasms
+	}
+	return 0
+}
+
+func (x *SensorData) GetBpmMother() float64 {
+	if x != nil && x.BpmMother != nil {
+		return *x.BpmMother
+	}
+	return 0
+}
+
+func (x *SensorData) GetBpmChild2() float64 {
+	if x != nil && x.BpmChild2 != nil {
+		return *x.BpmChild2
+	}
+	return 0
+}
+
+func (x *SensorData) GetBpmMotherLost() bool {
+	if x != nil {
+		return x.BpmMotherLost
+	}
+	return false
+}
+
+func (x *SensorData) GetBpmChild2Lost() bool {
+	if x != nil {
+		return x.BpmChild2Lost
+	}
+	return false
+}
+
+// Эталонная разметка событий генератора (generator.annotations)
+type Annotation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"` // contraction, deceleration, hypoxia, artifact, ...
+	Kind          string                 `protobuf:"bytes,2,opt,name=kind,proto3" json:"kind,omitempty"`
+	Start         float64                `protobuf:"fixed64,3,opt,name=start,proto3" json:"start,omitempty"`
+	End           *float64               `protobuf:"fixed64,4,opt,name=end,proto3,oneof" json:"end,omitempty"` // отсутствует, если событие длится до конца сессии
+	Channel       string                 `protobuf:"bytes,5,opt,name=channel,proto3" json:"channel,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Annotation) Reset() {
+	*x = Annotation{}
+	mi := &file_ctg_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Annotation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Annotation) ProtoMessage() {}
+
+func (x *Annotation) ProtoReflect() protoreflect.Message {
+	mi := &file_ctg_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Annotation.ProtoReflect.Descriptor instead.
+func (*Annotation) Descriptor() ([]byte, []int) {
+	return file_ctg_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Annotation) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Annotation) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *Annotation) GetStart() float64 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *Annotation) GetEnd() float64 {
+	if x != nil && x.End != nil {
+		return *x.End
+	}
+	return 0
+}
+
+func (x *Annotation) GetChannel() string {
+	if x != nil {
+		return x.Channel
+	}
+	return ""
+}
+
+// Отметки шевелений плода и нажатий кнопки события
+type Mark struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`     // fetal_movement, event
+	Source        string                 `protobuf:"bytes,2,opt,name=source,proto3" json:"source,omitempty"` // auto, manual
+	SecFromStart  float64                `protobuf:"fixed64,3,opt,name=sec_from_start,json=secFromStart,proto3" json:"sec_from_start,omitempty"`
+	Note          string                 `protobuf:"bytes,4,opt,name=note,proto3" json:"note,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Mark) Reset() {
+	*x = Mark{}
+	mi := &file_ctg_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Mark) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Mark) ProtoMessage() {}
+
+func (x *Mark) ProtoReflect() protoreflect.Message {
+	mi := &file_ctg_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Mark.ProtoReflect.Descriptor instead.
+func (*Mark) Descriptor() ([]byte, []int) {
+	return file_ctg_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Mark) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Mark) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *Mark) GetSecFromStart() float64 {
+	if x != nil {
+		return x.SecFromStart
+	}
+	return 0
+}
+
+func (x *Mark) GetNote() string {
+	if x != nil {
+		return x.Note
+	}
+	return ""
+}
+
+var File_ctg_proto protoreflect.FileDescriptor
+
+const file_ctg_proto_rawDesc = "" +
+	"\n" +
+	"\tctg.proto\x12\x06ctg.v1\"\xd2\x01\n" +
+	"\vMessageData\x12\x1b\n" +
+	"\tsensor_id\x18\x01 \x01(\tR\bsensorId\x12$\n" +
+	"\x0esec_from_start\x18\x02 \x01(\x01R\fsecFromStart\x12&\n" +
+	"\x04data\x18\x03 \x01(\v2\x12.ctg.v1.SensorDataR\x04data\x124\n" +
+	"\vannotations\x18\x04 \x03(\v2\x12.ctg.v1.AnnotationR\vannotations\x12\"\n" +
+	"\x05marks\x18\x05 \x03(\v2\f.ctg.v1.MarkR\x05marks\"\xc2\x02\n" +
+	"\n" +
+	"SensorData\x12 \n" +
+	"\tbpm_child\x18\x01 \x01(\x01H\x00R\bbpmChild\x88\x01\x01\x12\x1b\n" +
+	"\x06uterus\x18\x02 \x01(\x01H\x01R\x06uterus\x88\x01\x01\x12\x1b\n" +
+	"\x06spasms\x18\x03 \x01(\x01H\x02R\x06spasms\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"bpm_mother\x18\x04 \x01(\x01H\x03R\tbpmMother\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"bpm_child2\x18\x05 \x01(\x01H\x04R\tbpmChild2\x88\x01\x01\x12&\n" +
+	"\x0fbpm_mother_lost\x18\x06 \x01(\bR\rbpmMotherLost\x12&\n" +
+	"\x0fbpm_child2_lost\x18\a \x01(\bR\rbpmChild2LostB\f\n" +
+	"\n" +
+	"_bpm_childB\t\n" +
+	"\a_uterusB\t\n" +
+	"\a_spasmsB\r\n" +
+	"\v_bpm_motherB\r\n" +
+	"\v_bpm_child2\"\x83\x01\n" +
+	"\n" +
+	"Annotation\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x12\n" +
+	"\x04kind\x18\x02 \x01(\tR\x04kind\x12\x14\n" +
+	"\x05start\x18\x03 \x01(\x01R\x05start\x12\x15\n" +
+	"\x03end\x18\x04 \x01(\x01H\x00R\x03end\x88\x01\x01\x12\x18\n" +
+	"\achannel\x18\x05 \x01(\tR\achannelB\x06\n" +
+	"\x04_end\"l\n" +
+	"\x04Mark\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x16\n" +
+	"\x06source\x18\x02 \x01(\tR\x06source\x12$\n" +
+	"\x0esec_from_start\x18\x03 \x01(\x01R\fsecFromStart\x12\x12\n" +
+	"\x04note\x18\x04 \x01(\tR\x04noteB$Z\"backend_gen/api/proto/ctg/v1;ctgv1b\x06proto3"
+
+var (
+	file_ctg_proto_rawDescOnce sync.Once
+	file_ctg_proto_rawDescData []byte
+)
+
+func file_ctg_proto_rawDescGZIP() []byte {
+	file_ctg_proto_rawDescOnce.Do(func() {
+		file_ctg_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_ctg_proto_rawDesc), len(file_ctg_proto_rawDesc)))
+	})
+	return file_ctg_proto_rawDescData
+}
+
+var file_ctg_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_ctg_proto_goTypes = []any{
+	(*MessageData)(nil), // 0: ctg.v1.MessageData
+	(*SensorData)(nil),  // 1: ctg.v1.SensorData
+	(*Annotation)(nil),  // 2: ctg.v1.Annotation
+	(*Mark)(nil),        // 3: ctg.v1.Mark
+}
+var file_ctg_proto_depIdxs = []int32{
+	1, // 0: ctg.v1.MessageData.data:type_name -> ctg.v1.SensorData
+	2, // 1: ctg.v1.MessageData.annotations:type_name -> ctg.v1.Annotation
+	3, // 2: ctg.v1.MessageData.marks:type_name -> ctg.v1.Mark
+	3, // [3:3] is the sub-list for method output_type
+	3, // [3:3] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_ctg_proto_init() }
+func file_ctg_proto_init() {
+	if File_ctg_proto != nil {
+		return
+	}
+	file_ctg_proto_msgTypes[1].OneofWrappers = []any{}
+	file_ctg_proto_msgTypes[2].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_ctg_proto_rawDesc), len(file_ctg_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_ctg_proto_goTypes,
+		DependencyIndexes: file_ctg_proto_depIdxs,
+		MessageInfos:      file_ctg_proto_msgTypes,
+	}.Build()
+	File_ctg_proto = out.File
+	file_ctg_proto_goTypes = nil
+	file_ctg_proto_depIdxs = nil
+}
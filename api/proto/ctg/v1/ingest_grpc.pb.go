@@ -0,0 +1,122 @@
+// gRPC API приёма данных датчика (transport.type = grpc).
+// Клиент открывает один клиентский поток на сессию и отправляет в нём Sample;
+// при закрытии потока сервер отвечает итогом IngestResponse.
+//
+// Метаданные вызова:
+//   x-sensor-id    идентификатор датчика
+//   authorization  Bearer <токен датчика>
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: ingest.proto
+
+package ctgv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Ingestion_Ingest_FullMethodName = "/ctg.v1.Ingestion/Ingest"
+)
+
+// IngestionClient is the client API for Ingestion service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type IngestionClient interface {
+	Ingest(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[Sample, IngestResponse], error)
+}
+
+type ingestionClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewIngestionClient(cc grpc.ClientConnInterface) IngestionClient {
+	return &ingestionClient{cc}
+}
+
+func (c *ingestionClient) Ingest(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[Sample, IngestResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Ingestion_ServiceDesc.Streams[0], Ingestion_Ingest_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[Sample, IngestResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Ingestion_IngestClient = grpc.ClientStreamingClient[Sample, IngestResponse]
+
+// IngestionServer is the server API for Ingestion service.
+// All implementations must embed UnimplementedIngestionServer
+// for forward compatibility.
+type IngestionServer interface {
+	Ingest(grpc.ClientStreamingServer[Sample, IngestResponse]) error
+	mustEmbedUnimplementedIngestionServer()
+}
+
+// UnimplementedIngestionServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedIngestionServer struct{}
+
+func (UnimplementedIngestionServer) Ingest(grpc.ClientStreamingServer[Sample, IngestResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method Ingest not implemented")
+}
+func (UnimplementedIngestionServer) mustEmbedUnimplementedIngestionServer() {}
+func (UnimplementedIngestionServer) testEmbeddedByValue()                   {}
+
+// UnsafeIngestionServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to IngestionServer will
+// result in compilation errors.
+type UnsafeIngestionServer interface {
+	mustEmbedUnimplementedIngestionServer()
+}
+
+func RegisterIngestionServer(s grpc.ServiceRegistrar, srv IngestionServer) {
+	// If the following call pancis, it indicates UnimplementedIngestionServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Ingestion_ServiceDesc, srv)
+}
+
+func _Ingestion_Ingest_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(IngestionServer).Ingest(&grpc.GenericServerStream[Sample, IngestResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Ingestion_IngestServer = grpc.ClientStreamingServer[Sample, IngestResponse]
+
+// Ingestion_ServiceDesc is the grpc.ServiceDesc for Ingestion service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Ingestion_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ctg.v1.Ingestion",
+	HandlerType: (*IngestionServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Ingest",
+			Handler:       _Ingestion_Ingest_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "ingest.proto",
+}
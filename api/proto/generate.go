@@ -0,0 +1,5 @@
+// Package proto схемы Protobuf генератора; Go код в ctg/v1 сгенерирован из них
+package proto
+
+//go:generate protoc -I . --go_out=../.. --go_opt=module=backend_gen ctg.proto ingest.proto
+//go:generate protoc -I . --go-grpc_out=../.. --go-grpc_opt=module=backend_gen ingest.proto
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+
+	"backend_gen/internal/adapter/dataset"
+	datasetUC "backend_gen/internal/usecase/dataset"
+)
+
+// augment записывает аугментированные варианты записей, сохраняя класс и пациента
+func augment(args []string) int {
+	opts := datasetUC.AugmentDatasetOptions{
+		Variants: 3,
+		Seed:     42,
+		Augment: dataset.AugmentOptions{
+			TimeWarp:            0.1,
+			BaselineShift:       8,
+			VariabilityMin:      0.8,
+			VariabilityMax:      1.25,
+			LossPerHour:         4,
+			HalvingPerHour:      1,
+			ContractionsPerHour: 1,
+		},
+	}
+	fs := flag.NewFlagSet("augment", flag.ExitOnError)
+	root := fs.String("root", ".", "dataset root with regular/ and hypoxia/")
+	out := fs.String("out", "augmented", "output directory, same layout as the dataset")
+	classes := fs.String("class", dataset.ClassHypoxia, "comma-separated classes to augment")
+	fs.IntVar(&opts.Variants, "variants", opts.Variants, "variants per recording")
+	fs.Uint64Var(&opts.Seed, "seed", opts.Seed, "random seed")
+	fs.Float64Var(&opts.Augment.TimeWarp, "time-warp", opts.Augment.TimeWarp, "max relative change of time speed")
+	fs.Float64Var(&opts.Augment.BaselineShift, "baseline-shift", opts.Augment.BaselineShift, "max FHR baseline shift, bpm")
+	fs.Float64Var(&opts.Augment.VariabilityMin, "variability-min", opts.Augment.VariabilityMin, "min FHR variability scale")
+	fs.Float64Var(&opts.Augment.VariabilityMax, "variability-max", opts.Augment.VariabilityMax, "max FHR variability scale")
+	fs.Float64Var(&opts.Augment.LossPerHour, "loss", opts.Augment.LossPerHour, "FHR signal loss episodes per hour")
+	fs.Float64Var(&opts.Augment.HalvingPerHour, "halving", opts.Augment.HalvingPerHour, "FHR halving episodes per hour")
+	fs.Float64Var(&opts.Augment.ContractionsPerHour, "contractions", opts.Augment.ContractionsPerHour, "contractions per hour mixed in from the same class")
+	fs.Parse(args)
+	opts.Classes = strings.Split(*classes, ",")
+
+	result, err := datasetUC.AugmentDataset(*root, *out, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, class := range opts.Classes {
+		fmt.Printf("%-8s variants: %5d  contraction shapes: %d\n", class, result.Variants[class], result.Shapes[class])
+	}
+	fmt.Printf("%d recordings augmented, %d skipped, written to %s (seed %d)\n",
+		result.Recordings, len(result.Skipped), *out, opts.Seed)
+	return 0
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// Утилиты для датасета записей КТГ (каталоги regular/ и hypoxia/)
+var commands = map[string]func(args []string) int{
+	"validate": validate,
+	"stats":    stats,
+	"split":    split,
+	"augment":  augment,
+}
+
+func main() {
+	if len(os.Args) < 2 || commands[os.Args[1]] == nil {
+		fmt.Fprintln(os.Stderr, "usage: dataset <command> [flags]")
+		fmt.Fprintln(os.Stderr, "commands:")
+		fmt.Fprintln(os.Stderr, "  validate  check files under regular/ and hypoxia/, optionally write cleaned copies")
+		fmt.Fprintln(os.Stderr, "  stats     per-class and per-patient statistics, optionally as JSON")
+		fmt.Fprintln(os.Stderr, "  split     patient-level stratified train/val/test segments with manifest")
+		fmt.Fprintln(os.Stderr, "  augment   write augmented variants of recordings, keeping class and patient")
+		os.Exit(2)
+	}
+	os.Exit(commands[os.Args[1]](os.Args[2:]))
+}
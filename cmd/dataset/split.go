@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	datasetUC "backend_gen/internal/usecase/dataset"
+)
+
+// split делит пациентов на train/validation/test и выгружает сегменты с метками
+func split(args []string) int {
+	opts := datasetUC.SplitOptions{Seed: 42, Val: 0.15, Test: 0.15, Window: 600, Stride: 600, MaxMissing: 0.2}
+	fs := flag.NewFlagSet("split", flag.ExitOnError)
+	root := fs.String("root", ".", "dataset root with regular/ and hypoxia/")
+	out := fs.String("out", "split", "output directory")
+	format := fs.String("format", datasetUC.FormatNPY, "segment files: npy or csv")
+	fs.Uint64Var(&opts.Seed, "seed", opts.Seed, "random seed of the patient split")
+	fs.Float64Var(&opts.Val, "val", opts.Val, "fraction of patients in the validation split")
+	fs.Float64Var(&opts.Test, "test", opts.Test, "fraction of patients in the test split")
+	fs.IntVar(&opts.Window, "window", opts.Window, "segment length, seconds")
+	fs.IntVar(&opts.Stride, "stride", opts.Stride, "step between segments, seconds")
+	fs.Float64Var(&opts.MaxMissing, "max-missing", opts.MaxMissing, "drop segments with a larger fraction of lost FHR")
+	fs.Parse(args)
+
+	result, err := datasetUC.Split(*root, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	manifest, err := datasetUC.ExportSplit(result, opts, *out, *format)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, name := range datasetUC.SplitNames {
+		s := manifest.Splits[name]
+		fmt.Printf("%-5s patients: %3d  segments: %5d  (regular %d, hypoxia %d)\n",
+			name, len(s.Patients), s.Segments, s.ByClass["regular"], s.ByClass["hypoxia"])
+	}
+	fmt.Printf("dropped %d segments with lost signal, %d patient groups kept together\n",
+		manifest.Dropped, len(manifest.PatientGroups))
+	fmt.Printf("written to %s (seed %d)\n", *out, opts.Seed)
+	return 0
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"backend_gen/internal/adapter/dataset"
+	datasetUC "backend_gen/internal/usecase/dataset"
+)
+
+// stats печатает статистику по классам и пациентам и при необходимости пишет её в JSON
+func stats(args []string) int {
+	rules := datasetUC.DefaultRules()
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	root := fs.String("root", ".", "dataset root with regular/ and hypoxia/")
+	jsonPath := fs.String("json", "", "write statistics as JSON to this file (- = stdout instead of tables)")
+	fs.Float64Var(&rules.FHRMin, "fhr-min", rules.FHRMin, "minimal plausible FHR, bpm")
+	fs.Float64Var(&rules.FHRMax, "fhr-max", rules.FHRMax, "maximal plausible FHR, bpm")
+	fs.Parse(args)
+
+	result, err := datasetUC.ComputeStats(*root, rules)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *jsonPath != "" {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		data = append(data, '\n')
+		if *jsonPath == "-" {
+			os.Stdout.Write(data)
+			return 0
+		}
+		if err := os.WriteFile(*jsonPath, data, 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	header := func(first string) {
+		fmt.Printf("%-10s %10s %8s %13s %16s %14s %10s\n",
+			first, "recordings", "hours", "baseline_bpm", "variability_bpm", "contr_per_10m", "loss_pct")
+	}
+	row := func(name string, s datasetUC.Summary) {
+		fmt.Printf("%-10s %10d %8.2f %13.1f %16.1f %14.2f %10.1f\n",
+			name, s.Recordings, s.Hours, s.BaselineBPM, s.VariabilityBPM, s.ContractionsPer10Min, s.SignalLossPct)
+	}
+
+	header("class")
+	for _, class := range dataset.Classes {
+		if s, ok := result.Classes[class]; ok {
+			row(class, s)
+		}
+	}
+	row("total", result.Total)
+
+	class := ""
+	for _, p := range result.Patients {
+		if p.Class != class {
+			class = p.Class
+			fmt.Println()
+			header(class)
+		}
+		row(p.Patient, p.Summary)
+	}
+	if len(result.Skipped) > 0 {
+		fmt.Printf("\nskipped %d recordings without readable FHR channel\n", len(result.Skipped))
+	}
+	return 0
+}
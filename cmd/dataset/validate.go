@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"sort"
+
+	"backend_gen/internal/usecase/dataset"
+)
+
+// validate проверяет датасет и печатает отчёт; код выхода 1, если есть ошибки
+func validate(args []string) int {
+	rules := dataset.DefaultRules()
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	root := fs.String("root", ".", "dataset root with regular/ and hypoxia/")
+	out := fs.String("out", "", "directory for cleaned copies (default: do not write)")
+	quiet := fs.Bool("q", false, "print only the summary")
+	fs.Float64Var(&rules.FHRMin, "fhr-min", rules.FHRMin, "minimal plausible FHR, bpm")
+	fs.Float64Var(&rules.FHRMax, "fhr-max", rules.FHRMax, "maximal plausible FHR, bpm")
+	fs.Float64Var(&rules.UterusMax, "uterus-max", rules.UterusMax, "maximal plausible uterine tone, mmHg")
+	fs.Float64Var(&rules.MaxGap, "max-gap", rules.MaxGap, "report gaps longer than this, seconds")
+	fs.Float64Var(&rules.MaxLoss, "max-loss", rules.MaxLoss, "report signal loss above this fraction")
+	fs.Float64Var(&rules.MinDuration, "min-duration", rules.MinDuration, "report recordings shorter than this, seconds")
+	fs.Float64Var(&rules.LengthTolerance, "length-tolerance", rules.LengthTolerance, "allowed FHR/uterus length difference, seconds")
+	fs.Parse(args)
+
+	report, err := dataset.Validate(*root, rules, *out)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if !*quiet {
+		path := ""
+		for _, issue := range report.Issues {
+			if issue.Path != path {
+				path = issue.Path
+				fmt.Println(path)
+			}
+			fmt.Printf("  %-7s %-15s %s\n", issue.Severity, issue.Check, issue.Message)
+		}
+		if len(report.Issues) > 0 {
+			fmt.Println()
+		}
+	}
+
+	fmt.Printf("files: %d, recordings: %d\n", report.Files, report.Recordings)
+	fmt.Printf("errors: %d, warnings: %d\n", report.Count(dataset.SeverityError), report.Count(dataset.SeverityWarning))
+	counts := report.ByCheck()
+	checks := make([]string, 0, len(counts))
+	for check := range counts {
+		checks = append(checks, check)
+	}
+	sort.Strings(checks)
+	for _, check := range checks {
+		fmt.Printf("  %-15s %d\n", check, counts[check])
+	}
+	if *out != "" {
+		fmt.Printf("cleaned: %d files written to %s, %d samples removed\n", report.Cleaned, *out, report.Removed)
+	}
+
+	if report.Count(dataset.SeverityError) > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"log"
+	"net"
+
+	ctgv1 "backend_gen/api/proto/ctg/v1"
+	"backend_gen/internal/adapter/encoding"
+	grpcAdapter "backend_gen/internal/adapter/grpc"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Тестовый сервер API приёма Ingestion.Ingest (api/proto/ingest.proto)
+// без TLS. Печатает принятые сообщения и при закрытии потока отвечает итогом
+var (
+	addr    = flag.String("addr", ":50051", "listen address")
+	token   = flag.String("token", "", "required sensor token (default: any)")
+	verbose = flag.Bool("v", false, "print every message")
+)
+
+type ingestion struct {
+	ctgv1.UnimplementedIngestionServer
+}
+
+func main() {
+	flag.Parse()
+
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	server := grpc.NewServer()
+	ctgv1.RegisterIngestionServer(server, ingestion{})
+
+	log.Printf("gRPC тестовый сервер запущен на %s", *addr)
+	log.Printf("Метод: %s", ctgv1.Ingestion_Ingest_FullMethodName)
+	log.Fatal(server.Serve(ln))
+}
+
+func (ingestion) Ingest(stream ctgv1.Ingestion_IngestServer) error {
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	sensorID := first(md.Get(grpcAdapter.MetadataSensorID))
+	if *token != "" && first(md.Get(grpcAdapter.MetadataAuthorization)) != "Bearer "+*token {
+		log.Printf("Отклонён датчик %q: неверный токен", sensorID)
+		return status.Error(codes.Unauthenticated, "invalid sensor token")
+	}
+
+	// Заголовки сразу: клиент по ним понимает, что поток принят
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+	log.Printf("Открыт поток датчика %q", sensorID)
+
+	var received, last uint64
+	for {
+		sample, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			log.Printf("Поток датчика %q прерван: принято %d: %v", sensorID, received, err)
+			return err
+		}
+		if last != 0 && sample.GetSequence() != last+1 {
+			log.Printf("⚠️ Пропуск номеров: %d -> %d", last, sample.GetSequence())
+		}
+		last = sample.GetSequence()
+		received++
+
+		if *verbose {
+			out, _ := json.Marshal(encoding.FromProto(sample.GetMessage()))
+			log.Printf("#%d %s", last, out)
+		}
+	}
+
+	log.Printf("Поток датчика %q закрыт: принято %d, последний номер %d", sensorID, received, last)
+	return stream.SendAndClose(&ctgv1.IngestResponse{Accepted: received, LastSequence: last})
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net"
+	"strings"
+
+	"backend_gen/internal/adapter/hl7"
+)
+
+// Тестовый получатель HL7 v2 по MLLP: принимает ORU^R01 и отвечает ACK.
+// Флаги -error-rate и -reject-rate позволяют проверить обработку NAK
+var (
+	addr       = flag.String("addr", ":2575", "listen address")
+	errorRate  = flag.Float64("error-rate", 0, "fraction of messages answered with AE")
+	rejectRate = flag.Float64("reject-rate", 0, "fraction of messages answered with AR")
+	verbose    = flag.Bool("v", false, "print every message")
+)
+
+func main() {
+	flag.Parse()
+
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("MLLP получатель запущен на %s", *addr)
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			log.Fatal(err)
+		}
+		go serve(conn)
+	}
+}
+
+func serve(conn net.Conn) {
+	defer conn.Close()
+	log.Printf("Подключен отправитель %s", conn.RemoteAddr())
+
+	reader := bufio.NewReader(conn)
+	var received, accepted int
+	for n := 1; ; n++ {
+		message, err := hl7.ReadFrame(reader)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				log.Printf("Ошибка чтения: %v", err)
+			}
+			break
+		}
+		received++
+
+		header, err := hl7.ParseHeader(message)
+		if err != nil {
+			log.Printf("Сообщение без MSH отброшено: %v", err)
+			continue
+		}
+		code, text := check(message, header)
+		if *verbose || code != hl7.AckAccept {
+			log.Printf("#%d %s %s -> %s %s", received, header.MessageType, header.ControlID, code, text)
+		}
+		if *verbose {
+			for _, s := range hl7.Segments(message) {
+				log.Printf("    %s", s)
+			}
+		}
+		if code == hl7.AckAccept {
+			accepted++
+		}
+
+		ack := hl7.BuildAck(header, code, text, fmt.Sprintf("ACK%d", n))
+		if err := hl7.WriteFrame(conn, ack); err != nil {
+			log.Printf("Ошибка отправки ACK: %v", err)
+			break
+		}
+	}
+	log.Printf("Отправитель %s отключен: принято %d из %d", conn.RemoteAddr(), accepted, received)
+}
+
+// check проверяет сообщение и выбирает код подтверждения
+func check(message []byte, header hl7.Header) (code string, text string) {
+	if !strings.HasPrefix(header.MessageType, "ORU^R01") {
+		return hl7.AckError, "unsupported message type " + header.MessageType
+	}
+	obx := 0
+	for _, s := range hl7.Segments(message) {
+		if strings.HasPrefix(s, "OBX|") {
+			obx++
+		}
+	}
+	if obx == 0 {
+		return hl7.AckError, "no OBX segments"
+	}
+
+	switch r := rand.Float64(); {
+	case r < *errorRate:
+		return hl7.AckError, "simulated application error"
+	case r < *errorRate+*rejectRate:
+		return hl7.AckReject, "simulated temporary rejection"
+	}
+	return hl7.AckAccept, ""
+}
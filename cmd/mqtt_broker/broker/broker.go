@@ -0,0 +1,406 @@
+package broker
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+)
+
+// Broker минимальный MQTT 3.1.1 брокер для проверки MQTT транспорта в тестах
+// и через cmd/mqtt_broker. Не предназначен для эксплуатации: подписчики
+// получают сообщения только с QoS 0, авторизация и keep-alive не проверяются.
+// Принимает публикации с QoS 0/1/2, хранит retained сообщения, рассылает
+// завещания.
+// Для сессий без clean session помнит полученные QoS 2 публикации до PUBREL,
+// чтобы повторная отправка после переподключения не дублировала сообщение
+type Broker struct {
+	mu       sync.Mutex
+	sessions map[*brokerSession]struct{}
+	retained map[string]publish
+	// pending идентификаторы QoS 2 публикаций без PUBREL по client id
+	pending map[string]map[uint16]struct{}
+	ln      net.Listener
+}
+
+// brokerSession подключение клиента к брокеру
+type brokerSession struct {
+	conn     net.Conn
+	clientID string
+	writeMu  sync.Mutex
+	filters  []string
+	will     *publish
+	pending  map[uint16]struct{}
+}
+
+// NewBroker создает брокер
+func NewBroker() *Broker {
+	return &Broker{
+		sessions: make(map[*brokerSession]struct{}),
+		retained: make(map[string]publish),
+		pending:  make(map[string]map[uint16]struct{}),
+	}
+}
+
+// Listen открывает порт и обслуживает клиентов в фоне
+func (b *Broker) Listen(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	slog.Info("MQTT test broker started", "addr", ln.Addr().String())
+	go func() {
+		if err := b.Serve(ln); err != nil {
+			slog.Error("MQTT test broker stopped", "error", err)
+		}
+	}()
+	return nil
+}
+
+// Serve принимает подключения до закрытия listener
+func (b *Broker) Serve(ln net.Listener) error {
+	b.mu.Lock()
+	b.ln = ln
+	b.mu.Unlock()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go b.handle(conn)
+	}
+}
+
+// Close останавливает брокер и закрывает все подключения
+func (b *Broker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for s := range b.sessions {
+		s.conn.Close()
+	}
+	if b.ln != nil {
+		return b.ln.Close()
+	}
+	return nil
+}
+
+func (b *Broker) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	s, err := b.connect(conn, reader)
+	if err != nil {
+		slog.Warn("MQTT client rejected", "remote", conn.RemoteAddr().String(), "error", err)
+		return
+	}
+
+	err = b.serveSession(s, reader)
+
+	b.mu.Lock()
+	_, current := b.sessions[s]
+	delete(b.sessions, s)
+	b.mu.Unlock()
+
+	// Вытесненная сессия завещание не публикует: клиент уже подключился заново
+	if err != nil && current && s.will != nil {
+		// Аварийное отключение: публикуем завещание
+		slog.Info("MQTT client lost, publishing will", "client_id", s.clientID, "topic", s.will.topic)
+		b.route(*s.will)
+	}
+	slog.Info("MQTT client disconnected", "client_id", s.clientID)
+}
+
+// connect разбирает CONNECT и отвечает CONNACK
+func (b *Broker) connect(conn net.Conn, reader *bufio.Reader) (*brokerSession, error) {
+	pk, err := readPacket(reader)
+	if err != nil {
+		return nil, err
+	}
+	if pk.kind != packetConnect {
+		return nil, fmt.Errorf("expected CONNECT, got packet type %d", pk.kind)
+	}
+
+	protocol, pos, err := readString(pk.body, 0)
+	if err != nil {
+		return nil, err
+	}
+	if pos+4 > len(pk.body) {
+		return nil, fmt.Errorf("truncated CONNECT")
+	}
+	level, flags := pk.body[pos], pk.body[pos+1]
+	pos += 4 // уровень, флаги, keep alive
+	if protocol != "MQTT" || level != 4 {
+		_ = writePacket(conn, packetConnack, 0, []byte{0, 1})
+		return nil, fmt.Errorf("unsupported protocol %s level %d", protocol, level)
+	}
+
+	s := &brokerSession{conn: conn}
+	if s.clientID, pos, err = readString(pk.body, pos); err != nil {
+		return nil, err
+	}
+	if flags&0x04 != 0 {
+		will := publish{qos: (flags >> 3) & 0x03, retain: flags&0x20 != 0}
+		if will.topic, pos, err = readString(pk.body, pos); err != nil {
+			return nil, err
+		}
+		payload, next, err := readString(pk.body, pos)
+		if err != nil {
+			return nil, err
+		}
+		will.payload, pos = []byte(payload), next
+		s.will = &will
+	}
+	var username string
+	if flags&0x80 != 0 {
+		if username, _, err = readString(pk.body, pos); err != nil {
+			return nil, err
+		}
+	}
+
+	present := b.register(s, flags&0x02 != 0)
+	var ack byte
+	if present {
+		ack = 1
+	}
+	if err := s.write(packetConnack, 0, []byte{ack, 0}); err != nil {
+		return nil, err
+	}
+	slog.Info("MQTT client connected", "client_id", s.clientID, "username", username, "session_present", present)
+	return s, nil
+}
+
+// register добавляет сессию, отключая прежнее подключение с тем же client id,
+// и восстанавливает состояние QoS 2; возвращает признак сохранённой сессии
+func (b *Broker) register(s *brokerSession, clean bool) bool {
+	b.mu.Lock()
+	var stale []net.Conn
+	for other := range b.sessions {
+		if s.clientID != "" && other.clientID == s.clientID {
+			delete(b.sessions, other)
+			stale = append(stale, other.conn)
+		}
+	}
+	pending, present := b.pending[s.clientID]
+	if clean || s.clientID == "" || !present {
+		pending, present = make(map[uint16]struct{}), false
+	}
+	if clean || s.clientID == "" {
+		delete(b.pending, s.clientID)
+	} else {
+		b.pending[s.clientID] = pending
+	}
+	s.pending = pending
+	b.sessions[s] = struct{}{}
+	b.mu.Unlock()
+
+	for _, conn := range stale {
+		slog.Info("MQTT session taken over", "client_id", s.clientID)
+		conn.Close()
+	}
+	return present
+}
+
+// serveSession обрабатывает пакеты клиента; nil означает штатный DISCONNECT
+func (b *Broker) serveSession(s *brokerSession, reader *bufio.Reader) error {
+	for {
+		pk, err := readPacket(reader)
+		if err != nil {
+			return err
+		}
+
+		switch pk.kind {
+		case packetPublish:
+			p, err := decodePublish(pk)
+			if err != nil {
+				return err
+			}
+			duplicate := false
+			switch p.qos {
+			case 1:
+				err = s.write(packetPuback, 0, ackBody(p.id))
+			case 2:
+				// Повтор до PUBREL: подтверждаем, но не рассылаем второй раз
+				b.mu.Lock()
+				_, duplicate = s.pending[p.id]
+				s.pending[p.id] = struct{}{}
+				b.mu.Unlock()
+				err = s.write(packetPubrec, 0, ackBody(p.id))
+			}
+			if err != nil {
+				return err
+			}
+			if !duplicate {
+				b.route(p)
+			}
+		case packetPubrel:
+			id, err := packetID(pk.body)
+			if err != nil {
+				return err
+			}
+			b.mu.Lock()
+			delete(s.pending, id)
+			b.mu.Unlock()
+			if err := s.write(packetPubcomp, 0, ackBody(id)); err != nil {
+				return err
+			}
+		case packetSubscribe:
+			if err := b.subscribe(s, pk.body); err != nil {
+				return err
+			}
+		case packetUnsubscribe:
+			if err := b.unsubscribe(s, pk.body); err != nil {
+				return err
+			}
+		case packetPingreq:
+			if err := s.write(packetPingresp, 0, nil); err != nil {
+				return err
+			}
+		case packetPuback:
+			// Подписчикам отправляем QoS 0, подтверждения не ожидаются
+		case packetDisconnect:
+			s.will = nil
+			return nil
+		default:
+			return fmt.Errorf("unexpected packet type %d", pk.kind)
+		}
+	}
+}
+
+// subscribe добавляет фильтры, отвечает SUBACK и отдаёт retained сообщения
+func (b *Broker) subscribe(s *brokerSession, body []byte) error {
+	id, err := packetID(body)
+	if err != nil {
+		return err
+	}
+	ack := ackBody(id)
+	var added []string
+	for pos := 2; pos < len(body); {
+		filter, next, err := readString(body, pos)
+		if err != nil {
+			return err
+		}
+		if next >= len(body) {
+			return fmt.Errorf("missing requested QoS for %q", filter)
+		}
+		pos = next + 1
+		added = append(added, filter)
+		ack = append(ack, 0)
+	}
+
+	b.mu.Lock()
+	s.filters = append(s.filters, added...)
+	var retained []publish
+	for topic, p := range b.retained {
+		for _, filter := range added {
+			if topicMatches(filter, topic) {
+				retained = append(retained, p)
+				break
+			}
+		}
+	}
+	b.mu.Unlock()
+
+	if err := s.write(packetSuback, 0, ack); err != nil {
+		return err
+	}
+	for _, p := range retained {
+		if err := s.deliver(p, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Broker) unsubscribe(s *brokerSession, body []byte) error {
+	id, err := packetID(body)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	for pos := 2; pos < len(body); {
+		filter, next, err := readString(body, pos)
+		if err != nil {
+			b.mu.Unlock()
+			return err
+		}
+		pos = next
+		for i, f := range s.filters {
+			if f == filter {
+				s.filters = append(s.filters[:i], s.filters[i+1:]...)
+				break
+			}
+		}
+	}
+	b.mu.Unlock()
+
+	return s.write(packetUnsuback, 0, ackBody(id))
+}
+
+// route сохраняет retained сообщение и рассылает его подписчикам
+func (b *Broker) route(p publish) {
+	slog.Debug("MQTT publish", "topic", p.topic, "qos", p.qos, "retain", p.retain, "bytes", len(p.payload))
+
+	b.mu.Lock()
+	if p.retain {
+		if len(p.payload) == 0 {
+			delete(b.retained, p.topic)
+		} else {
+			b.retained[p.topic] = p
+		}
+	}
+	var targets []*brokerSession
+	for s := range b.sessions {
+		for _, filter := range s.filters {
+			if topicMatches(filter, p.topic) {
+				targets = append(targets, s)
+				break
+			}
+		}
+	}
+	b.mu.Unlock()
+
+	for _, s := range targets {
+		if err := s.deliver(p, false); err != nil {
+			slog.Warn("Failed to deliver MQTT message", "client_id", s.clientID, "error", err)
+			s.conn.Close()
+		}
+	}
+}
+
+// deliver отправляет сообщение подписчику с QoS 0
+func (s *brokerSession) deliver(p publish, retained bool) error {
+	flags, body := encodePublish(publish{topic: p.topic, payload: p.payload, retain: retained})
+	return s.write(packetPublish, flags, body)
+}
+
+func (s *brokerSession) write(kind, flags byte, body []byte) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return writePacket(s.conn, kind, flags, body)
+}
+
+// topicMatches проверяет топик по фильтру с подстановками + и #
+func topicMatches(filter, topic string) bool {
+	fs, ts := strings.Split(filter, "/"), strings.Split(topic, "/")
+	for i, f := range fs {
+		if f == "#" {
+			return true
+		}
+		if i >= len(ts) {
+			return false
+		}
+		if f != "+" && f != ts[i] {
+			return false
+		}
+	}
+	return len(fs) == len(ts)
+}
@@ -0,0 +1,165 @@
+package broker
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+const waitTimeout = 5 * time.Second
+
+// subscribers счётчик для уникальных client id подписчиков
+var subscribers atomic.Int32
+
+// startBroker запускает брокер на свободном порту
+func startBroker(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	b := NewBroker()
+	go b.Serve(ln)
+	t.Cleanup(func() { b.Close() })
+	return ln.Addr().String()
+}
+
+// subscribe подписывает отдельного paho клиента на фильтр
+func subscribe(t *testing.T, addr, filter string) <-chan paho.Message {
+	t.Helper()
+	messages := make(chan paho.Message, 64)
+	opts := paho.NewClientOptions().AddBroker("tcp://" + addr).SetClientID(fmt.Sprintf("sub-%d", subscribers.Add(1)))
+	sub := paho.NewClient(opts)
+	if tok := sub.Connect(); !tok.WaitTimeout(waitTimeout) || tok.Error() != nil {
+		t.Fatalf("subscriber connect: %v", tok.Error())
+	}
+	t.Cleanup(func() { sub.Disconnect(0) })
+	tok := sub.Subscribe(filter, 1, func(_ paho.Client, m paho.Message) { messages <- m })
+	if !tok.WaitTimeout(waitTimeout) || tok.Error() != nil {
+		t.Fatalf("subscribe: %v", tok.Error())
+	}
+	return messages
+}
+
+// expect ждёт сообщение с заданным payload
+func expect(t *testing.T, messages <-chan paho.Message, topic, payload string) paho.Message {
+	t.Helper()
+	select {
+	case m := <-messages:
+		if m.Topic() != topic || string(m.Payload()) != payload {
+			t.Fatalf("got %s %q, want %s %q", m.Topic(), m.Payload(), topic, payload)
+		}
+		return m
+	case <-time.After(waitTimeout):
+		t.Fatalf("timed out waiting for %s %q", topic, payload)
+	}
+	return nil
+}
+
+// expectNone проверяет, что лишних сообщений нет
+func expectNone(t *testing.T, messages <-chan paho.Message) {
+	t.Helper()
+	select {
+	case m := <-messages:
+		t.Fatalf("unexpected message %s %q", m.Topic(), m.Payload())
+	case <-time.After(300 * time.Millisecond):
+	}
+}
+
+// rawSession подключается к брокеру без clean session и возвращает флаг session present
+func rawSession(t *testing.T, addr, clientID string) (net.Conn, *bufio.Reader, bool) {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	body := appendString(nil, "MQTT")
+	body = append(body, 4, 0, 0, 0)
+	body = appendString(body, clientID)
+	if err := writePacket(conn, packetConnect, 0, body); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	r := bufio.NewReader(conn)
+	ack := readKind(t, r, packetConnack)
+	if ack.body[1] != 0 {
+		t.Fatalf("connection refused: %d", ack.body[1])
+	}
+	return conn, r, ack.body[0]&0x01 != 0
+}
+
+func readKind(t *testing.T, r *bufio.Reader, kind byte) packet {
+	t.Helper()
+	pk, err := readPacket(r)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if pk.kind != kind {
+		t.Fatalf("got packet type %d, want %d", pk.kind, kind)
+	}
+	return pk
+}
+
+func TestBrokerQoS2Duplicate(t *testing.T) {
+	addr := startBroker(t)
+	messages := subscribe(t, addr, "data")
+
+	conn, r, present := rawSession(t, addr, "raw")
+	if present {
+		t.Error("new session reported as present")
+	}
+	flags, body := encodePublish(publish{topic: "data", payload: []byte("once"), qos: 2, id: 7})
+	if err := writePacket(conn, packetPublish, flags, body); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	readKind(t, r, packetPubrec)
+	expect(t, messages, "data", "once")
+	conn.Close()
+
+	// Повтор после переподключения: PUBREC без повторной рассылки
+	conn, r, present = rawSession(t, addr, "raw")
+	if !present {
+		t.Error("session with pending QoS 2 message must be present")
+	}
+	if err := writePacket(conn, packetPublish, flags|0x08, body); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	readKind(t, r, packetPubrec)
+	if err := writePacket(conn, packetPubrel, 0x02, ackBody(7)); err != nil {
+		t.Fatalf("pubrel: %v", err)
+	}
+	readKind(t, r, packetPubcomp)
+	expectNone(t, messages)
+
+	// После PUBREL тот же идентификатор означает новое сообщение
+	if err := writePacket(conn, packetPublish, flags, body); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	readKind(t, r, packetPubrec)
+	expect(t, messages, "data", "once")
+}
+
+func TestTopicMatches(t *testing.T) {
+	cases := []struct {
+		filter, topic string
+		want          bool
+	}{
+		{"ctg/+/data", "ctg/s1/data", true},
+		{"ctg/+/data", "ctg/s1/status", false},
+		{"ctg/#", "ctg/s1/data", true},
+		{"#", "ctg", true},
+		{"ctg/+", "ctg/s1/data", false},
+		{"ctg/s1", "ctg/s1", true},
+	}
+	for _, c := range cases {
+		if got := topicMatches(c.filter, c.topic); got != c.want {
+			t.Errorf("topicMatches(%q, %q) = %v, want %v", c.filter, c.topic, got, c.want)
+		}
+	}
+}
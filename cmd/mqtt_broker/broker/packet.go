@@ -0,0 +1,169 @@
+package broker
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Типы управляющих пакетов MQTT 3.1.1
+const (
+	packetConnect     = 1
+	packetConnack     = 2
+	packetPublish     = 3
+	packetPuback      = 4
+	packetPubrec      = 5
+	packetPubrel      = 6
+	packetPubcomp     = 7
+	packetSubscribe   = 8
+	packetSuback      = 9
+	packetUnsubscribe = 10
+	packetUnsuback    = 11
+	packetPingreq     = 12
+	packetPingresp    = 13
+	packetDisconnect  = 14
+)
+
+// Максимальная длина пакета, которую принимает брокер
+const maxPacketSize = 1 << 20
+
+// packet управляющий пакет: тип, флаги фиксированного заголовка и тело
+type packet struct {
+	kind  byte
+	flags byte
+	body  []byte
+}
+
+// readPacket читает пакет с переменной длиной остатка (до 4 байт)
+func readPacket(r *bufio.Reader) (packet, error) {
+	head, err := r.ReadByte()
+	if err != nil {
+		return packet{}, err
+	}
+
+	var length, shift int
+	for i := 0; ; i++ {
+		if i == 4 {
+			return packet{}, fmt.Errorf("mqtt: malformed remaining length")
+		}
+		b, err := r.ReadByte()
+		if err != nil {
+			return packet{}, err
+		}
+		length |= int(b&0x7f) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			break
+		}
+	}
+	if length > maxPacketSize {
+		return packet{}, fmt.Errorf("mqtt: packet of %d bytes is too large", length)
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return packet{}, err
+	}
+	return packet{kind: head >> 4, flags: head & 0x0f, body: body}, nil
+}
+
+// writePacket пишет пакет одним вызовом Write
+func writePacket(w io.Writer, kind, flags byte, body []byte) error {
+	buf := make([]byte, 0, len(body)+5)
+	buf = append(buf, kind<<4|flags)
+	for n := len(body); ; {
+		b := byte(n & 0x7f)
+		n >>= 7
+		if n > 0 {
+			b |= 0x80
+		}
+		buf = append(buf, b)
+		if n == 0 {
+			break
+		}
+	}
+	buf = append(buf, body...)
+	_, err := w.Write(buf)
+	return err
+}
+
+// appendString дописывает строку с двухбайтовым префиксом длины
+func appendString(buf []byte, s string) []byte {
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(s)))
+	return append(buf, s...)
+}
+
+// readString читает строку с префиксом длины из body начиная с pos
+func readString(body []byte, pos int) (string, int, error) {
+	if pos+2 > len(body) {
+		return "", pos, fmt.Errorf("mqtt: truncated string")
+	}
+	n := int(binary.BigEndian.Uint16(body[pos:]))
+	pos += 2
+	if pos+n > len(body) {
+		return "", pos, fmt.Errorf("mqtt: truncated string")
+	}
+	return string(body[pos : pos+n]), pos + n, nil
+}
+
+// packetID читает идентификатор пакета (PUBACK, PUBREC, PUBREL, PUBCOMP, SUBACK)
+func packetID(body []byte) (uint16, error) {
+	if len(body) < 2 {
+		return 0, fmt.Errorf("mqtt: missing packet identifier")
+	}
+	return binary.BigEndian.Uint16(body), nil
+}
+
+// ackBody тело пакета подтверждения с идентификатором id
+func ackBody(id uint16) []byte {
+	return binary.BigEndian.AppendUint16(nil, id)
+}
+
+// publish разобранный пакет PUBLISH
+type publish struct {
+	topic   string
+	payload []byte
+	qos     byte
+	retain  bool
+	id      uint16
+}
+
+// encodePublish собирает пакет PUBLISH: флаги и тело
+func encodePublish(p publish) (byte, []byte) {
+	flags := p.qos << 1
+	if p.retain {
+		flags |= 0x01
+	}
+	body := appendString(make([]byte, 0, len(p.topic)+len(p.payload)+4), p.topic)
+	if p.qos > 0 {
+		body = binary.BigEndian.AppendUint16(body, p.id)
+	}
+	return flags, append(body, p.payload...)
+}
+
+// decodePublish разбирает пакет PUBLISH
+func decodePublish(pk packet) (publish, error) {
+	p := publish{
+		qos:    (pk.flags >> 1) & 0x03,
+		retain: pk.flags&0x01 != 0,
+	}
+	if p.qos > 2 {
+		return p, fmt.Errorf("mqtt: invalid QoS %d", p.qos)
+	}
+
+	topic, pos, err := readString(pk.body, 0)
+	if err != nil {
+		return p, err
+	}
+	p.topic = topic
+	if p.qos > 0 {
+		if pos+2 > len(pk.body) {
+			return p, fmt.Errorf("mqtt: missing packet identifier")
+		}
+		p.id = binary.BigEndian.Uint16(pk.body[pos:])
+		pos += 2
+	}
+	p.payload = pk.body[pos:]
+	return p, nil
+}
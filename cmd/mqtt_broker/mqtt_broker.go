@@ -0,0 +1,29 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+
+	"backend_gen/cmd/mqtt_broker/broker"
+)
+
+// Тестовый MQTT брокер для проверки транспорта transport.type = mqtt без
+// внешнего брокера. Для эксплуатации нужен полноценный брокер
+var addr = flag.String("addr", ":1883", "listen address")
+
+func main() {
+	flag.Parse()
+
+	b := broker.NewBroker()
+	if err := b.Listen(*addr); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("MQTT брокер запущен на %s", *addr)
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt)
+	<-stop
+	b.Close()
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"backend_gen/config"
+	"backend_gen/internal/ports/generator"
+	"backend_gen/internal/server"
+	"backend_gen/internal/usecase/offline"
+)
+
+// Офлайн генерация: сессия заданной длительности без ожидания реального
+// времени, с записью в файл. Генератор собирается из той же конфигурации,
+// что и у сервера
+var (
+	cfgPath     = flag.String("c", "config/config.yaml", "path to config file")
+	scenario    = flag.String("scenario", "", "scenario or preset name (default: generator from config)")
+	duration    = flag.Duration("duration", 20*time.Minute, "session duration")
+	format      = flag.String("format", "json", "output format: json (JSON Lines), fhir (FHIR Observation NDJSON), edf (EDF+ with annotations)")
+	output      = flag.String("o", "-", "output file (- = stdout)")
+	startFlag   = flag.String("start", "", "session start time, RFC3339 (default: now)")
+	window      = flag.Duration("window", 0, "fhir: samples per Observation (default: fhir.window_sec from config)")
+	annotations = flag.Bool("annotations", false, "include ground-truth annotations (always on for edf)")
+	patient     = flag.String("patient", "", "patient identifier for fhir and edf (default: from config)")
+	weeks       = flag.Float64("weeks", 0, "gestational age in weeks (default: profile from config)")
+)
+
+func main() {
+	flag.Parse()
+
+	cfg, err := config.ReadConfig(*cfgPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	gen, scenarios, err := server.NewGenerators(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *scenario != "" {
+		if gen, err = scenarios.Open(*scenario); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	start := time.Now()
+	if *startFlag != "" {
+		if start, err = time.Parse(time.RFC3339, *startFlag); err != nil {
+			log.Fatalf("invalid -start: %v", err)
+		}
+	}
+
+	out := os.Stdout
+	if *output != "-" {
+		if out, err = os.Create(*output); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *window > 0 {
+		cfg.FHIR.WindowSec = window.Seconds()
+	}
+	if *patient != "" {
+		cfg.FHIR.PatientID = *patient
+		cfg.Export.PatientID = *patient
+	}
+	writer, err := server.NewExportWriter(cfg, *format, out, start)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	profile := generator.Profile{
+		GestationalWeeks: cfg.Profile.GestationalWeeks,
+		MaternalAge:      cfg.Profile.MaternalAge,
+		Parity:           cfg.Profile.Parity,
+	}
+	if *weeks > 0 {
+		profile.GestationalWeeks = *weeks
+	}
+
+	// В EDF+ разметка - это аннотации схваток и децелераций для просмотрщиков
+	withAnnotations := *annotations || cfg.Generator.Annotations || *format == "edf"
+
+	count, err := offline.Generate(gen, offline.Options{
+		Duration:    *duration,
+		Annotations: withAnnotations,
+		Profile:     &profile,
+	}, writer)
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Fprintf(os.Stderr, "generated %d messages (%s) in %s format\n", count, *duration, *format)
+}
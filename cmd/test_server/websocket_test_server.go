@@ -1,32 +1,47 @@
 package main
 
 import (
+	"encoding/json"
+	"flag"
 	"log"
 	"net/http"
 	"sync"
 
+	"backend_gen/internal/adapter/encoding"
+	ws "backend_gen/internal/ports/websocket"
+
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/gorilla/websocket"
 )
 
+// Формат входящих сообщений для вывода в консоль; по умолчанию определяется
+// пробным декодированием. Клиентам сообщения пересылаются без изменений
+var encodingFlag = flag.String("encoding", "", "message encoding: json, msgpack, protobuf, cbor (default: auto)")
+
 var upgrader = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool {
 		return true // разрешаем все origin для простоты
 	},
 }
 
+// frame сообщение генератора вместе с типом WebSocket фрейма
+type frame struct {
+	messageType int
+	data        []byte
+}
+
 // Глобальный менеджер клиентов
 var clientManager = &ClientManager{
 	clients:    make(map[*websocket.Conn]bool),
-	broadcast:  make(chan []byte),
+	broadcast:  make(chan frame),
 	register:   make(chan *websocket.Conn),
 	unregister: make(chan *websocket.Conn),
 }
 
 type ClientManager struct {
 	clients    map[*websocket.Conn]bool
-	broadcast  chan []byte
+	broadcast  chan frame
 	register   chan *websocket.Conn
 	unregister chan *websocket.Conn
 	mutex      sync.RWMutex
@@ -53,7 +68,7 @@ func (cm *ClientManager) run() {
 		case message := <-cm.broadcast:
 			cm.mutex.RLock()
 			for client := range cm.clients {
-				err := client.WriteMessage(websocket.TextMessage, message)
+				err := client.WriteMessage(message.messageType, message.data)
 				if err != nil {
 					log.Printf("Ошибка отправки сообщения клиенту: %v", err)
 					client.Close()
@@ -66,6 +81,8 @@ func (cm *ClientManager) run() {
 }
 
 func main() {
+	flag.Parse()
+
 	r := chi.NewRouter()
 
 	//для лог
@@ -99,7 +116,7 @@ func handleWebSocket(w http.ResponseWriter, r *http.Request) {
 
 	// Обработка входящих сообщений
 	for {
-		_, message, err := conn.ReadMessage()
+		messageType, message, err := conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				log.Printf("WebSocket ошибка: %v", err)
@@ -107,10 +124,28 @@ func handleWebSocket(w http.ResponseWriter, r *http.Request) {
 			break
 		}
 
-		// Выводим сообщение в консоль (от генератора)
-		log.Printf("📊 Получено от генератора: %s", string(message))
+		format := *encodingFlag
+		if format == "" {
+			format = encoding.Detect(message)
+		}
+		if decoded, err := decode(format, message); err != nil {
+			log.Printf("Не удалось декодировать сообщение (%q, %d байт): %v", format, len(message), err)
+		} else {
+			// Выводим сообщение в консоль (от генератора)
+			pretty, _ := json.MarshalIndent(decoded, "", "  ")
+			log.Printf("📊 Получено от генератора (%s, %d байт):\n%s", format, len(message), pretty)
+		}
 
-		// Транслируем сообщение всем подключенным клиентам (фронтенду)
-		clientManager.broadcast <- message
+		// Транслируем сообщение всем подключенным клиентам (фронтенду) без изменений
+		clientManager.broadcast <- frame{messageType: messageType, data: message}
+	}
+}
+
+// decode декодирует сообщение генератора
+func decode(format string, message []byte) (ws.MessageData, error) {
+	codec, err := encoding.New(format)
+	if err != nil {
+		return ws.MessageData{}, err
 	}
+	return codec.Decode(message)
 }
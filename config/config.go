@@ -13,10 +13,203 @@ type Config struct {
 	Log       log
 	WebSocket websocket
 	Generator generator
+	Profile   profile
+	Replay    replay
+	Hybrid    hybrid
+	Artifacts artifacts
+	Augment   augment
+	Faults    faults
+	Scenarios scenarios
+	Transport transport
+	MQTT      mqtt
+	GRPC      grpc
+	HTTPBatch httpBatch `yaml:"http_batch"`
+	HL7       hl7
+	FHIR      fhir
+	Export    export
 }
 
 type generator struct {
 	HypoxiaMode int `yaml:"hypoxia_mode" envconfig:"HYPOXIA_MODE"`
+	// Annotations включает отправку эталонной разметки событий вместе с данными.
+	// Схватки и децелерации синтетического генератора размечаются только с Events
+	Annotations bool `yaml:"annotations" envconfig:"ANNOTATIONS"`
+	// MaternalHR добавляет в данные канал пульса матери (bpmMother)
+	MaternalHR bool `yaml:"maternal_hr" envconfig:"MATERNAL_HR"`
+	// Twins включает режим двойни: второй канал FHR (bpmChild2)
+	Twins bool `yaml:"twins" envconfig:"TWINS"`
+	// Events включает в синтетическом генераторе схватки, децелерации и стадии
+	// гипоксии; без него уровни сигнала соответствуют датасету
+	Events bool `yaml:"events" envconfig:"GENERATOR_EVENTS"`
+	// Source источник данных: synthetic (CTG генератор), replay (запись из датасета)
+	// или hybrid (сегменты записей датасета с синтетическими переходами)
+	Source string `yaml:"source" envconfig:"GENERATOR_SOURCE"`
+	// Preset имя пресета или сценария из каталога для сессий без явного сценария
+	// (только для source = synthetic)
+	Preset string `yaml:"preset" envconfig:"GENERATOR_PRESET"`
+}
+
+// profile профиль пациентки по умолчанию (переопределяется параметрами /api/on)
+type profile struct {
+	GestationalWeeks float64 `yaml:"gestational_weeks" envconfig:"PROFILE_GESTATIONAL_WEEKS"`
+	MaternalAge      int     `yaml:"maternal_age" envconfig:"PROFILE_MATERNAL_AGE"`
+	Parity           int     `yaml:"parity" envconfig:"PROFILE_PARITY"`
+}
+
+// replay запись датасета для воспроизведения (generator.source = replay)
+type replay struct {
+	DatasetDir string `yaml:"dataset_dir" envconfig:"REPLAY_DATASET_DIR"`
+	Class      string `yaml:"class" envconfig:"REPLAY_CLASS"`
+	Patient    string `yaml:"patient" envconfig:"REPLAY_PATIENT"`
+	Recording  string `yaml:"recording" envconfig:"REPLAY_RECORDING"`
+	// File запись в формате EDF, CSV с колонками каналов или JSONL генератора
+	// вместо записи датасета; format = "" определяется по расширению
+	File   string `yaml:"file" envconfig:"REPLAY_FILE"`
+	Format string `yaml:"format" envconfig:"REPLAY_FORMAT"`
+	// Stitch склеивает все записи пациента по порядку в одну сессию (recording не используется)
+	Stitch bool `yaml:"stitch" envconfig:"REPLAY_STITCH"`
+	// StitchGap обработка стыков: none (встык), loss (пауза без сигнала), bridge (плавный переход)
+	StitchGap    string  `yaml:"stitch_gap" envconfig:"REPLAY_STITCH_GAP"`
+	StitchGapSec float64 `yaml:"stitch_gap_sec" envconfig:"REPLAY_STITCH_GAP_SEC"`
+}
+
+// hybrid сегменты записей класса class из replay.dataset_dir, соединённые
+// синтетическими переходами (generator.source = hybrid)
+type hybrid struct {
+	Class         string  `yaml:"class" envconfig:"HYBRID_CLASS"`
+	SegmentSec    float64 `yaml:"segment_sec" envconfig:"HYBRID_SEGMENT_SEC"`
+	TransitionSec float64 `yaml:"transition_sec" envconfig:"HYBRID_TRANSITION_SEC"`
+	// MaxMissing сегменты с большей долей потери сигнала FHR пропускаются
+	MaxMissing float64 `yaml:"max_missing" envconfig:"HYBRID_MAX_MISSING"`
+	// Seed начальное значение выбора сегментов (0 - случайное)
+	Seed uint64 `yaml:"seed" envconfig:"HYBRID_SEED"`
+}
+
+// artifacts параметры слоя искусственных артефактов сигнала (частоты в событиях в час)
+type artifacts struct {
+	Enabled                bool    `yaml:"enabled" envconfig:"ARTIFACTS_ENABLED"`
+	LossValue              string  `yaml:"loss_value" envconfig:"ARTIFACTS_LOSS_VALUE"` // zero | nan, также для generator.source = hybrid
+	SignalLossPerHour      float64 `yaml:"signal_loss_per_hour" envconfig:"ARTIFACTS_SIGNAL_LOSS_PER_HOUR"`
+	SpikesPerHour          float64 `yaml:"spikes_per_hour" envconfig:"ARTIFACTS_SPIKES_PER_HOUR"`
+	HalvingDoublingPerHour float64 `yaml:"halving_doubling_per_hour" envconfig:"ARTIFACTS_HALVING_DOUBLING_PER_HOUR"`
+	MaternalCapturePerHour float64 `yaml:"maternal_capture_per_hour" envconfig:"ARTIFACTS_MATERNAL_CAPTURE_PER_HOUR"`
+	UterusLossPerHour      float64 `yaml:"uterus_loss_per_hour" envconfig:"ARTIFACTS_UTERUS_LOSS_PER_HOUR"`
+}
+
+// augment аугментация записи воспроизведения (generator.source = replay): каждая
+// сессия получает новый вариант записи того же класса
+type augment struct {
+	Enabled bool `yaml:"enabled" envconfig:"AUGMENT_ENABLED"`
+	// Seed начальное значение генератора вариантов (0 - случайное)
+	Seed uint64 `yaml:"seed" envconfig:"AUGMENT_SEED"`
+	// TimeWarp наибольшее относительное изменение скорости времени
+	TimeWarp float64 `yaml:"time_warp" envconfig:"AUGMENT_TIME_WARP"`
+	// BaselineShift наибольший сдвиг базального ритма FHR, уд/мин
+	BaselineShift  float64 `yaml:"baseline_shift" envconfig:"AUGMENT_BASELINE_SHIFT"`
+	VariabilityMin float64 `yaml:"variability_min" envconfig:"AUGMENT_VARIABILITY_MIN"`
+	VariabilityMax float64 `yaml:"variability_max" envconfig:"AUGMENT_VARIABILITY_MAX"`
+	LossPerHour    float64 `yaml:"loss_per_hour" envconfig:"AUGMENT_LOSS_PER_HOUR"`
+	HalvingPerHour float64 `yaml:"halving_per_hour" envconfig:"AUGMENT_HALVING_PER_HOUR"`
+	// ContractionsPerHour схваток из записей того же класса, добавляемых в тонус
+	ContractionsPerHour float64 `yaml:"contractions_per_hour" envconfig:"AUGMENT_CONTRACTIONS_PER_HOUR"`
+}
+
+// faults параметры инъекции сетевых сбоев по умолчанию для каждой сессии
+type faults struct {
+	Enabled            bool    `yaml:"enabled" envconfig:"FAULTS_ENABLED"`
+	LatencyMs          int     `yaml:"latency_ms" envconfig:"FAULTS_LATENCY_MS"`
+	JitterMs           int     `yaml:"jitter_ms" envconfig:"FAULTS_JITTER_MS"`
+	DropRate           float64 `yaml:"drop_rate" envconfig:"FAULTS_DROP_RATE"`
+	DuplicateRate      float64 `yaml:"duplicate_rate" envconfig:"FAULTS_DUPLICATE_RATE"`
+	ReorderRate        float64 `yaml:"reorder_rate" envconfig:"FAULTS_REORDER_RATE"`
+	DisconnectEverySec float64 `yaml:"disconnect_every_sec" envconfig:"FAULTS_DISCONNECT_EVERY_SEC"`
+	DisconnectRate     float64 `yaml:"disconnect_rate" envconfig:"FAULTS_DISCONNECT_RATE"`
+	ReconnectAfterMs   int     `yaml:"reconnect_after_ms" envconfig:"FAULTS_RECONNECT_AFTER_MS"`
+}
+
+// scenarios каталог YAML сценариев, запускаемых через /api/scenarios/{name}/on
+type scenarios struct {
+	Dir string `yaml:"dir" envconfig:"SCENARIOS_DIR"`
+}
+
+// transport способ доставки сообщений на бэкенд: websocket (по умолчанию),
+// mqtt, grpc, http, mllp или fhir
+type transport struct {
+	Type string `yaml:"type" envconfig:"TRANSPORT"`
+}
+
+// mqtt параметры публикации в MQTT брокер (transport.type = mqtt).
+// В топиках {sensor_id} заменяется идентификатором датчика
+type mqtt struct {
+	Addr         string `yaml:"addr" envconfig:"MQTT_ADDR"`
+	Port         string `yaml:"port" envconfig:"MQTT_PORT"`
+	ClientID     string `yaml:"client_id" envconfig:"MQTT_CLIENT_ID"`
+	Topic        string `yaml:"topic" envconfig:"MQTT_TOPIC"`
+	StatusTopic  string `yaml:"status_topic" envconfig:"MQTT_STATUS_TOPIC"`
+	QoS          int    `yaml:"qos" envconfig:"MQTT_QOS"`
+	RetainStatus bool   `yaml:"retain_status" envconfig:"MQTT_RETAIN_STATUS"`
+	KeepAliveSec int    `yaml:"keep_alive_sec" envconfig:"MQTT_KEEP_ALIVE_SEC"`
+}
+
+// grpc параметры потока Ingestion.Ingest (transport.type = grpc, api/proto/ingest.proto).
+// Сообщения всегда кодируются в Protobuf
+type grpc struct {
+	Addr string `yaml:"addr" envconfig:"GRPC_ADDR"`
+	Port string `yaml:"port" envconfig:"GRPC_PORT"`
+	// TLS включает HTTPS; без него используется HTTP/2 без шифрования (h2c)
+	TLS      bool `yaml:"tls" envconfig:"GRPC_TLS"`
+	Insecure bool `yaml:"insecure" envconfig:"GRPC_INSECURE"`
+	// Buffer размер очереди отправки, пока HTTP/2 окно сервера закрыто
+	Buffer int `yaml:"buffer" envconfig:"GRPC_BUFFER"`
+}
+
+// httpBatch параметры пакетной отправки POST запросами (transport.type = http).
+// Сообщения всегда кодируются в JSON и отправляются массивом
+type httpBatch struct {
+	URL            string `yaml:"url" envconfig:"HTTP_BATCH_URL"`
+	IntervalMs     int    `yaml:"interval_ms" envconfig:"HTTP_BATCH_INTERVAL_MS"`
+	MaxBatch       int    `yaml:"max_batch" envconfig:"HTTP_BATCH_MAX_BATCH"`
+	MaxBuffer      int    `yaml:"max_buffer" envconfig:"HTTP_BATCH_MAX_BUFFER"`
+	Retries        int    `yaml:"retries" envconfig:"HTTP_BATCH_RETRIES"`
+	RetryBackoffMs int    `yaml:"retry_backoff_ms" envconfig:"HTTP_BATCH_RETRY_BACKOFF_MS"`
+	TimeoutMs      int    `yaml:"timeout_ms" envconfig:"HTTP_BATCH_TIMEOUT_MS"`
+	FlushTimeoutMs int    `yaml:"flush_timeout_ms" envconfig:"HTTP_BATCH_FLUSH_TIMEOUT_MS"`
+}
+
+// hl7 параметры сообщений ORU^R01 (websocket.encoding = hl7) и получателя MLLP
+// (transport.type = mllp)
+type hl7 struct {
+	Addr              string `yaml:"addr" envconfig:"HL7_ADDR"`
+	Port              string `yaml:"port" envconfig:"HL7_PORT"`
+	AckTimeoutMs      int    `yaml:"ack_timeout_ms" envconfig:"HL7_ACK_TIMEOUT_MS"`
+	Retries           int    `yaml:"retries" envconfig:"HL7_RETRIES"`
+	SendingApp        string `yaml:"sending_app" envconfig:"HL7_SENDING_APP"`
+	SendingFacility   string `yaml:"sending_facility" envconfig:"HL7_SENDING_FACILITY"`
+	ReceivingApp      string `yaml:"receiving_app" envconfig:"HL7_RECEIVING_APP"`
+	ReceivingFacility string `yaml:"receiving_facility" envconfig:"HL7_RECEIVING_FACILITY"`
+	// PatientID идентификатор пациентки в PID-3 ("" = идентификатор датчика)
+	PatientID string `yaml:"patient_id" envconfig:"HL7_PATIENT_ID"`
+}
+
+// fhir параметры ресурсов Observation (transport.type = fhir и офлайн формат fhir).
+// Сообщения собираются в Observation с SampledData по окнам window_sec
+type fhir struct {
+	// URL базовый адрес FHIR сервера; ресурсы отправляются в [url]/Observation
+	URL            string  `yaml:"url" envconfig:"FHIR_URL"`
+	PatientID      string  `yaml:"patient_id" envconfig:"FHIR_PATIENT_ID"`
+	WindowSec      float64 `yaml:"window_sec" envconfig:"FHIR_WINDOW_SEC"`
+	Retries        int     `yaml:"retries" envconfig:"FHIR_RETRIES"`
+	RetryBackoffMs int     `yaml:"retry_backoff_ms" envconfig:"FHIR_RETRY_BACKOFF_MS"`
+	TimeoutMs      int     `yaml:"timeout_ms" envconfig:"FHIR_TIMEOUT_MS"`
+}
+
+// export запись живых сессий в файлы (dir = "" - запись выключена)
+type export struct {
+	Dir string `yaml:"dir" envconfig:"EXPORT_DIR"`
+	// Format формат файлов: edf (EDF+ с аннотациями), json (JSON Lines), fhir (NDJSON)
+	Format string `yaml:"format" envconfig:"EXPORT_FORMAT"`
+	// PatientID код пациентки в заголовке EDF+
+	PatientID string `yaml:"patient_id" envconfig:"EXPORT_PATIENT_ID"`
 }
 
 type log struct {
@@ -33,6 +226,8 @@ type server struct {
 type websocket struct {
 	Addr string `yaml:"addr" envconfig:"WEBSOCKET_ADDR"`
 	Port string `yaml:"port" envconfig:"WEBSOCKET_PORT"`
+	// Encoding формат сообщений по умолчанию: json, msgpack, protobuf или cbor
+	Encoding string `yaml:"encoding" envconfig:"WEBSOCKET_ENCODING"`
 }
 
 func ReadConfig(path string) (*Config, error) {
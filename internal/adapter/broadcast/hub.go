@@ -0,0 +1,57 @@
+package broadcast
+
+import (
+	"backend_gen/internal/ports/websocket"
+	"log/slog"
+	"sync"
+)
+
+// Размер очереди подписчика: около 8 секунд сообщений при шаге 120 мс
+const subscriberBuffer = 64
+
+// hub раздаёт сообщения подписчикам. Медленный подписчик не задерживает
+// генерацию: если его очередь заполнена, сообщение для него пропускается
+type hub struct {
+	mu          sync.Mutex
+	subscribers map[chan websocket.MessageData]int // канал -> пропущено сообщений
+}
+
+// NewHub создает раздатчик сообщений
+func NewHub() websocket.Broadcaster {
+	return &hub{subscribers: make(map[chan websocket.MessageData]int)}
+}
+
+func (h *hub) Publish(message websocket.MessageData) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subscribers {
+		select {
+		case ch <- message:
+		default:
+			h.subscribers[ch]++
+		}
+	}
+}
+
+func (h *hub) Subscribe() (<-chan websocket.MessageData, func()) {
+	ch := make(chan websocket.MessageData, subscriberBuffer)
+
+	h.mu.Lock()
+	h.subscribers[ch] = 0
+	count := len(h.subscribers)
+	h.mu.Unlock()
+	slog.Info("Stream subscriber added", "subscribers", count)
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			dropped := h.subscribers[ch]
+			delete(h.subscribers, ch)
+			count := len(h.subscribers)
+			h.mu.Unlock()
+			slog.Info("Stream subscriber removed", "subscribers", count, "dropped", dropped)
+		})
+	}
+}
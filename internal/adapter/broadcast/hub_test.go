@@ -0,0 +1,73 @@
+package broadcast
+
+import (
+	"backend_gen/internal/ports/websocket"
+	"testing"
+)
+
+func message(sec float64) websocket.MessageData {
+	return websocket.MessageData{SensorID: "s1", SecFromStart: sec}
+}
+
+func TestHubFanOut(t *testing.T) {
+	h := NewHub()
+	a, unsubscribeA := h.Subscribe()
+	b, unsubscribeB := h.Subscribe()
+	defer unsubscribeB()
+
+	h.Publish(message(1))
+	for name, ch := range map[string]<-chan websocket.MessageData{"a": a, "b": b} {
+		select {
+		case m := <-ch:
+			if m.SecFromStart != 1 {
+				t.Errorf("%s got %v", name, m.SecFromStart)
+			}
+		default:
+			t.Errorf("%s got nothing", name)
+		}
+	}
+
+	// После отписки сообщения не приходят; повторная отписка безопасна
+	unsubscribeA()
+	unsubscribeA()
+	h.Publish(message(2))
+	select {
+	case m := <-a:
+		t.Errorf("unsubscribed channel got %v", m.SecFromStart)
+	default:
+	}
+	if m := <-b; m.SecFromStart != 2 {
+		t.Errorf("b got %v, want 2", m.SecFromStart)
+	}
+}
+
+func TestHubSlowSubscriber(t *testing.T) {
+	h := NewHub()
+	slow, unsubscribeSlow := h.Subscribe()
+	defer unsubscribeSlow()
+	fast, unsubscribeFast := h.Subscribe()
+	defer unsubscribeFast()
+
+	// Переполненная очередь не блокирует Publish: лишние сообщения пропускаются
+	// только для медленного подписчика
+	for i := range subscriberBuffer + 10 {
+		h.Publish(message(float64(i)))
+		if m := <-fast; m.SecFromStart != float64(i) {
+			t.Fatalf("fast subscriber got %v, want %d", m.SecFromStart, i)
+		}
+	}
+	if len(slow) != subscriberBuffer {
+		t.Fatalf("slow queue holds %d messages, want %d", len(slow), subscriberBuffer)
+	}
+	if m := <-slow; m.SecFromStart != 0 {
+		t.Errorf("slow subscriber starts with %v, want the oldest message", m.SecFromStart)
+	}
+	// Счётчик пропусков ведётся по подписчику: у быстрого он нулевой
+	var dropped []int
+	for _, n := range h.(*hub).subscribers {
+		dropped = append(dropped, n)
+	}
+	if dropped[0]+dropped[1] != 10 || dropped[0]*dropped[1] != 0 {
+		t.Errorf("dropped per subscriber = %v, want 10 and 0", dropped)
+	}
+}
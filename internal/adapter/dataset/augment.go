@@ -0,0 +1,280 @@
+package dataset
+
+import (
+	"math"
+	"math/rand/v2"
+	"sort"
+)
+
+// Диапазон значений монитора: FHR вне него не встречается в датасете
+const (
+	fhrFloor   = 50.0
+	fhrCeiling = 210.0
+	toneLimit  = 100.0
+)
+
+// Параметры поиска схваток по умолчанию: подъём тонуса над базальным уровнем
+// записи не меньше ContractionRise, длящийся не меньше ContractionMinDuration секунд
+const (
+	ContractionRise        = 10.0
+	ContractionMinDuration = 30.0
+)
+
+// Episode интервал записи, секунды
+type Episode struct {
+	Start, End float64
+}
+
+// FindContractions находит схватки: подъём тонуса над медианой записи не меньше
+// rise, длящийся не меньше minDuration секунд без пропусков
+func FindContractions(s Series, rise, minDuration float64) []Episode {
+	var values []float64
+	for _, v := range s.Value {
+		if v > 0 {
+			values = append(values, v)
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	threshold := Median(values) + rise
+
+	var episodes []Episode
+	start, inside := 0.0, false
+	for i, t := range s.Time {
+		above := s.Value[i] > threshold && (i == 0 || t-s.Time[i-1] <= 1)
+		switch {
+		case above && !inside:
+			start, inside = t, true
+		case !above && inside:
+			if s.Time[i-1]-start >= minDuration {
+				episodes = append(episodes, Episode{start, s.Time[i-1]})
+			}
+			inside = false
+		}
+	}
+	if last := s.Time[len(s.Time)-1]; inside && last-start >= minDuration {
+		episodes = append(episodes, Episode{start, last})
+	}
+	return episodes
+}
+
+// ContractionShapes вырезает схватки из каналов тонуса (с запасом margin секунд
+// по краям) как посекундные отклонения от медианы записи
+func ContractionShapes(uterus []Series, rise, minDuration, margin float64) [][]float64 {
+	var shapes [][]float64
+	for _, s := range uterus {
+		episodes := FindContractions(s, rise, minDuration)
+		if len(episodes) == 0 {
+			continue
+		}
+		var values []float64
+		for _, v := range s.Value {
+			if v > 0 {
+				values = append(values, v)
+			}
+		}
+		base := Median(values)
+		for _, e := range episodes {
+			var shape []float64
+			for t := math.Max(e.Start-margin, s.Time[0]); t <= math.Min(e.End+margin, s.Duration()); t++ {
+				v, ok := s.At(t)
+				if !ok {
+					v = base
+				}
+				shape = append(shape, math.Max(v-base, 0))
+			}
+			shapes = append(shapes, shape)
+		}
+	}
+	return shapes
+}
+
+// ClassShapes собирает схватки из каналов тонуса всех записей класса class в root.
+// Нечитаемые файлы пропускаются
+func ClassShapes(root, class string) ([][]float64, error) {
+	files, err := ScanFiles(root)
+	if err != nil {
+		return nil, err
+	}
+	var uterus []Series
+	for _, file := range files {
+		if file.Class != class || file.Channel != ChannelUterus {
+			continue
+		}
+		if s, err := ReadSeries(file.Path); err == nil && len(s.Time) > 0 {
+			uterus = append(uterus, s)
+		}
+	}
+	return ContractionShapes(uterus, ContractionRise, ContractionMinDuration, 20), nil
+}
+
+// AugmentOptions параметры аугментации. Нулевое значение параметра выключает
+// соответствующее преобразование
+type AugmentOptions struct {
+	// TimeWarp наибольшее относительное изменение скорости времени (0.1 = ±10%)
+	TimeWarp float64
+	// BaselineShift наибольший сдвиг базального ритма FHR, уд/мин
+	BaselineShift float64
+	// VariabilityMin, VariabilityMax диапазон множителя вариабельности FHR
+	VariabilityMin, VariabilityMax float64
+	// LossPerHour эпизодов потери сигнала FHR в час (5-30 с)
+	LossPerHour float64
+	// HalvingPerHour эпизодов регистрации половинной частоты в час (3-10 с)
+	HalvingPerHour float64
+	// ContractionsPerHour добавляемых схваток в час из Shapes
+	ContractionsPerHour float64
+	// Shapes схватки записей того же класса (ContractionShapes)
+	Shapes [][]float64
+}
+
+// Augment создает вариант записи с тем же классом и пациентом. Результат
+// зависит только от rng, поэтому варианты воспроизводимы по seed
+func Augment(rec *Recording, opts AugmentOptions, rng *rand.Rand) *Recording {
+	out := &Recording{Class: rec.Class, Patient: rec.Patient, ID: rec.ID}
+
+	// Деформация времени: общий множитель скорости и медленная волна
+	speed := 1 + uniform(rng, -opts.TimeWarp, opts.TimeWarp)
+	wave := uniform(rng, 0, opts.TimeWarp/2)
+	period := uniform(rng, 120, 600)
+	phase := uniform(rng, 0, 2*math.Pi)
+	var source []float64 // время исходной записи для каждой секунды результата
+	for t, k := 1.0, 0; t <= rec.Duration(); k++ {
+		source = append(source, t)
+		t += speed * (1 + wave*math.Sin(2*math.Pi*float64(k)/period+phase))
+	}
+	resample := func(s Series) Series {
+		var r Series
+		for k, t := range source {
+			if v, ok := s.At(t); ok {
+				r.Time = append(r.Time, float64(k+1))
+				r.Value = append(r.Value, v)
+			}
+		}
+		return r
+	}
+
+	shift := uniform(rng, -opts.BaselineShift, opts.BaselineShift)
+	scale := 1.0
+	if opts.VariabilityMax > 0 {
+		scale = uniform(rng, opts.VariabilityMin, opts.VariabilityMax)
+	}
+	fhr := func(s Series) Series {
+		r := resample(s)
+		baseline := movingAverage(r, 60)
+		for i, v := range r.Value {
+			if v == 0 {
+				continue // потеря сигнала в исходной записи
+			}
+			v = baseline[i] + (v-baseline[i])*scale + shift
+			r.Value[i] = math.Min(math.Max(v, fhrFloor), fhrCeiling)
+		}
+		return r
+	}
+
+	out.FHR = fhr(rec.FHR)
+	if rec.FHR2 != nil {
+		fhr2 := fhr(*rec.FHR2)
+		out.FHR2 = &fhr2
+	}
+	if rec.MHR != nil {
+		mhr := resample(*rec.MHR)
+		out.MHR = &mhr
+	}
+	out.Uterus = resample(rec.Uterus)
+
+	hours := float64(len(source)) / 3600
+	if len(opts.Shapes) > 0 {
+		for range events(rng, opts.ContractionsPerHour*hours) {
+			shape := opts.Shapes[rng.IntN(len(opts.Shapes))]
+			addShape(&out.Uterus, shape, uniform(rng, 1, float64(len(source))-float64(len(shape))))
+		}
+	}
+
+	// Артефакты FHR: половинная частота, затем потеря сигнала
+	for range events(rng, opts.HalvingPerHour*hours) {
+		start := uniform(rng, 1, float64(len(source)))
+		end := start + uniform(rng, 3, 10)
+		for i, t := range out.FHR.Time {
+			// Потеря сигнала (0) остаётся потерей, а не становится нижней границей
+			if t >= start && t < end && out.FHR.Value[i] != 0 {
+				out.FHR.Value[i] = math.Max(out.FHR.Value[i]/2, fhrFloor)
+			}
+		}
+	}
+	for range events(rng, opts.LossPerHour*hours) {
+		start := uniform(rng, 1, float64(len(source)))
+		removeRange(&out.FHR, start, start+uniform(rng, 5, 30))
+	}
+	return out
+}
+
+// events возвращает число событий при среднем mean: целая часть плюс
+// ещё одно событие с вероятностью дробной части
+func events(rng *rand.Rand, mean float64) int {
+	if mean <= 0 {
+		return 0
+	}
+	n := int(mean)
+	if rng.Float64() < mean-float64(n) {
+		n++
+	}
+	return n
+}
+
+func uniform(rng *rand.Rand, low, high float64) float64 {
+	if high <= low {
+		return low
+	}
+	return low + rng.Float64()*(high-low)
+}
+
+// movingAverage центрированное скользящее среднее по окну window отсчётов (без нулей)
+func movingAverage(s Series, window int) []float64 {
+	n := len(s.Value)
+	sums := make([]float64, n+1)
+	counts := make([]int, n+1)
+	for i, v := range s.Value {
+		sums[i+1], counts[i+1] = sums[i], counts[i]
+		if v != 0 {
+			sums[i+1] += v
+			counts[i+1]++
+		}
+	}
+	avg := make([]float64, n)
+	for i := range avg {
+		lo, hi := max(i-window/2, 0), min(i+window/2+1, n)
+		if c := counts[hi] - counts[lo]; c > 0 {
+			avg[i] = (sums[hi] - sums[lo]) / float64(c)
+		} else {
+			avg[i] = s.Value[i]
+		}
+	}
+	return avg
+}
+
+// addShape прибавляет схватку к тонусу начиная с секунды start
+func addShape(s *Series, shape []float64, start float64) {
+	i := sort.SearchFloat64s(s.Time, start)
+	for ; i < len(s.Time); i++ {
+		k := int(s.Time[i] - start)
+		if k >= len(shape) {
+			break
+		}
+		if s.Value[i] > 0 {
+			s.Value[i] = math.Min(s.Value[i]+shape[k], toneLimit)
+		}
+	}
+}
+
+// removeRange удаляет отсчёты в интервале [start, end)
+func removeRange(s *Series, start, end float64) {
+	r := Series{Step: s.Step}
+	for i, t := range s.Time {
+		if t < start || t >= end {
+			r.Time = append(r.Time, t)
+			r.Value = append(r.Value, s.Value[i])
+		}
+	}
+	*s = r
+}
@@ -0,0 +1,114 @@
+package dataset
+
+import (
+	"math/rand/v2"
+	"reflect"
+	"testing"
+)
+
+func TestMedian(t *testing.T) {
+	values := []float64{3, 1, 2, 10}
+	if got := Median(values); got != 2.5 {
+		t.Errorf("median = %v, want 2.5", got)
+	}
+	if !reflect.DeepEqual(values, []float64{3, 1, 2, 10}) {
+		t.Errorf("median reordered its input: %v", values)
+	}
+	if got := Median([]float64{5, 1, 3}); got != 3 {
+		t.Errorf("median = %v, want 3", got)
+	}
+	if got := Median(nil); got != 0 {
+		t.Errorf("median of nothing = %v, want 0", got)
+	}
+}
+
+// uterusWithContractions тонус 10 на 600 с с подъёмами до 30 на [100, 140) и [300, 320)
+func uterusWithContractions() Series {
+	s := seconds(600, 10)
+	for i, t := range s.Time {
+		if t >= 100 && t < 140 || t >= 300 && t < 320 {
+			s.Value[i] = 30
+		}
+	}
+	return s
+}
+
+func TestFindContractions(t *testing.T) {
+	// Подъём на 20 с короче минимальной длительности
+	episodes := FindContractions(uterusWithContractions(), ContractionRise, ContractionMinDuration)
+	if want := []Episode{{100, 139}}; !reflect.DeepEqual(episodes, want) {
+		t.Errorf("episodes = %v, want %v", episodes, want)
+	}
+
+	shapes := ContractionShapes([]Series{uterusWithContractions()}, ContractionRise, ContractionMinDuration, 5)
+	if len(shapes) != 1 || len(shapes[0]) != 50 {
+		t.Fatalf("shapes = %d, first of %d s", len(shapes), len(shapes[0]))
+	}
+	// Отклонение от медианы: 0 на запасе по краям, 20 внутри схватки
+	if shapes[0][0] != 0 || shapes[0][5] != 20 || shapes[0][49] != 0 {
+		t.Errorf("shape edges %v, %v, %v", shapes[0][0], shapes[0][5], shapes[0][49])
+	}
+}
+
+// augmentRecording 10 минут FHR 140 с потерей сигнала (нули) на [100, 200)
+func augmentRecording() *Recording {
+	fhr := seconds(600, 140)
+	for i, t := range fhr.Time {
+		if t >= 100 && t < 200 {
+			fhr.Value[i] = 0
+		}
+	}
+	return &Recording{Class: ClassHypoxia, Patient: "7", ID: "1", FHR: fhr, Uterus: uterusWithContractions()}
+}
+
+func TestAugmentHalvingKeepsLoss(t *testing.T) {
+	rec := augmentRecording()
+	// Эпизодов столько, что половинная частота покрывает всю запись, кроме краёв
+	out := Augment(rec, AugmentOptions{HalvingPerHour: 36000}, rand.New(rand.NewPCG(1, 2)))
+	if len(out.FHR.Time) != len(rec.FHR.Time) {
+		t.Fatalf("%d FHR samples, want %d", len(out.FHR.Time), len(rec.FHR.Time))
+	}
+	for i, v := range out.FHR.Value {
+		lost := rec.FHR.Value[i] == 0
+		switch {
+		case lost && v != 0:
+			t.Fatalf("lost sample at %vs became %v", out.FHR.Time[i], v)
+		case !lost && out.FHR.Time[i] > 20 && out.FHR.Time[i] < 580 && (v < fhrFloor || v > 70):
+			t.Fatalf("halved sample at %vs = %v, want 50..70", out.FHR.Time[i], v)
+		}
+	}
+}
+
+func TestAugmentReproducible(t *testing.T) {
+	opts := AugmentOptions{
+		TimeWarp:            0.1,
+		BaselineShift:       10,
+		VariabilityMin:      0.5,
+		VariabilityMax:      1.5,
+		LossPerHour:         6,
+		HalvingPerHour:      6,
+		ContractionsPerHour: 12,
+		Shapes:              ContractionShapes([]Series{uterusWithContractions()}, ContractionRise, ContractionMinDuration, 5),
+	}
+	a := Augment(augmentRecording(), opts, rand.New(rand.NewPCG(42, 0)))
+	b := Augment(augmentRecording(), opts, rand.New(rand.NewPCG(42, 0)))
+	if !reflect.DeepEqual(a, b) {
+		t.Fatal("same seed gave different variants")
+	}
+	if a.Class != ClassHypoxia || a.Patient != "7" || a.ID != "1" {
+		t.Errorf("variant identity %s/%s/%s", a.Class, a.Patient, a.ID)
+	}
+	if d := a.Duration(); d < 540 || d > 660 {
+		t.Errorf("warped duration = %v, want within ±10%% of 600", d)
+	}
+	for i, v := range a.FHR.Value {
+		if v != 0 && (v < fhrFloor || v > fhrCeiling) {
+			t.Errorf("FHR at %vs = %v out of monitor range", a.FHR.Time[i], v)
+		}
+	}
+	for i, v := range a.Uterus.Value {
+		if v < 0 || v > toneLimit {
+			t.Errorf("uterus at %vs = %v out of range", a.Uterus.Time[i], v)
+		}
+	}
+}
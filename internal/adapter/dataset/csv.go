@@ -0,0 +1,122 @@
+package dataset
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Имена колонки времени в секундах
+var timeColumns = []string{"timesec", "time", "seconds", "sec", "t"}
+
+// importCSV читает CSV с колонкой времени в секундах и колонками каналов
+// (fhr, toco, mhr, fhr2 и их варианты). Разделитель - запятая, точка с запятой
+// или табуляция. Пустые значения и NaN считаются потерей сигнала
+func importCSV(path string) (*Recording, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	buffered := bufio.NewReader(file)
+	first, err := buffered.Peek(1024)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	reader := csv.NewReader(buffered)
+	reader.Comma = detectDelimiter(string(first))
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	timeIndex := -1
+	for _, name := range timeColumns {
+		for i, column := range header {
+			if timeIndex < 0 && normalizeLabel(column) == name {
+				timeIndex = i
+			}
+		}
+	}
+	if timeIndex < 0 {
+		return nil, fmt.Errorf("no time column in header %v", header)
+	}
+
+	names := []string{"fhr", "uterus", "fhr2", "mhr"}
+	columns := make([]int, len(names))
+	builders := make([]seriesBuilder, len(names))
+	for k, name := range names {
+		columns[k] = channelIndex(header, name)
+	}
+	if columns[0] < 0 {
+		return nil, fmt.Errorf("no FHR column in header %v", header)
+	}
+
+	origin := math.NaN()
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		t, err := parseValue(row[timeIndex])
+		if err != nil || math.IsNaN(t) {
+			return nil, fmt.Errorf("line %d: invalid time %q", line, row[timeIndex])
+		}
+		if math.IsNaN(origin) {
+			origin = t
+		}
+		for k, column := range columns {
+			if column < 0 {
+				continue
+			}
+			v, err := parseValue(row[column])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid %s value %q", line, header[column], row[column])
+			}
+			if err := builders[k].add(t-origin+timeOrigin, v); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+	}
+
+	return &Recording{
+		FHR:    builders[0].series,
+		Uterus: builders[1].series,
+		FHR2:   optionalSeries(builders[2].series),
+		MHR:    optionalSeries(builders[3].series),
+	}, nil
+}
+
+// detectDelimiter выбирает разделитель по первой строке файла
+func detectDelimiter(sample string) rune {
+	line, _, _ := strings.Cut(sample, "\n")
+	best, count := ',', strings.Count(line, ",")
+	for _, d := range []rune{';', '\t'} {
+		if n := strings.Count(line, string(d)); n > count {
+			best, count = d, n
+		}
+	}
+	return best
+}
+
+// parseValue разбирает значение канала; пустое значение и NaN - потеря сигнала.
+// Десятичная запятая допускается (экспорт с точкой с запятой)
+func parseValue(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	switch strings.ToLower(s) {
+	case "", "nan", "null", "-":
+		return math.NaN(), nil
+	}
+	return strconv.ParseFloat(strings.Replace(s, ",", ".", 1), 64)
+}
@@ -0,0 +1,72 @@
+package dataset
+
+import (
+	"backend_gen/internal/adapter/edf"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// importEDF читает запись EDF/EDF+. Каналы находятся по меткам сигналов,
+// время отсчётов - по частоте сигнала и отметкам времени записей EDF+
+func importEDF(path string) (*Recording, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	f, err := edf.Read(file)
+	if err != nil {
+		return nil, err
+	}
+	labels := make([]string, len(f.Header.Signals))
+	for i, s := range f.Header.Signals {
+		labels[i] = s.Label
+	}
+
+	// Все каналы сдвигаются на начало первой записи, чтобы сохранить их взаимное положение
+	origin := 0.0
+	if len(f.Onsets) > 0 {
+		origin = f.Onsets[0]
+	}
+	read := func(name string) (Series, error) {
+		i := channelIndex(labels, name)
+		if i < 0 {
+			return Series{}, nil
+		}
+		var b seriesBuilder
+		for n, v := range f.Samples[i] {
+			if err := b.add(f.Time(i, n)-origin+timeOrigin, v); err != nil {
+				return Series{}, fmt.Errorf("signal %s: %w", labels[i], err)
+			}
+		}
+		return b.series, nil
+	}
+
+	rec := &Recording{}
+	if rec.FHR, err = read("fhr"); err != nil {
+		return nil, err
+	}
+	if len(rec.FHR.Time) == 0 {
+		return nil, fmt.Errorf("no FHR signal among %v", labels)
+	}
+	if rec.Uterus, err = read("uterus"); err != nil {
+		return nil, err
+	}
+	fhr2, err := read("fhr2")
+	if err != nil {
+		return nil, err
+	}
+	mhr, err := read("mhr")
+	if err != nil {
+		return nil, err
+	}
+	rec.FHR2, rec.MHR = optionalSeries(fhr2), optionalSeries(mhr)
+
+	// В EDF+ первое подполе patient - код пациентки (X = не указан)
+	if code, _, _ := strings.Cut(f.Header.Patient, " "); code != "" && code != "X" {
+		rec.Patient = code
+	}
+	return rec, nil
+}
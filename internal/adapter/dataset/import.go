@@ -0,0 +1,128 @@
+package dataset
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+)
+
+// Форматы импортируемых записей
+const (
+	FormatEDF   = "edf"   // EDF/EDF+ с каналами FHR, TOCO/UC, MHR
+	FormatCSV   = "csv"   // CSV с колонкой времени и колонками каналов
+	FormatJSONL = "jsonl" // JSON Lines, записанные генератором (офлайн CLI, запись сессий)
+)
+
+// timeOrigin время первого отсчёта: в CSV датасета отсчёты начинаются с time_sec = 1,
+// импортированные записи приводятся к тому же началу
+const timeOrigin = 1.0
+
+// Import читает запись в одном из поддерживаемых форматов. Пустой format
+// определяется по расширению файла. Класс записи задаёт вызывающий
+func Import(path, format string) (*Recording, error) {
+	if format == "" {
+		format = DetectFormat(path)
+	}
+
+	var (
+		rec *Recording
+		err error
+	)
+	switch format {
+	case FormatEDF:
+		rec, err = importEDF(path)
+	case FormatCSV:
+		rec, err = importCSV(path)
+	case FormatJSONL:
+		rec, err = importJSONL(path)
+	default:
+		return nil, fmt.Errorf("%s: unknown recording format %q", path, format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if rec.ID == "" {
+		rec.ID = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if len(rec.FHR.Time) == 0 {
+		return nil, fmt.Errorf("%s: recording has no FHR samples", path)
+	}
+	for _, s := range []*Series{&rec.FHR, &rec.Uterus, rec.FHR2, rec.MHR} {
+		if s != nil {
+			s.estimateStep()
+		}
+	}
+	return rec, nil
+}
+
+// DetectFormat определяет формат записи по расширению файла
+func DetectFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".edf", ".bdf":
+		return FormatEDF
+	case ".jsonl", ".ndjson", ".json":
+		return FormatJSONL
+	}
+	return FormatCSV
+}
+
+// Имена каналов в экспорте разных мониторов. Порядок задаёт приоритет:
+// в EDF генератора UC - интенсивность схваток, а тонус матки - TOCO
+var channelNames = map[string][]string{
+	"fhr":    {"fhr", "fhr1", "bpm", "bpmchild", "hr1", "fetalheartrate"},
+	"fhr2":   {"fhr2", "bpmchild2", "hr2"},
+	"mhr":    {"mhr", "bpmmother", "maternalhr", "maternalheartrate"},
+	"uterus": {"toco", "uterus", "ua", "uterineactivity", "uc"},
+}
+
+// channelIndex ищет канал name среди меток labels; -1 - канала нет
+func channelIndex(labels []string, name string) int {
+	for _, candidate := range channelNames[name] {
+		for i, label := range labels {
+			if normalizeLabel(label) == candidate {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// normalizeLabel приводит метку канала к виду без регистра, пробелов и знаков
+func normalizeLabel(label string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		}
+		return -1
+	}, label)
+}
+
+// seriesBuilder собирает ряд, пропуская отсутствующие значения (потеря сигнала)
+type seriesBuilder struct {
+	series Series
+}
+
+// add добавляет отсчёт; время должно возрастать
+func (b *seriesBuilder) add(t, v float64) error {
+	if n := len(b.series.Time); n > 0 && t <= b.series.Time[n-1] {
+		return fmt.Errorf("time is not increasing at %.3fs", t)
+	}
+	if math.IsNaN(v) {
+		return nil
+	}
+	b.series.Time = append(b.series.Time, t)
+	b.series.Value = append(b.series.Value, v)
+	return nil
+}
+
+// optionalSeries возвращает nil для пустого ряда
+func optionalSeries(s Series) *Series {
+	if len(s.Time) == 0 {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,84 @@
+package dataset
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+)
+
+// jsonlMessage сообщение генератора; null - потеря сигнала
+type jsonlMessage struct {
+	SecFromStart *float64 `json:"secFromStart"`
+	Data         struct {
+		BPMChild  *float64 `json:"bpmChild"`
+		Uterus    *float64 `json:"uterus"`
+		BPMMother *float64 `json:"bpmMother"`
+		BPMChild2 *float64 `json:"bpmChild2"`
+	} `json:"data"`
+}
+
+// importJSONL читает сообщения генератора в формате JSON Lines
+// (офлайн CLI с -format json или запись сессий с export.format = json)
+func importJSONL(path string) (*Recording, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var fhr, uterus, fhr2, mhr seriesBuilder
+	origin := math.NaN()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var m jsonlMessage
+		if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if m.SecFromStart == nil {
+			return nil, fmt.Errorf("line %d: no secFromStart", line)
+		}
+		if math.IsNaN(origin) {
+			origin = *m.SecFromStart
+		}
+
+		t := *m.SecFromStart - origin + timeOrigin
+		for _, c := range []struct {
+			b *seriesBuilder
+			v *float64
+		}{
+			{&fhr, m.Data.BPMChild},
+			{&uterus, m.Data.Uterus},
+			{&fhr2, m.Data.BPMChild2},
+			{&mhr, m.Data.BPMMother},
+		} {
+			if err := c.b.add(t, value(c.v)); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return &Recording{
+		FHR:    fhr.series,
+		Uterus: uterus.series,
+		FHR2:   optionalSeries(fhr2.series),
+		MHR:    optionalSeries(mhr.series),
+	}, nil
+}
+
+// value возвращает NaN для отсутствующего значения
+func value(v *float64) float64 {
+	if v == nil {
+		return math.NaN()
+	}
+	return *v
+}
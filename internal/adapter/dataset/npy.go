@@ -0,0 +1,60 @@
+package dataset
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// WriteNPY записывает массив в формате NumPy .npy (версия 1.0, little-endian, C-порядок).
+// values - элементы массива формы shape подряд
+func WriteNPY[T float32 | int64](path string, shape []int, values []T) error {
+	var descr string
+	switch any(values).(type) {
+	case []float32:
+		descr = "<f4"
+	case []int64:
+		descr = "<i8"
+	}
+
+	size := 1
+	dims := make([]string, len(shape))
+	for i, d := range shape {
+		size *= d
+		dims[i] = strconv.Itoa(d)
+	}
+	if size != len(values) {
+		return fmt.Errorf("npy shape %v does not match %d values", shape, len(values))
+	}
+	tuple := "(" + strings.Join(dims, ", ")
+	if len(shape) == 1 {
+		tuple += ","
+	}
+	tuple += ")"
+
+	// Заголовок дополняется пробелами так, чтобы данные начинались с границы 64 байт
+	header := fmt.Sprintf("{'descr': '%s', 'fortran_order': False, 'shape': %s, }", descr, tuple)
+	const prefix = 10 // magic, версия и длина заголовка
+	header += strings.Repeat(" ", 63-(prefix+len(header))%64) + "\n"
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(file)
+	w.WriteString("\x93NUMPY\x01\x00")
+	binary.Write(w, binary.LittleEndian, uint16(len(header)))
+	w.WriteString(header)
+	if err := binary.Write(w, binary.LittleEndian, values); err != nil {
+		file.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,107 @@
+package dataset
+
+import (
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
+// readNPY разбирает файл .npy версии 1.0: заголовок-словарь и байты данных
+func readNPY(t *testing.T, path string) (string, []byte) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) < 10 || string(data[:8]) != "\x93NUMPY\x01\x00" {
+		t.Fatalf("%s: bad magic %q", path, data[:min(len(data), 8)])
+	}
+	size := int(binary.LittleEndian.Uint16(data[8:10]))
+	if (10+size)%64 != 0 {
+		t.Errorf("%s: data starts at %d, not aligned to 64 bytes", path, 10+size)
+	}
+	header := data[10 : 10+size]
+	if header[size-1] != '\n' {
+		t.Errorf("%s: header does not end with a newline", path)
+	}
+	return string(bytes.TrimRight(header, " \n")), data[10+size:]
+}
+
+func TestWriteNPYFloat32(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "x.npy")
+	values := []float32{1, 2.5, float32(math.NaN()), -4, 0, 140.25}
+	if err := WriteNPY(path, []int{2, 1, 3}, values); err != nil {
+		t.Fatal(err)
+	}
+	header, data := readNPY(t, path)
+	if want := "{'descr': '<f4', 'fortran_order': False, 'shape': (2, 1, 3), }"; header != want {
+		t.Errorf("header = %q, want %q", header, want)
+	}
+	got := make([]float32, len(values))
+	if err := binary.Read(bytes.NewReader(data), binary.LittleEndian, got); err != nil {
+		t.Fatal(err)
+	}
+	for i := range values {
+		if got[i] != values[i] && !(math.IsNaN(float64(got[i])) && math.IsNaN(float64(values[i]))) {
+			t.Errorf("value %d = %v, want %v", i, got[i], values[i])
+		}
+	}
+}
+
+func TestWriteNPYInt64(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "y.npy")
+	values := []int64{0, 1, 1, 0}
+	if err := WriteNPY(path, []int{4}, values); err != nil {
+		t.Fatal(err)
+	}
+	header, data := readNPY(t, path)
+	// Одномерная форма - кортеж с запятой
+	if want := "{'descr': '<i8', 'fortran_order': False, 'shape': (4,), }"; header != want {
+		t.Errorf("header = %q, want %q", header, want)
+	}
+	got := make([]int64, len(values))
+	if err := binary.Read(bytes.NewReader(data), binary.LittleEndian, got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, values) {
+		t.Errorf("values = %v, want %v", got, values)
+	}
+
+	if err := WriteNPY(path, []int{3}, values); err == nil {
+		t.Error("shape mismatch must be an error")
+	}
+}
+
+func TestWriteNPYGolden(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "x.npy")
+	if err := WriteNPY(path, []int{2, 2, 2}, []float32{140, 141, 10, 12, float32(math.NaN()), 150, 0, 30}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden := filepath.Join("testdata", "segments.npy")
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("read golden: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("npy output differs from %s", golden)
+	}
+}
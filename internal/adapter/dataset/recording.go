@@ -0,0 +1,231 @@
+package dataset
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// Классы записей датасета (имена корневых каталогов)
+const (
+	ClassRegular = "regular"
+	ClassHypoxia = "hypoxia"
+)
+
+// Series ряд отсчётов (в CSV датасета - с частотой 1 Гц). Пропуски во времени
+// означают потерю сигнала
+type Series struct {
+	Time  []float64
+	Value []float64
+	// Step номинальный шаг отсчётов, секунды (0 = 1 с). Задаётся по частоте
+	// источника, чтобы редкие отсчёты не принимались за пропуски
+	Step float64
+}
+
+// maxGap наибольший интервал между соседними отсчётами, который ещё
+// интерполируется: полтора шага, но не меньше секунды
+func (s Series) maxGap() float64 {
+	step := s.Step
+	if step <= 0 {
+		step = 1
+	}
+	return math.Max(1, 1.5*step)
+}
+
+// estimateStep задаёт Step по медиане интервалов между отсчётами
+func (s *Series) estimateStep() {
+	if len(s.Time) < 2 {
+		return
+	}
+	intervals := make([]float64, 0, len(s.Time)-1)
+	for i := 1; i < len(s.Time); i++ {
+		intervals = append(intervals, s.Time[i]-s.Time[i-1])
+	}
+	s.Step = Median(intervals)
+}
+
+// Median возвращает медиану значений (0 для пустого среза); values не изменяется
+func Median(values []float64) float64 {
+	n := len(values)
+	if n == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// Duration возвращает длительность ряда в секундах
+func (s Series) Duration() float64 {
+	if len(s.Time) == 0 {
+		return 0
+	}
+	return s.Time[len(s.Time)-1]
+}
+
+// At возвращает значение в момент t с линейной интерполяцией между соседними
+// отсчётами. ok = false, если t попадает в пропуск или за пределы ряда
+func (s Series) At(t float64) (float64, bool) {
+	n := len(s.Time)
+	if n == 0 || t < s.Time[0] || t > s.Time[n-1] {
+		return 0, false
+	}
+
+	// Индекс первого отсчёта со временем > t
+	lo, hi := 0, n
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if s.Time[mid] <= t {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	i := lo - 1
+	if i == n-1 || s.Time[i] == t {
+		return s.Value[i], true
+	}
+	if s.Time[i+1]-s.Time[i] > s.maxGap() {
+		return 0, false
+	}
+	frac := (t - s.Time[i]) / (s.Time[i+1] - s.Time[i])
+	return s.Value[i] + (s.Value[i+1]-s.Value[i])*frac, true
+}
+
+// Recording одна запись CTG пациента
+type Recording struct {
+	Class   string
+	Patient string
+	ID      string
+
+	FHR    Series
+	Uterus Series
+	FHR2   *Series // второй канал FHR, если монитор его экспортировал
+	MHR    *Series // пульс матери (только в импортированных записях)
+}
+
+// Duration возвращает длительность записи по самому длинному каналу
+func (r *Recording) Duration() float64 {
+	return math.Max(r.FHR.Duration(), r.Uterus.Duration())
+}
+
+// Span возвращает время первого и последнего отсчёта по каналам FHR и тонуса.
+// ok = false, если в записи нет отсчётов
+func (r *Recording) Span() (start, end float64, ok bool) {
+	for _, s := range []Series{r.FHR, r.Uterus} {
+		n := len(s.Time)
+		if n == 0 {
+			continue
+		}
+		if !ok || s.Time[0] < start {
+			start = s.Time[0]
+		}
+		if !ok || s.Time[n-1] > end {
+			end = s.Time[n-1]
+		}
+		ok = true
+	}
+	return start, end, ok
+}
+
+// LoadRecording читает запись id пациента patient класса class из каталога root
+func LoadRecording(root, class, patient, id string) (*Recording, error) {
+	dir := filepath.Join(root, class, patient)
+
+	fhr, err := ReadSeries(filepath.Join(dir, DirFHR, FileName(id, ChannelFHR)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read FHR: %w", err)
+	}
+	uterus, err := ReadSeries(filepath.Join(dir, DirUterus, FileName(id, ChannelUterus)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read uterus: %w", err)
+	}
+
+	rec := &Recording{
+		Class:   class,
+		Patient: patient,
+		ID:      id,
+		FHR:     fhr,
+		Uterus:  uterus,
+	}
+
+	fhr2, err := ReadSeries(filepath.Join(dir, DirFHR, FileName(id, ChannelFHR2)))
+	switch {
+	case err == nil:
+		rec.FHR2 = &fhr2
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, fmt.Errorf("failed to read second FHR: %w", err)
+	}
+
+	return rec, nil
+}
+
+// WriteRecording записывает каналы записи в root по структуре датасета,
+// как их читает LoadRecording
+func WriteRecording(root string, rec *Recording) error {
+	dir := filepath.Join(root, rec.Class, rec.Patient)
+	if err := WriteSeries(filepath.Join(dir, DirFHR, FileName(rec.ID, ChannelFHR)), rec.FHR); err != nil {
+		return err
+	}
+	if err := WriteSeries(filepath.Join(dir, DirUterus, FileName(rec.ID, ChannelUterus)), rec.Uterus); err != nil {
+		return err
+	}
+	if rec.FHR2 != nil {
+		return WriteSeries(filepath.Join(dir, DirFHR, FileName(rec.ID, ChannelFHR2)), *rec.FHR2)
+	}
+	return nil
+}
+
+// ReadSeries читает CSV с заголовком time_sec,value
+func ReadSeries(path string) (Series, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Series{}, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = 2
+
+	header, err := reader.Read()
+	if err != nil {
+		return Series{}, fmt.Errorf("%s: failed to read header: %w", path, err)
+	}
+	if header[0] != "time_sec" || header[1] != "value" {
+		return Series{}, fmt.Errorf("%s: unexpected header %v", path, header)
+	}
+
+	var series Series
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Series{}, fmt.Errorf("%s: %w", path, err)
+		}
+
+		t, err := strconv.ParseFloat(record[0], 64)
+		if err != nil {
+			return Series{}, fmt.Errorf("%s: invalid time %q: %w", path, record[0], err)
+		}
+		v, err := strconv.ParseFloat(record[1], 64)
+		if err != nil {
+			return Series{}, fmt.Errorf("%s: invalid value %q: %w", path, record[1], err)
+		}
+		series.Time = append(series.Time, t)
+		series.Value = append(series.Value, v)
+	}
+
+	series.estimateStep()
+	return series, nil
+}
@@ -0,0 +1,89 @@
+package dataset
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSeriesAt(t *testing.T) {
+	// 1 Гц, отсчёт 4 с потерян
+	s := Series{Time: []float64{1, 2, 3, 5, 6}, Value: []float64{10, 20, 30, 50, 60}}
+	cases := []struct {
+		t    float64
+		want float64
+		ok   bool
+	}{
+		{1, 10, true},
+		{1.5, 15, true},
+		{3, 30, true},
+		{4, 0, false},
+		{5.25, 52.5, true},
+		{6, 60, true},
+		{0.5, 0, false},
+		{6.5, 0, false},
+	}
+	for _, c := range cases {
+		v, ok := s.At(c.t)
+		if ok != c.ok || v != c.want {
+			t.Errorf("At(%v) = %v, %v; want %v, %v", c.t, v, ok, c.want, c.ok)
+		}
+	}
+}
+
+func TestSeriesAtSubHertz(t *testing.T) {
+	// Отсчёты раз в 4 с: между соседними интерполируем, пропуск в 8 с остаётся пропуском
+	s := Series{Time: []float64{1, 5, 9, 17}, Value: []float64{140, 144, 148, 150}}
+	s.estimateStep()
+	if s.Step != 4 {
+		t.Fatalf("step = %v, want 4", s.Step)
+	}
+	if v, ok := s.At(3); !ok || v != 142 {
+		t.Errorf("At(3) = %v, %v; want 142, true", v, ok)
+	}
+	if _, ok := s.At(13); ok {
+		t.Error("At(13) must fall into the gap")
+	}
+
+	// Без шага ряд считается секундным: весь ряд - пропуски
+	s.Step = 0
+	if _, ok := s.At(3); ok {
+		t.Error("At(3) without step must fall into the gap")
+	}
+}
+
+func TestImportSubHertzCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export.csv")
+	data := "time;FHR;TOCO\n0;140;10\n4;144;12\n8;;14\n12;148;16\n16;150;18\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rec, err := Import(path, "")
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if rec.ID != "export" || rec.FHR.Step != 4 || rec.Uterus.Step != 4 {
+		t.Fatalf("id %q, steps %v/%v", rec.ID, rec.FHR.Step, rec.Uterus.Step)
+	}
+	// Время приводится к началу датасета (1 с)
+	if v, ok := rec.Uterus.At(3); !ok || v != 11 {
+		t.Errorf("uterus At(3) = %v, %v; want 11, true", v, ok)
+	}
+	if _, ok := rec.FHR.At(9); ok {
+		t.Error("lost FHR sample must stay a gap")
+	}
+
+	// Запись в формате датасета и чтение обратно сохраняют шаг
+	root := t.TempDir()
+	rec.Class, rec.Patient = ClassRegular, "p1"
+	if err := WriteRecording(root, rec); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	loaded, err := LoadRecording(root, ClassRegular, "p1", rec.ID)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if loaded.FHR.Step != 4 || len(loaded.FHR.Time) != 4 {
+		t.Errorf("loaded FHR step %v, %d samples", loaded.FHR.Step, len(loaded.FHR.Time))
+	}
+}
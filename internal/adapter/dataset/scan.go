@@ -0,0 +1,173 @@
+package dataset
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Classes классы датасета в порядке обхода
+var Classes = []string{ClassRegular, ClassHypoxia}
+
+// Каталоги каналов внутри каталога пациента
+const (
+	DirFHR    = "bpm"
+	DirUterus = "uterus"
+)
+
+// Channel номер канала в имени файла: <id>_1.csv - FHR, <id>_2.csv - тонус матки,
+// <id>_3.csv и <id>_4.csv - второй канал FHR и тонуса (двойня)
+type Channel string
+
+const (
+	ChannelFHR     Channel = "1"
+	ChannelUterus  Channel = "2"
+	ChannelFHR2    Channel = "3"
+	ChannelUterus2 Channel = "4"
+)
+
+// File файл канала записи датасета
+type File struct {
+	Path    string
+	Class   string
+	Patient string
+	Dir     string // bpm или uterus
+	ID      string
+	// Channel номер канала; "" - имя файла не соответствует шаблону <id>_<n>.csv
+	Channel Channel
+}
+
+// ScanFiles обходит каталоги классов root/<класс>/<пациент>/{bpm,uterus}/*.csv.
+// Отсутствующий каталог класса пропускается
+func ScanFiles(root string) ([]File, error) {
+	var files []File
+	for _, class := range Classes {
+		patients, err := os.ReadDir(filepath.Join(root, class))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, patient := range patients {
+			if !patient.IsDir() {
+				continue
+			}
+			for _, dir := range []string{DirFHR, DirUterus} {
+				path := filepath.Join(root, class, patient.Name(), dir)
+				entries, err := os.ReadDir(path)
+				if os.IsNotExist(err) {
+					continue
+				}
+				if err != nil {
+					return nil, err
+				}
+				for _, entry := range entries {
+					if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".csv") {
+						continue
+					}
+					file := File{
+						Path:    filepath.Join(path, entry.Name()),
+						Class:   class,
+						Patient: patient.Name(),
+						Dir:     dir,
+					}
+					file.ID, file.Channel = parseFileName(entry.Name())
+					files = append(files, file)
+				}
+			}
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// FileName возвращает имя файла канала ch записи id
+func FileName(id string, ch Channel) string {
+	return id + "_" + string(ch) + ".csv"
+}
+
+// parseFileName разбирает имя <id>_<n>.csv
+func parseFileName(name string) (string, Channel) {
+	base := strings.TrimSuffix(name, ".csv")
+	i := strings.LastIndexByte(base, '_')
+	if i <= 0 {
+		return base, ""
+	}
+	switch channel := Channel(base[i+1:]); channel {
+	case ChannelFHR, ChannelUterus, ChannelFHR2, ChannelUterus2:
+		return base[:i], channel
+	}
+	return base, ""
+}
+
+// WriteSeries записывает ряд в CSV с заголовком time_sec,value
+func WriteSeries(path string, s Series) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	var b strings.Builder
+	b.WriteString("time_sec,value\n")
+	for i := range s.Time {
+		fmt.Fprintf(&b, "%g,%g\n", s.Time[i], s.Value[i])
+	}
+	return os.WriteFile(path, []byte(b.String()), 0o644)
+}
+
+// ListRecordings возвращает ID записей пациента по файлам всех каналов (bpm и uterus)
+// в порядке номеров. Ошибка - только если у пациента нет ни одного из каталогов
+func ListRecordings(root, class, patient string) ([]string, error) {
+	seen := make(map[string]bool)
+	found := false
+	for _, dir := range []string{DirFHR, DirUterus} {
+		entries, err := os.ReadDir(filepath.Join(root, class, patient, dir))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		found = true
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".csv") {
+				continue
+			}
+			if id, channel := parseFileName(entry.Name()); channel != "" {
+				seen[id] = true
+			}
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("patient %s/%s: %w", class, patient, os.ErrNotExist)
+	}
+
+	ids := make([]string, 0, len(seen))
+	for id := range seen {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return lessID(ids[i], ids[j]) })
+	return ids, nil
+}
+
+// lessID сравнивает ID записей: числовые - по номеру, остальные - как строки
+func lessID(a, b string) bool {
+	x, errA := strconv.ParseUint(a, 10, 64)
+	y, errB := strconv.ParseUint(b, 10, 64)
+	if errA == nil && errB == nil && x != y {
+		return x < y
+	}
+	return a < b
+}
+
+// missingIDs число пропущенных номеров между числовыми ID prev и next
+func missingIDs(prev, next string) int {
+	x, errA := strconv.ParseUint(prev, 10, 64)
+	y, errB := strconv.ParseUint(next, 10, 64)
+	if errA != nil || errB != nil || y <= x+1 {
+		return 0
+	}
+	return int(y - x - 1)
+}
@@ -0,0 +1,202 @@
+package dataset
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// Обработка стыков между записями при склейке
+const (
+	// GapNone записи идут встык
+	GapNone = "none"
+	// GapLoss между записями пауза без сигнала, как при снятом датчике
+	GapLoss = "loss"
+	// GapBridge пауза заполняется линейным переходом между концом и началом записей
+	GapBridge = "bridge"
+)
+
+// StitchOptions параметры склейки записей пациента
+type StitchOptions struct {
+	// Gap обработка стыков: none, loss или bridge
+	Gap string
+	// GapSec длительность паузы между записями, секунды (для loss и bridge)
+	GapSec float64
+}
+
+// LoadPatient склеивает все записи пациента в порядке ID в одну непрерывную сессию.
+// Запись берётся, если есть хотя бы один из её каналов: отсутствующий канал
+// на её участке - потеря сигнала. Нечитаемые записи пропускаются и, как и
+// пропуски в нумерации ID, заменяются паузой по настройке Gap
+func LoadPatient(root, class, patient string, opts StitchOptions) (*Recording, error) {
+	ids, err := ListRecordings(root, class, patient)
+	if err != nil {
+		return nil, err
+	}
+
+	var recordings []*Recording
+	for _, id := range ids {
+		rec, err := loadChannels(root, class, patient, id)
+		if err != nil {
+			slog.Warn("Recording skipped while stitching", "class", class, "patient", patient, "recording", id, "error", err)
+			continue
+		}
+		recordings = append(recordings, rec)
+	}
+	if len(recordings) == 0 {
+		return nil, fmt.Errorf("patient %s/%s has no readable recordings", class, patient)
+	}
+	return Stitch(recordings, opts)
+}
+
+// loadChannels читает имеющиеся каналы записи. Если основного канала нет,
+// используется второй (<id>_3.csv для FHR, <id>_4.csv для тонуса)
+func loadChannels(root, class, patient, id string) (*Recording, error) {
+	dir := filepath.Join(root, class, patient)
+	read := func(sub string, ch Channel) (*Series, error) {
+		s, err := ReadSeries(filepath.Join(dir, sub, FileName(id, ch)))
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &s, nil
+	}
+
+	rec := &Recording{Class: class, Patient: patient, ID: id}
+	found := false
+	for _, c := range []struct {
+		sub      string
+		primary  Channel
+		fallback Channel
+		dst      *Series
+	}{
+		{DirFHR, ChannelFHR, ChannelFHR2, &rec.FHR},
+		{DirUterus, ChannelUterus, ChannelUterus2, &rec.Uterus},
+	} {
+		s, err := read(c.sub, c.primary)
+		if err != nil {
+			return nil, err
+		}
+		if s == nil {
+			if s, err = read(c.sub, c.fallback); err != nil {
+				return nil, err
+			}
+		} else if c.primary == ChannelFHR {
+			if rec.FHR2, err = read(c.sub, ChannelFHR2); err != nil {
+				return nil, err
+			}
+		}
+		if s != nil {
+			*c.dst = *s
+			found = true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("recording %s has no FHR or uterus channel", id)
+	}
+	return rec, nil
+}
+
+// Stitch склеивает записи в одну: время каждой следующей записи сдвигается
+// на длительность предыдущих и пауз между ними. Пропущенные номера ID между
+// соседними записями в режимах loss и bridge добавляют к паузе по медианной
+// длительности записи и паузе на каждую; в режиме none записи идут встык
+func Stitch(recordings []*Recording, opts StitchOptions) (*Recording, error) {
+	gap := opts.GapSec
+	switch opts.Gap {
+	case "", GapNone:
+		gap = 0
+	case GapLoss, GapBridge:
+		if gap < 0 {
+			return nil, fmt.Errorf("gap duration must not be negative")
+		}
+	default:
+		return nil, fmt.Errorf("unknown gap handling %q", opts.Gap)
+	}
+	bridge := opts.Gap == GapBridge
+
+	first, last := recordings[0], recordings[len(recordings)-1]
+	stitched := &Recording{
+		Class:   first.Class,
+		Patient: first.Patient,
+		ID:      first.ID,
+	}
+	if len(recordings) > 1 {
+		stitched.ID = first.ID + ".." + last.ID
+	}
+
+	hasFHR2, hasMHR := false, false
+	for _, rec := range recordings {
+		hasFHR2 = hasFHR2 || rec.FHR2 != nil
+		hasMHR = hasMHR || rec.MHR != nil
+	}
+	var fhr2, mhr Series
+
+	durations := make([]float64, len(recordings))
+	for i, rec := range recordings {
+		durations[i] = rec.Duration()
+	}
+	typical := Median(durations)
+
+	offset := 0.0
+	for i, rec := range recordings {
+		if i > 0 {
+			offset += gap
+			if missing := missingIDs(recordings[i-1].ID, rec.ID); missing > 0 {
+				slog.Warn("Recordings missing between stitched recordings",
+					"patient", rec.Patient, "after", recordings[i-1].ID, "before", rec.ID, "missing", missing, "gap", opts.Gap)
+				if opts.Gap == GapLoss || opts.Gap == GapBridge {
+					offset += float64(missing) * (typical + gap)
+				}
+			}
+		}
+		appendSeries(&stitched.FHR, rec.FHR, offset, bridge)
+		appendSeries(&stitched.Uterus, rec.Uterus, offset, bridge)
+		// Необязательные каналы: у записей без них на этом участке потеря сигнала
+		if rec.FHR2 != nil {
+			appendSeries(&fhr2, *rec.FHR2, offset, bridge)
+		}
+		if rec.MHR != nil {
+			appendSeries(&mhr, *rec.MHR, offset, bridge)
+		}
+		offset += rec.Duration()
+	}
+	if hasFHR2 {
+		stitched.FHR2 = &fhr2
+	}
+	if hasMHR {
+		stitched.MHR = &mhr
+	}
+	return stitched, nil
+}
+
+// appendSeries дописывает ряд src со сдвигом offset. В режиме bridge пауза
+// между концом dst и началом src заполняется посекундной линейной интерполяцией
+func appendSeries(dst *Series, src Series, offset float64, bridge bool) {
+	if len(src.Time) == 0 {
+		return
+	}
+	start := src.Time[0] + offset
+	if n := len(dst.Time); bridge && n > 0 {
+		from, to := dst.Value[n-1], src.Value[0]
+		t0 := dst.Time[n-1]
+		span := start - t0
+		for t := t0 + 1; t < start; t++ {
+			dst.Time = append(dst.Time, t)
+			dst.Value = append(dst.Value, from+(to-from)*(t-t0)/span)
+		}
+	}
+	for i, t := range src.Time {
+		t += offset
+		// Перекрытие с концом предыдущей записи отбрасывается
+		if n := len(dst.Time); n > 0 && t <= dst.Time[n-1] {
+			continue
+		}
+		dst.Time = append(dst.Time, t)
+		dst.Value = append(dst.Value, src.Value[i])
+	}
+}
@@ -0,0 +1,104 @@
+package dataset
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// seconds посекундный ряд 1..n с постоянным значением
+func seconds(n int, v float64) Series {
+	var s Series
+	for t := 1; t <= n; t++ {
+		s.Time = append(s.Time, float64(t))
+		s.Value = append(s.Value, v)
+	}
+	return s
+}
+
+// stitchDataset пациент с записями 1001 (FHR и тонус), 1002 (только второй
+// канал FHR и тонуса, <id>_3 и <id>_4), 1004 (только FHR); номер 1003 пропущен
+func stitchDataset(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	dir := filepath.Join(root, ClassRegular, "p1")
+	for path, s := range map[string]Series{
+		filepath.Join(dir, DirFHR, FileName("1001", ChannelFHR)):                    seconds(10, 140),
+		filepath.Join(dir, DirUterus, FileName("1001", ChannelUterus)):              seconds(10, 10),
+		filepath.Join(dir, DirFHR, FileName("1002", ChannelFHR2)):                   seconds(10, 150),
+		filepath.Join(dir, DirUterus, FileName("1002", ChannelUterus2)):             seconds(10, 20),
+		filepath.Join(dir, DirFHR, FileName("1004", ChannelFHR)):                    seconds(10, 160),
+		filepath.Join(root, ClassRegular, "p2", DirFHR, FileName("9", ChannelFHR)):  seconds(1, 0),
+		filepath.Join(root, ClassRegular, "p2", DirFHR, FileName("10", ChannelFHR)): seconds(1, 0),
+	} {
+		if err := WriteSeries(path, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestListRecordings(t *testing.T) {
+	root := stitchDataset(t)
+	ids, err := ListRecordings(root, ClassRegular, "p1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"1001", "1002", "1004"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("ids = %v, want %v", ids, want)
+	}
+
+	// Номера сравниваются как числа
+	ids, err = ListRecordings(root, ClassRegular, "p2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"9", "10"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("ids = %v, want %v", ids, want)
+	}
+
+	if _, err := ListRecordings(root, ClassRegular, "missing"); err == nil {
+		t.Error("missing patient must be an error")
+	}
+}
+
+func TestLoadPatientGaps(t *testing.T) {
+	root := stitchDataset(t)
+	cases := []struct {
+		opts StitchOptions
+		// время первого отсчёта FHR каждой записи
+		starts []float64
+		uterus int
+	}{
+		// Встык: пропуск нумерации не добавляет паузы
+		{StitchOptions{Gap: GapNone}, []float64{1, 11, 21}, 20},
+		// Пауза 5 с между записями и ещё запись медианной длительности (10 с)
+		// с паузой на место пропущенной 1003
+		{StitchOptions{Gap: GapLoss, GapSec: 5}, []float64{1, 16, 46}, 20},
+	}
+	for _, c := range cases {
+		rec, err := LoadPatient(root, ClassRegular, "p1", c.opts)
+		if err != nil {
+			t.Fatalf("%s: %v", c.opts.Gap, err)
+		}
+		if rec.ID != "1001..1004" {
+			t.Errorf("%s: id = %q", c.opts.Gap, rec.ID)
+		}
+		if len(rec.FHR.Time) != 30 {
+			t.Fatalf("%s: %d FHR samples, want 30", c.opts.Gap, len(rec.FHR.Time))
+		}
+		for i, start := range c.starts {
+			if got := rec.FHR.Time[10*i]; got != start {
+				t.Errorf("%s: recording %d starts at %v, want %v", c.opts.Gap, i, got, start)
+			}
+			// Запись 1002 без основного канала берёт второй (150 уд/мин)
+			if want := float64(140 + 10*i); rec.FHR.Value[10*i] != want {
+				t.Errorf("%s: recording %d FHR = %v, want %v", c.opts.Gap, i, rec.FHR.Value[10*i], want)
+			}
+		}
+		// У записи 1004 нет тонуса: на её участке потеря сигнала
+		if len(rec.Uterus.Time) != c.uterus || rec.FHR2 != nil {
+			t.Errorf("%s: %d uterus samples, FHR2 %v", c.opts.Gap, len(rec.Uterus.Time), rec.FHR2)
+		}
+	}
+}
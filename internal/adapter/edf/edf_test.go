@@ -0,0 +1,202 @@
+package edf
+
+import (
+	"backend_gen/internal/ports/websocket"
+	"bytes"
+	"flag"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
+func ptr(v float64) *float64 { return &v }
+
+// buffer файл в памяти для writer
+type buffer struct{ bytes.Buffer }
+
+func (*buffer) Close() error { return nil }
+
+var testStart = time.Date(2024, 3, 1, 12, 30, 15, 0, time.UTC)
+
+// session пишет короткую сессию: 4 отсчёта с шагом 0.5 с, пропуск тика,
+// потеря сигнала и канал матери, аннотации и отметка
+func session(t *testing.T) []byte {
+	t.Helper()
+	out := &buffer{}
+	w := NewWriter(out, Config{SensorID: "s1", PatientID: "p 1", Period: 500 * time.Millisecond, RecordSamples: 2}, testStart)
+	messages := []websocket.MessageData{
+		{SecFromStart: 0.5, Data: websocket.SensorData{BPMChild: 140, Uterus: 10, Spasms: 0, BPMMother: ptr(80)}},
+		{SecFromStart: 1, Data: websocket.SensorData{BPMChild: 141.5, Uterus: 12, Spasms: 5},
+			Annotations: []websocket.Annotation{{Type: "contraction", Start: 1, End: ptr(2.5)}}},
+		// Тик 1.5 с пропущен
+		{SecFromStart: 2, Data: websocket.SensorData{BPMChild: math.NaN(), Uterus: 20, Spasms: 30},
+			Annotations: []websocket.Annotation{{Type: "artifact", Kind: "signal_loss", Start: 2, Channel: "bpmChild"}},
+			Marks:       []websocket.Mark{{Type: "event", Source: "manual", SecFromStart: 2, Note: "note\x14"}}},
+	}
+	for _, m := range messages {
+		if err := w.Write(m); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	return out.Bytes()
+}
+
+func TestHeaderRoundTrip(t *testing.T) {
+	h := Header{
+		Patient:        "X X X X",
+		Recording:      "Startdate 01-MAR-2024 X X test",
+		Start:          testStart,
+		Reserved:       ReservedContinuous,
+		Records:        3,
+		RecordDuration: 0.5,
+		Signals: []Signal{
+			{Label: "FHR", Transducer: "Doppler", Dimension: "bpm", PhysicalMax: 300, DigitalMin: digitalMin, DigitalMax: digitalMax, Samples: 4},
+			{Label: AnnotationsLabel, PhysicalMin: -1, PhysicalMax: 1, DigitalMin: digitalMin, DigitalMax: digitalMax, Samples: 30},
+		},
+	}
+	data := h.Bytes()
+	if len(data) != h.Size() {
+		t.Fatalf("header is %d bytes, want %d", len(data), h.Size())
+	}
+	got, err := ParseHeader(data)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if !reflect.DeepEqual(got, h) {
+		t.Errorf("header = %+v\nwant %+v", got, h)
+	}
+}
+
+func TestWriteReadRoundTrip(t *testing.T) {
+	f, err := Read(bytes.NewReader(session(t)))
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+
+	var labels []string
+	for _, s := range f.Header.Signals {
+		labels = append(labels, s.Label)
+	}
+	// FHR2 в данных не встретился и не пишется
+	if want := []string{"FHR", "MHR", "TOCO", "UC", AnnotationsLabel}; !reflect.DeepEqual(labels, want) {
+		t.Fatalf("signals = %v, want %v", labels, want)
+	}
+	if f.Header.Reserved != ReservedContinuous || f.Header.Records != 2 || f.Header.RecordDuration != 1 {
+		t.Errorf("header = %+v", f.Header)
+	}
+	if !f.Header.Start.Equal(testStart) {
+		t.Errorf("start = %v", f.Header.Start)
+	}
+	if !reflect.DeepEqual(f.Onsets, []float64{0, 1}) {
+		t.Errorf("onsets = %v", f.Onsets)
+	}
+
+	// Пропущенный тик и потеря сигнала читаются физическим минимумом (0)
+	want := map[string][]float64{
+		"FHR":  {140, 141.5, 0, 0},
+		"MHR":  {80, 0, 0, 0},
+		"TOCO": {10, 12, 0, 20},
+		"UC":   {0, 5, 0, 30},
+	}
+	for i, s := range f.Header.Signals {
+		expected, ok := want[s.Label]
+		if !ok {
+			continue
+		}
+		// Точность квантования: диапазон делится на 65535 уровней
+		tolerance := (s.PhysicalMax - s.PhysicalMin) / float64(s.DigitalMax-s.DigitalMin)
+		if len(f.Samples[i]) != len(expected) {
+			t.Fatalf("%s has %d samples, want %d", s.Label, len(f.Samples[i]), len(expected))
+		}
+		for n, v := range expected {
+			if math.Abs(f.Samples[i][n]-v) > tolerance {
+				t.Errorf("%s[%d] = %v, want %v", s.Label, n, f.Samples[i][n], v)
+			}
+		}
+		if f.Rate(i) != 2 || f.Time(i, 3) != 1.5 {
+			t.Errorf("%s rate %v, time of sample 3 %v", s.Label, f.Rate(i), f.Time(i, 3))
+		}
+	}
+}
+
+func TestAnnotations(t *testing.T) {
+	data := session(t)
+	f, err := Read(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	annotations := string(data[f.Header.Size():])
+	// Аннотации попадают в запись по времени начала, служебные байты в тексте заменены
+	for _, want := range []string{
+		"+0\x14\x14\x00",
+		"+1\x14\x14\x00+1\x151.5\x14contraction\x14\x00", // длительность, а не конец
+		"+2\x14artifact signal_loss (bpmChild)\x14\x00",
+		"+2\x14mark event manual: note \x14\x00",
+	} {
+		if !strings.Contains(annotations, want) {
+			t.Errorf("annotations do not contain %q", want)
+		}
+	}
+}
+
+func TestGolden(t *testing.T) {
+	got := session(t)
+	path := filepath.Join("testdata", "session.edf")
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read golden: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("EDF+ output differs from %s", path)
+	}
+}
+
+func TestOnsets(t *testing.T) {
+	// EDF+C: дробная часть секунды начала приходит из отметок времени записей
+	out := &buffer{}
+	w := NewWriter(out, Config{Period: 500 * time.Millisecond, RecordSamples: 2}, testStart.Add(250*time.Millisecond))
+	for _, sec := range []float64{0.5, 1, 1.5} {
+		if err := w.Write(websocket.MessageData{SecFromStart: sec, Data: websocket.SensorData{BPMChild: 140, Uterus: 10}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f, err := Read(bytes.NewReader(out.Bytes()))
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !reflect.DeepEqual(f.Onsets, []float64{0.25, 1.25}) {
+		t.Errorf("EDF+C onsets = %v, want [0.25 1.25]", f.Onsets)
+	}
+
+	// EDF без аннотаций: записи идут подряд
+	h := Header{Patient: "X", Recording: "X", Start: testStart, Records: 3, RecordDuration: 2,
+		Signals: []Signal{{Label: "FHR", PhysicalMax: 300, DigitalMin: digitalMin, DigitalMax: digitalMax, Samples: 1}}}
+	data := append(h.Bytes(), make([]byte, 3*h.RecordSize())...)
+	f, err = Read(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !reflect.DeepEqual(f.Onsets, []float64{0, 2, 4}) {
+		t.Errorf("EDF onsets = %v, want [0 2 4]", f.Onsets)
+	}
+}
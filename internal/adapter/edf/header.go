@@ -0,0 +1,217 @@
+package edf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Параметры формата EDF/EDF+
+const (
+	// AnnotationsLabel метка сигнала аннотаций EDF+
+	AnnotationsLabel = "EDF Annotations"
+	// ReservedContinuous поле reserved для непрерывной записи EDF+
+	ReservedContinuous = "EDF+C"
+
+	digitalMin = -32768
+	digitalMax = 32767
+
+	headerSize = 256
+	signalSize = 256
+)
+
+// Signal описание сигнала в заголовке EDF
+type Signal struct {
+	Label       string
+	Transducer  string
+	Dimension   string
+	PhysicalMin float64
+	PhysicalMax float64
+	DigitalMin  int
+	DigitalMax  int
+	Prefilter   string
+	// Samples число отсчётов сигнала в одной записи данных
+	Samples int
+}
+
+// IsAnnotations сообщает, что сигнал содержит аннотации EDF+
+func (s Signal) IsAnnotations() bool {
+	return s.Label == AnnotationsLabel
+}
+
+// Physical переводит цифровое значение в физическое
+func (s Signal) Physical(d int16) float64 {
+	scale := (s.PhysicalMax - s.PhysicalMin) / float64(s.DigitalMax-s.DigitalMin)
+	return s.PhysicalMin + (float64(d)-float64(s.DigitalMin))*scale
+}
+
+// Header заголовок файла EDF
+type Header struct {
+	Patient   string
+	Recording string
+	Start     time.Time
+	Reserved  string
+	// Records число записей данных (-1 = неизвестно)
+	Records int
+	// RecordDuration длительность одной записи данных, секунды
+	RecordDuration float64
+	Signals        []Signal
+}
+
+// Size размер заголовка в байтах
+func (h Header) Size() int {
+	return headerSize + signalSize*len(h.Signals)
+}
+
+// RecordSize размер записи данных в байтах
+func (h Header) RecordSize() int {
+	size := 0
+	for _, s := range h.Signals {
+		size += 2 * s.Samples
+	}
+	return size
+}
+
+// Bytes кодирует заголовок: ASCII поля фиксированной ширины, дополненные пробелами
+func (h Header) Bytes() []byte {
+	var b strings.Builder
+	field(&b, "0", 8)
+	field(&b, h.Patient, 80)
+	field(&b, h.Recording, 80)
+	field(&b, h.Start.Format("02.01.06"), 8)
+	field(&b, h.Start.Format("15.04.05"), 8)
+	field(&b, strconv.Itoa(h.Size()), 8)
+	field(&b, h.Reserved, 44)
+	field(&b, strconv.Itoa(h.Records), 8)
+	field(&b, number(h.RecordDuration), 8)
+	field(&b, strconv.Itoa(len(h.Signals)), 4)
+
+	// Поля сигналов идут столбцами: сначала все метки, затем все датчики и т.д.
+	columns := []struct {
+		width int
+		value func(Signal) string
+	}{
+		{16, func(s Signal) string { return s.Label }},
+		{80, func(s Signal) string { return s.Transducer }},
+		{8, func(s Signal) string { return s.Dimension }},
+		{8, func(s Signal) string { return number(s.PhysicalMin) }},
+		{8, func(s Signal) string { return number(s.PhysicalMax) }},
+		{8, func(s Signal) string { return strconv.Itoa(s.DigitalMin) }},
+		{8, func(s Signal) string { return strconv.Itoa(s.DigitalMax) }},
+		{80, func(s Signal) string { return s.Prefilter }},
+		{8, func(s Signal) string { return strconv.Itoa(s.Samples) }},
+		{32, func(Signal) string { return "" }},
+	}
+	for _, c := range columns {
+		for _, s := range h.Signals {
+			field(&b, c.value(s), c.width)
+		}
+	}
+	return []byte(b.String())
+}
+
+// ParseHeader разбирает заголовок EDF: 256 байт общей части и по 256 байт на сигнал
+func ParseHeader(data []byte) (Header, error) {
+	if len(data) < headerSize {
+		return Header{}, fmt.Errorf("edf header is too short: %d bytes", len(data))
+	}
+	if v := strings.TrimSpace(string(data[0:8])); v != "0" {
+		return Header{}, fmt.Errorf("unsupported edf version %q", v)
+	}
+
+	h := Header{
+		Patient:   strings.TrimSpace(string(data[8:88])),
+		Recording: strings.TrimSpace(string(data[88:168])),
+		Reserved:  strings.TrimSpace(string(data[192:236])),
+	}
+	start, err := time.Parse("02.01.06 15.04.05", string(data[168:176])+" "+string(data[176:184]))
+	if err != nil {
+		return Header{}, fmt.Errorf("invalid edf start time: %w", err)
+	}
+	h.Start = start
+	if h.Records, err = strconv.Atoi(strings.TrimSpace(string(data[236:244]))); err != nil {
+		return Header{}, fmt.Errorf("invalid number of data records: %w", err)
+	}
+	if h.RecordDuration, err = strconv.ParseFloat(strings.TrimSpace(string(data[244:252])), 64); err != nil {
+		return Header{}, fmt.Errorf("invalid data record duration: %w", err)
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(string(data[252:256])))
+	if err != nil || count <= 0 {
+		return Header{}, fmt.Errorf("invalid number of signals %q", strings.TrimSpace(string(data[252:256])))
+	}
+	if len(data) < headerSize+signalSize*count {
+		return Header{}, fmt.Errorf("edf header is too short for %d signals", count)
+	}
+
+	// Поля сигналов идут столбцами, как в Bytes
+	offset := headerSize
+	column := func(width int) []string {
+		values := make([]string, count)
+		for i := range values {
+			values[i] = strings.TrimSpace(string(data[offset : offset+width]))
+			offset += width
+		}
+		return values
+	}
+	labels, transducers, dimensions := column(16), column(80), column(8)
+	physMin, physMax, digMin, digMax := column(8), column(8), column(8), column(8)
+	prefilters, samples := column(80), column(8)
+
+	h.Signals = make([]Signal, count)
+	for i := range h.Signals {
+		s := Signal{Label: labels[i], Transducer: transducers[i], Dimension: dimensions[i], Prefilter: prefilters[i]}
+		var errs [5]error
+		s.PhysicalMin, errs[0] = strconv.ParseFloat(physMin[i], 64)
+		s.PhysicalMax, errs[1] = strconv.ParseFloat(physMax[i], 64)
+		s.DigitalMin, errs[2] = strconv.Atoi(digMin[i])
+		s.DigitalMax, errs[3] = strconv.Atoi(digMax[i])
+		s.Samples, errs[4] = strconv.Atoi(samples[i])
+		for _, err := range errs {
+			if err != nil {
+				return Header{}, fmt.Errorf("invalid parameters of signal %q: %w", s.Label, err)
+			}
+		}
+		if s.DigitalMax <= s.DigitalMin || s.Samples <= 0 {
+			return Header{}, fmt.Errorf("invalid parameters of signal %q", s.Label)
+		}
+		h.Signals[i] = s
+	}
+	return h, nil
+}
+
+// field дописывает значение, обрезанное или дополненное пробелами до width.
+// Вне печатного ASCII символы заменяются на _
+func field(b *strings.Builder, value string, width int) {
+	n := 0
+	for i := 0; i < len(value) && n < width; i++ {
+		c := value[i]
+		if c < 0x20 || c > 0x7e {
+			c = '_'
+		}
+		b.WriteByte(c)
+		n++
+	}
+	for ; n < width; n++ {
+		b.WriteByte(' ')
+	}
+}
+
+// number форматирует число для поля шириной 8 символов
+func number(v float64) string {
+	s := strconv.FormatFloat(v, 'f', -1, 64)
+	if len(s) > 8 {
+		s = strconv.FormatFloat(v, 'g', 6, 64)
+	}
+	return s
+}
+
+// subfield подготавливает значение подполя patient/recording EDF+:
+// пробелы заменяются на _, пустое значение - X
+func subfield(value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "X"
+	}
+	return strings.ReplaceAll(value, " ", "_")
+}
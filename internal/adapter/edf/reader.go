@@ -0,0 +1,103 @@
+package edf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// File содержимое файла EDF/EDF+ в физических величинах
+type File struct {
+	Header Header
+	// Samples отсчёты сигналов по порядку заголовка (у сигнала аннотаций - пусто)
+	Samples [][]float64
+	// Onsets время начала каждой записи данных от начала файла, секунды.
+	// В EDF+ (C и D) берётся из отметки времени записи - первой TAL, в EDF+C
+	// она включает дробную часть секунды начала. В EDF без сигнала аннотаций
+	// записи идут подряд
+	Onsets []float64
+}
+
+// Rate частота отсчётов сигнала i, Гц
+func (f *File) Rate(i int) float64 {
+	return float64(f.Header.Signals[i].Samples) / f.Header.RecordDuration
+}
+
+// Time время отсчёта n сигнала i от начала файла, секунды
+func (f *File) Time(i, n int) float64 {
+	samples := f.Header.Signals[i].Samples
+	return f.Onsets[n/samples] + float64(n%samples)/f.Rate(i)
+}
+
+// Read читает файл EDF/EDF+ целиком
+func Read(r io.Reader) (*File, error) {
+	fixed := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, fixed); err != nil {
+		return nil, fmt.Errorf("failed to read edf header: %w", err)
+	}
+	count, err := strconv.Atoi(string(bytes.TrimSpace(fixed[252:256])))
+	if err != nil || count <= 0 {
+		return nil, fmt.Errorf("invalid number of signals %q", bytes.TrimSpace(fixed[252:256]))
+	}
+	data := make([]byte, headerSize+signalSize*count)
+	copy(data, fixed)
+	if _, err := io.ReadFull(r, data[headerSize:]); err != nil {
+		return nil, fmt.Errorf("failed to read edf signal headers: %w", err)
+	}
+	header, err := ParseHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	if header.RecordDuration <= 0 {
+		return nil, fmt.Errorf("edf files without data record duration are not supported")
+	}
+
+	f := &File{Header: header, Samples: make([][]float64, len(header.Signals))}
+	record := make([]byte, header.RecordSize())
+	// Число записей -1 допускается для незавершённой записи: читаем до конца файла
+	for n := 0; header.Records < 0 || n < header.Records; n++ {
+		if _, err := io.ReadFull(r, record); err != nil {
+			if header.Records < 0 && errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("failed to read data record %d: %w", n, err)
+		}
+
+		onset := float64(n) * header.RecordDuration
+		pos := 0
+		for i, s := range header.Signals {
+			size := 2 * s.Samples
+			chunk := record[pos : pos+size]
+			pos += size
+			if s.IsAnnotations() {
+				if t, ok := recordOnset(chunk); ok {
+					onset = t
+				}
+				continue
+			}
+			for j := 0; j < size; j += 2 {
+				f.Samples[i] = append(f.Samples[i], s.Physical(int16(binary.LittleEndian.Uint16(chunk[j:]))))
+			}
+		}
+		f.Onsets = append(f.Onsets, onset)
+	}
+	f.Header.Records = len(f.Onsets)
+	return f, nil
+}
+
+// recordOnset разбирает отметку времени записи: первую TAL вида +onset\x14\x14
+func recordOnset(annotations []byte) (float64, bool) {
+	end := bytes.IndexByte(annotations, 0x14)
+	if end <= 0 {
+		return 0, false
+	}
+	onset := annotations[:end]
+	if i := bytes.IndexByte(onset, 0x15); i >= 0 {
+		onset = onset[:i]
+	}
+	t, err := strconv.ParseFloat(string(onset), 64)
+	return t, err == nil
+}
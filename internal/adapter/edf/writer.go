@@ -0,0 +1,271 @@
+package edf
+
+import (
+	"backend_gen/internal/ports/export"
+	"backend_gen/internal/ports/websocket"
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// channel канал SensorData в файле EDF
+type channel struct {
+	signal Signal
+	// optional канал пишется, только если встретился в данных
+	optional bool
+	value    func(websocket.SensorData) (float64, bool)
+}
+
+// Каналы КТГ. Физический минимум соответствует цифровому, поэтому потеря
+// сигнала записывается нулём, как принято в экспорте мониторов
+var channels = []channel{
+	{
+		signal: Signal{Label: "FHR", Transducer: "Doppler ultrasound", Dimension: "bpm", PhysicalMax: 300},
+		value:  func(d websocket.SensorData) (float64, bool) { return d.BPMChild, true },
+	},
+	{
+		signal:   Signal{Label: "FHR2", Transducer: "Doppler ultrasound", Dimension: "bpm", PhysicalMax: 300},
+		optional: true,
+		value:    func(d websocket.SensorData) (float64, bool) { return optional(d.BPMChild2) },
+	},
+	{
+		signal:   Signal{Label: "MHR", Transducer: "Maternal pulse", Dimension: "bpm", PhysicalMax: 300},
+		optional: true,
+		value:    func(d websocket.SensorData) (float64, bool) { return optional(d.BPMMother) },
+	},
+	{
+		signal: Signal{Label: "TOCO", Transducer: "Tocodynamometer", Dimension: "mmHg", PhysicalMax: 100},
+		value:  func(d websocket.SensorData) (float64, bool) { return d.Uterus, true },
+	},
+	{
+		signal: Signal{Label: "UC", Transducer: "Contraction intensity", PhysicalMax: 100},
+		value:  func(d websocket.SensorData) (float64, bool) { return d.Spasms, true },
+	},
+}
+
+func optional(v *float64) (float64, bool) {
+	if v == nil {
+		return 0, false
+	}
+	return *v, true
+}
+
+// Config параметры файла EDF+
+type Config struct {
+	SensorID string
+	// PatientID код пациентки в поле patient ("" = X)
+	PatientID string
+	// Period шаг отсчётов
+	Period time.Duration
+	// RecordSamples отсчётов сигнала в одной записи данных
+	RecordSamples int
+}
+
+func (cfg Config) withDefaults() Config {
+	if cfg.Period <= 0 {
+		cfg.Period = 120 * time.Millisecond
+	}
+	if cfg.RecordSamples <= 0 {
+		// 25 отсчётов по 120 мс - запись длительностью 3 с
+		cfg.RecordSamples = 25
+	}
+	return cfg
+}
+
+// tal аннотация EDF+ (time-stamped annotation list)
+type tal struct {
+	onset    float64
+	duration *float64
+	text     string
+}
+
+// writer накапливает сессию и пишет файл EDF+C при закрытии: число записей
+// и набор каналов (второй плод, мать) известны только в конце сессии
+type writer struct {
+	out   io.WriteCloser
+	cfg   Config
+	start time.Time
+
+	samples     [][]float64 // по каналам, индекс - номер отсчёта
+	present     []bool
+	annotations []tal
+}
+
+// NewWriter создает запись сессии, начавшейся в start, в формате EDF+
+func NewWriter(out io.WriteCloser, cfg Config, start time.Time) export.Writer {
+	return &writer{
+		out:     out,
+		cfg:     cfg.withDefaults(),
+		start:   start,
+		samples: make([][]float64, len(channels)),
+		present: make([]bool, len(channels)),
+	}
+}
+
+// Write размещает отсчёт по времени сообщения: пропущенные тики остаются потерей сигнала
+func (w *writer) Write(m websocket.MessageData) error {
+	index := max(int(math.Round(m.SecFromStart/w.cfg.Period.Seconds()))-1, 0)
+	for i, ch := range channels {
+		for len(w.samples[i]) <= index {
+			w.samples[i] = append(w.samples[i], math.NaN())
+		}
+		v, ok := ch.value(m.Data)
+		if !ok {
+			continue
+		}
+		w.present[i] = true
+		w.samples[i][index] = v
+	}
+
+	for _, a := range m.Annotations {
+		text := a.Type
+		if a.Kind != "" {
+			text += " " + a.Kind
+		}
+		if a.Channel != "" {
+			text += " (" + a.Channel + ")"
+		}
+		annotation := tal{onset: a.Start, text: text}
+		if a.End != nil {
+			duration := *a.End - a.Start
+			annotation.duration = &duration
+		}
+		w.annotations = append(w.annotations, annotation)
+	}
+	for _, mark := range m.Marks {
+		text := fmt.Sprintf("mark %s %s", mark.Type, mark.Source)
+		if mark.Note != "" {
+			text += ": " + mark.Note
+		}
+		w.annotations = append(w.annotations, tal{onset: mark.SecFromStart, text: text})
+	}
+	return nil
+}
+
+func (w *writer) Close() error {
+	buf := bufio.NewWriter(w.out)
+	if err := w.encode(buf); err != nil {
+		w.out.Close()
+		return err
+	}
+	if err := buf.Flush(); err != nil {
+		w.out.Close()
+		return err
+	}
+	return w.out.Close()
+}
+
+func (w *writer) encode(out io.Writer) error {
+	size := w.cfg.RecordSamples
+	recordDuration := w.cfg.Period.Seconds() * float64(size)
+	records := (len(w.samples[0]) + size - 1) / size
+
+	// Время начала в EDF с точностью до секунды, дробная часть уходит
+	// в отметки времени записей (первая TAL каждой записи)
+	start := w.start.Truncate(time.Second)
+	offset := w.start.Sub(start).Seconds()
+
+	var active []int
+	header := Header{
+		Patient:        subfield(w.cfg.PatientID) + " X X X",
+		Recording:      fmt.Sprintf("Startdate %s X X ctg-generator_%s", strings.ToUpper(start.Format("02-Jan-2006")), subfield(w.cfg.SensorID)),
+		Start:          start,
+		Reserved:       ReservedContinuous,
+		Records:        records,
+		RecordDuration: recordDuration,
+	}
+	for i, ch := range channels {
+		if ch.optional && !w.present[i] {
+			continue
+		}
+		signal := ch.signal
+		signal.DigitalMin, signal.DigitalMax = digitalMin, digitalMax
+		signal.Samples = size
+		header.Signals = append(header.Signals, signal)
+		active = append(active, i)
+	}
+
+	// Аннотации попадают в запись, на которую приходится их начало
+	lists := make([][]byte, records)
+	for r := range lists {
+		lists[r] = []byte("+" + seconds(offset+float64(r)*recordDuration) + "\x14\x14\x00")
+	}
+	for _, a := range w.annotations {
+		if records == 0 {
+			break
+		}
+		r := min(max(int(a.onset/recordDuration), 0), records-1)
+		entry := "+" + seconds(offset+a.onset)
+		if a.duration != nil {
+			entry += "\x15" + seconds(*a.duration)
+		}
+		entry += "\x14" + strings.Map(printable, a.text) + "\x14\x00"
+		lists[r] = append(lists[r], entry...)
+	}
+	annotationBytes := 0
+	for _, list := range lists {
+		annotationBytes = max(annotationBytes, len(list))
+	}
+	header.Signals = append(header.Signals, Signal{
+		Label:       AnnotationsLabel,
+		PhysicalMin: -1,
+		PhysicalMax: 1,
+		DigitalMin:  digitalMin,
+		DigitalMax:  digitalMax,
+		Samples:     max((annotationBytes+1)/2, 1),
+	})
+
+	if _, err := out.Write(header.Bytes()); err != nil {
+		return err
+	}
+
+	record := make([]byte, header.RecordSize())
+	for r := range records {
+		pos := 0
+		for k, i := range active {
+			signal := header.Signals[k]
+			for j := range size {
+				v := math.NaN()
+				if n := r*size + j; n < len(w.samples[i]) {
+					v = w.samples[i][n]
+				}
+				binary.LittleEndian.PutUint16(record[pos:], uint16(digitize(signal, v)))
+				pos += 2
+			}
+		}
+		clear(record[pos:])
+		copy(record[pos:], lists[r])
+		if _, err := out.Write(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// digitize переводит физическое значение в цифровое; NaN - цифровой минимум
+func digitize(s Signal, v float64) int16 {
+	if math.IsNaN(v) {
+		return int16(s.DigitalMin)
+	}
+	scale := float64(s.DigitalMax-s.DigitalMin) / (s.PhysicalMax - s.PhysicalMin)
+	d := math.Round(float64(s.DigitalMin) + (v-s.PhysicalMin)*scale)
+	return int16(min(max(d, float64(s.DigitalMin)), float64(s.DigitalMax)))
+}
+
+// seconds форматирует время TAL: секунды без лишних знаков
+func seconds(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1000)/1000, 'f', -1, 64)
+}
+
+// printable убирает из текста аннотации служебные байты TAL
+func printable(r rune) rune {
+	if r < 0x20 {
+		return ' '
+	}
+	return r
+}
@@ -0,0 +1,35 @@
+package encoding
+
+import (
+	"backend_gen/internal/ports/websocket"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// Детерминированное кодирование (RFC 8949, 4.2.3): одно сообщение - одни байты
+var cborMode = func() cbor.EncMode {
+	mode, err := cbor.EncOptions{Sort: cbor.SortCoreDeterministic}.EncMode()
+	if err != nil {
+		panic(err)
+	}
+	return mode
+}()
+
+// cborCodec CBOR (RFC 8949): та же структура, что и JSON, числа как float64
+type cborCodec struct{}
+
+func (cborCodec) Name() string { return FormatCBOR }
+
+func (cborCodec) Binary() bool { return true }
+
+func (cborCodec) Encode(message websocket.MessageData) ([]byte, error) {
+	return cborMode.Marshal(newWireMessage(message))
+}
+
+func (cborCodec) Decode(data []byte) (websocket.MessageData, error) {
+	var w wireMessage
+	if err := cbor.Unmarshal(data, &w); err != nil {
+		return websocket.MessageData{}, err
+	}
+	return w.message(), nil
+}
@@ -0,0 +1,70 @@
+package encoding
+
+import (
+	"backend_gen/internal/ports/websocket"
+	"fmt"
+	"sort"
+)
+
+// Форматы сообщений
+const (
+	FormatJSON     = "json"
+	FormatMsgpack  = "msgpack"
+	FormatProtobuf = "protobuf"
+	FormatCBOR     = "cbor"
+)
+
+// Codec кодирует сообщения и декодирует их обратно (для тестового сервера)
+type Codec interface {
+	websocket.Encoder
+
+	// Decode декодирует сообщение; потеря сигнала восстанавливается как NaN
+	Decode(data []byte) (websocket.MessageData, error)
+}
+
+var codecs = map[string]Codec{
+	FormatJSON:     jsonCodec{},
+	FormatMsgpack:  msgpackCodec{},
+	FormatProtobuf: protobufCodec{},
+	FormatCBOR:     cborCodec{},
+}
+
+// New возвращает кодек формата name
+func New(name string) (Codec, error) {
+	codec, ok := codecs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown encoding %q, supported: %v", name, Names())
+	}
+	return codec, nil
+}
+
+// Encoders возвращает кодировщики всех форматов по именам
+func Encoders() map[string]websocket.Encoder {
+	encoders := make(map[string]websocket.Encoder, len(codecs))
+	for name, codec := range codecs {
+		encoders[name] = codec
+	}
+	return encoders
+}
+
+// Names возвращает имена поддерживаемых форматов
+func Names() []string {
+	names := make([]string, 0, len(codecs))
+	for name := range codecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Detect определяет формат сообщения пробным декодированием: подходит формат,
+// который разбирает сообщение целиком и находит в нём sensorID (генератор
+// заполняет его всегда). Пустая строка, если формат не распознан
+func Detect(data []byte) string {
+	for _, name := range []string{FormatJSON, FormatMsgpack, FormatCBOR, FormatProtobuf} {
+		if m, err := codecs[name].Decode(data); err == nil && m.SensorID != "" {
+			return name
+		}
+	}
+	return ""
+}
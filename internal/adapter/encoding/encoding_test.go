@@ -0,0 +1,153 @@
+package encoding
+
+import (
+	"backend_gen/internal/ports/websocket"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
+func ptr(v float64) *float64 { return &v }
+
+// fullMessage сообщение со всеми необязательными полями
+func fullMessage() websocket.MessageData {
+	return websocket.MessageData{
+		SensorID:     "test-sensor",
+		SecFromStart: 12.5,
+		Data: websocket.SensorData{
+			BPMChild:  140.25,
+			Uterus:    18,
+			Spasms:    0,
+			BPMMother: ptr(82),
+			BPMChild2: ptr(138.5),
+		},
+		Annotations: []websocket.Annotation{
+			{Type: "contraction", Start: 10, End: ptr(95)},
+			{Type: "artifact", Kind: "signal_loss", Start: 12, Channel: "bpmChild2"},
+		},
+		Marks: []websocket.Mark{{Type: "event", Source: "manual", SecFromStart: 12.25, Note: "test"}},
+	}
+}
+
+// canonical сравнимое представление сообщения: JSON передаёт NaN как null
+func canonical(t *testing.T, m websocket.MessageData) string {
+	t.Helper()
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return string(data)
+}
+
+func TestRoundTrip(t *testing.T) {
+	messages := map[string]websocket.MessageData{
+		"full":    fullMessage(),
+		"minimal": {SensorID: "s", Data: websocket.SensorData{BPMChild: 120, Uterus: 10}},
+		"signal loss": {
+			SensorID:     "s",
+			SecFromStart: 1.125,
+			Data:         websocket.SensorData{BPMChild: math.NaN(), Uterus: 12, Spasms: math.NaN()},
+		},
+	}
+	for _, name := range Names() {
+		codec, _ := New(name)
+		for label, m := range messages {
+			data, err := codec.Encode(m)
+			if err != nil {
+				t.Fatalf("%s/%s: encode: %v", name, label, err)
+			}
+			decoded, err := codec.Decode(data)
+			if err != nil {
+				t.Fatalf("%s/%s: decode: %v", name, label, err)
+			}
+			if got, want := canonical(t, decoded), canonical(t, m); got != want {
+				t.Errorf("%s/%s: round trip mismatch\n got: %s\nwant: %s", name, label, got, want)
+			}
+		}
+	}
+}
+
+// Потеря сигнала на необязательном канале: null в JSON, MessagePack и CBOR,
+// флаг *_lost в Protobuf. Отсутствующий канал остаётся отсутствующим
+func TestOptionalChannelLoss(t *testing.T) {
+	m := websocket.MessageData{
+		SensorID: "s",
+		Data:     websocket.SensorData{BPMChild: 130, Uterus: 10, BPMChild2: ptr(math.NaN())},
+	}
+	for _, name := range Names() {
+		codec, _ := New(name)
+		data, err := codec.Encode(m)
+		if err != nil {
+			t.Fatalf("%s: encode: %v", name, err)
+		}
+		decoded, err := codec.Decode(data)
+		if err != nil {
+			t.Fatalf("%s: decode: %v", name, err)
+		}
+		if fhr2 := decoded.Data.BPMChild2; fhr2 == nil || !math.IsNaN(*fhr2) {
+			t.Errorf("%s: bpmChild2 = %v, want NaN", name, fhr2)
+		}
+		if decoded.Data.BPMMother != nil {
+			t.Errorf("%s: bpmMother = %v, want absent", name, *decoded.Data.BPMMother)
+		}
+	}
+}
+
+// Golden файлы фиксируют байты каждого формата: изменение схемы или библиотеки
+// не должно незаметно менять то, что получает бэкенд
+func TestGolden(t *testing.T) {
+	m := fullMessage()
+	for _, name := range Names() {
+		codec, _ := New(name)
+		data, err := codec.Encode(m)
+		if err != nil {
+			t.Fatalf("%s: encode: %v", name, err)
+		}
+		path := filepath.Join("testdata", "message."+name)
+		if *update {
+			if err := os.WriteFile(path, data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		golden, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("%s: %v (run with -update to create)", name, err)
+		}
+		if !bytes.Equal(data, golden) {
+			t.Errorf("%s: encoding differs from %s\n got: %x\nwant: %x", name, path, data, golden)
+		}
+
+		decoded, err := codec.Decode(golden)
+		if err != nil {
+			t.Fatalf("%s: decode golden: %v", name, err)
+		}
+		if got, want := canonical(t, decoded), canonical(t, m); got != want {
+			t.Errorf("%s: golden decodes to\n %s\nwant %s", name, got, want)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	m := fullMessage()
+	for _, name := range Names() {
+		codec, _ := New(name)
+		data, err := codec.Encode(m)
+		if err != nil {
+			t.Fatalf("%s: encode: %v", name, err)
+		}
+		if got := Detect(data); got != name {
+			t.Errorf("Detect(%s) = %q", name, got)
+		}
+	}
+	for _, data := range [][]byte{nil, []byte("hello"), {0xff, 0x00, 0x13}} {
+		if got := Detect(data); got != "" {
+			t.Errorf("Detect(%x) = %q, want unknown", data, got)
+		}
+	}
+}
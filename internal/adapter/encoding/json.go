@@ -0,0 +1,23 @@
+package encoding
+
+import (
+	"backend_gen/internal/ports/websocket"
+	"encoding/json"
+)
+
+// jsonCodec исходный формат: JSON в текстовых фреймах
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string { return FormatJSON }
+
+func (jsonCodec) Binary() bool { return false }
+
+func (jsonCodec) Encode(message websocket.MessageData) ([]byte, error) {
+	return json.Marshal(message)
+}
+
+func (jsonCodec) Decode(data []byte) (websocket.MessageData, error) {
+	var m websocket.MessageData
+	err := json.Unmarshal(data, &m)
+	return m, err
+}
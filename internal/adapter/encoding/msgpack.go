@@ -0,0 +1,41 @@
+package encoding
+
+import (
+	"backend_gen/internal/ports/websocket"
+	"bytes"
+	"fmt"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// msgpackCodec MessagePack (https://msgpack.org/): та же структура, что и JSON,
+// числа передаются как float64
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string { return FormatMsgpack }
+
+func (msgpackCodec) Binary() bool { return true }
+
+func (msgpackCodec) Encode(message websocket.MessageData) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(newWireMessage(message)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (msgpackCodec) Decode(data []byte) (websocket.MessageData, error) {
+	r := bytes.NewReader(data)
+	dec := msgpack.NewDecoder(r)
+	dec.SetCustomStructTag("json")
+	var w wireMessage
+	if err := dec.Decode(&w); err != nil {
+		return websocket.MessageData{}, err
+	}
+	if r.Len() != 0 {
+		return websocket.MessageData{}, fmt.Errorf("msgpack: %d trailing bytes", r.Len())
+	}
+	return w.message(), nil
+}
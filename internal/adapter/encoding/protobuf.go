@@ -0,0 +1,133 @@
+package encoding
+
+import (
+	ctgv1 "backend_gen/api/proto/ctg/v1"
+	"backend_gen/internal/ports/websocket"
+	"math"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// protobufCodec Protobuf по схеме api/proto/ctg.proto. Отсутствующий основной
+// канал означает потерю сигнала; у необязательных каналов (bpmMother,
+// bpmChild2) потеря передаётся флагом *_lost, как null в остальных форматах
+type protobufCodec struct{}
+
+func (protobufCodec) Name() string { return FormatProtobuf }
+
+func (protobufCodec) Binary() bool { return true }
+
+func (protobufCodec) Encode(m websocket.MessageData) ([]byte, error) {
+	return proto.Marshal(ProtoMessage(m))
+}
+
+func (protobufCodec) Decode(data []byte) (websocket.MessageData, error) {
+	var pb ctgv1.MessageData
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return websocket.MessageData{}, err
+	}
+	return FromProto(&pb), nil
+}
+
+// ProtoMessage переводит сообщение в сгенерированный тип Protobuf
+func ProtoMessage(m websocket.MessageData) *ctgv1.MessageData {
+	pb := &ctgv1.MessageData{
+		SensorId:     m.SensorID,
+		SecFromStart: m.SecFromStart,
+		Data: &ctgv1.SensorData{
+			BpmChild:      present(&m.Data.BPMChild),
+			Uterus:        present(&m.Data.Uterus),
+			Spasms:        present(&m.Data.Spasms),
+			BpmMother:     present(m.Data.BPMMother),
+			BpmChild2:     present(m.Data.BPMChild2),
+			BpmMotherLost: lost(m.Data.BPMMother),
+			BpmChild2Lost: lost(m.Data.BPMChild2),
+		},
+	}
+	for _, a := range m.Annotations {
+		pb.Annotations = append(pb.Annotations, &ctgv1.Annotation{
+			Type:    a.Type,
+			Kind:    a.Kind,
+			Start:   a.Start,
+			End:     present(a.End),
+			Channel: a.Channel,
+		})
+	}
+	for _, mark := range m.Marks {
+		pb.Marks = append(pb.Marks, &ctgv1.Mark{
+			Type:         mark.Type,
+			Source:       mark.Source,
+			SecFromStart: mark.SecFromStart,
+			Note:         mark.Note,
+		})
+	}
+	return pb
+}
+
+// FromProto обратное ProtoMessage
+func FromProto(pb *ctgv1.MessageData) websocket.MessageData {
+	d := pb.GetData()
+	if d == nil {
+		d = &ctgv1.SensorData{}
+	}
+	m := websocket.MessageData{
+		SensorID:     pb.GetSensorId(),
+		SecFromStart: pb.GetSecFromStart(),
+		Data: websocket.SensorData{
+			BPMChild:  orNaN(d.BpmChild),
+			Uterus:    orNaN(d.Uterus),
+			Spasms:    orNaN(d.Spasms),
+			BPMMother: optional(d.BpmMother, d.GetBpmMotherLost()),
+			BPMChild2: optional(d.BpmChild2, d.GetBpmChild2Lost()),
+		},
+	}
+	for _, a := range pb.GetAnnotations() {
+		m.Annotations = append(m.Annotations, websocket.Annotation{
+			Type:    a.GetType(),
+			Kind:    a.GetKind(),
+			Start:   a.GetStart(),
+			End:     a.End,
+			Channel: a.GetChannel(),
+		})
+	}
+	for _, mark := range pb.GetMarks() {
+		m.Marks = append(m.Marks, websocket.Mark{
+			Type:         mark.GetType(),
+			Source:       mark.GetSource(),
+			SecFromStart: mark.GetSecFromStart(),
+			Note:         mark.GetNote(),
+		})
+	}
+	return m
+}
+
+// present копирует значение optional поля; nil и NaN не передаются
+func present(v *float64) *float64 {
+	if v == nil || math.IsNaN(*v) {
+		return nil
+	}
+	return proto.Float64(*v)
+}
+
+// lost сообщает о потере сигнала на необязательном канале
+func lost(v *float64) bool {
+	return v != nil && math.IsNaN(*v)
+}
+
+// optional восстанавливает необязательный канал: NaN при потере сигнала,
+// nil при отсутствии канала
+func optional(v *float64, lost bool) *float64 {
+	if lost {
+		nan := math.NaN()
+		return &nan
+	}
+	return v
+}
+
+// orNaN возвращает NaN для отсутствующего канала (потеря сигнала)
+func orNaN(v *float64) float64 {
+	if v == nil {
+		return math.NaN()
+	}
+	return *v
+}
@@ -0,0 +1,78 @@
+package encoding
+
+import (
+	"backend_gen/internal/ports/websocket"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// wireMessage представление сообщения для MessagePack и CBOR: те же имена полей
+// и omitempty, что и в JSON. Каналы передаются картой, чтобы различать потерю
+// сигнала (nil) и отсутствующий необязательный канал (нет ключа)
+type wireMessage struct {
+	SensorID     string                 `json:"sensorID"`
+	SecFromStart float64                `json:"secFromStart"`
+	Data         channels               `json:"data"`
+	Annotations  []websocket.Annotation `json:"annotations,omitempty"`
+	Marks        []websocket.Mark       `json:"marks,omitempty"`
+}
+
+func newWireMessage(m websocket.MessageData) wireMessage {
+	return wireMessage{
+		SensorID:     m.SensorID,
+		SecFromStart: m.SecFromStart,
+		Data:         m.Data.Channels(),
+		Annotations:  m.Annotations,
+		Marks:        m.Marks,
+	}
+}
+
+func (w wireMessage) message() websocket.MessageData {
+	return websocket.MessageData{
+		SensorID:     w.SensorID,
+		SecFromStart: w.SecFromStart,
+		Data:         websocket.SensorDataFromChannels(w.Data),
+		Annotations:  w.Annotations,
+		Marks:        w.Marks,
+	}
+}
+
+// channels каналы датчика по именам JSON (websocket.SensorData.Channels)
+type channels map[string]*float64
+
+// Порядок каналов как в JSON
+var channelOrder = []string{"bpmChild", "uterus", "spasms", "bpmMother", "bpmChild2"}
+
+// EncodeMsgpack записывает каналы в порядке JSON: msgpack не сортирует такие
+// карты, а одно сообщение должно давать одни и те же байты
+func (c channels) EncodeMsgpack(enc *msgpack.Encoder) error {
+	if err := enc.EncodeMapLen(len(c)); err != nil {
+		return err
+	}
+	for _, name := range channelOrder {
+		v, ok := c[name]
+		if !ok {
+			continue
+		}
+		if err := enc.EncodeString(name); err != nil {
+			return err
+		}
+		encode := enc.EncodeNil
+		if v != nil {
+			encode = func() error { return enc.EncodeFloat64(*v) }
+		}
+		if err := encode(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *channels) DecodeMsgpack(dec *msgpack.Decoder) error {
+	var m map[string]*float64
+	if err := dec.Decode(&m); err != nil {
+		return err
+	}
+	*c = m
+	return nil
+}
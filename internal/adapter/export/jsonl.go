@@ -0,0 +1,43 @@
+package export
+
+import (
+	"backend_gen/internal/ports/export"
+	"backend_gen/internal/ports/websocket"
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// jsonlWriter пишет сообщения построчно текстовым кодировщиком (JSON Lines)
+type jsonlWriter struct {
+	out     io.WriteCloser
+	buf     *bufio.Writer
+	encoder websocket.Encoder
+}
+
+// NewJSONLWriter создает запись сообщений по одному на строку
+func NewJSONLWriter(out io.WriteCloser, encoder websocket.Encoder) (export.Writer, error) {
+	if encoder.Binary() {
+		return nil, fmt.Errorf("encoding %s is binary and cannot be written line by line", encoder.Name())
+	}
+	return &jsonlWriter{out: out, buf: bufio.NewWriter(out), encoder: encoder}, nil
+}
+
+func (w *jsonlWriter) Write(message websocket.MessageData) error {
+	data, err := w.encoder.Encode(message)
+	if err != nil {
+		return err
+	}
+	if _, err := w.buf.Write(data); err != nil {
+		return err
+	}
+	return w.buf.WriteByte('\n')
+}
+
+func (w *jsonlWriter) Close() error {
+	if err := w.buf.Flush(); err != nil {
+		w.out.Close()
+		return err
+	}
+	return w.out.Close()
+}
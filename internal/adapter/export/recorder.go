@@ -0,0 +1,47 @@
+package export
+
+import (
+	"backend_gen/internal/ports/export"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// OpenFunc создает запись сессии поверх открытого файла
+type OpenFunc func(out io.WriteCloser, start time.Time) (export.Writer, error)
+
+// fileRecorder пишет каждую сессию в отдельный файл каталога dir
+type fileRecorder struct {
+	dir    string
+	prefix string
+	ext    string
+	open   OpenFunc
+}
+
+// NewFileRecorder создает запись сессий в файлы вида <prefix>_<время начала>.<ext>
+func NewFileRecorder(dir, prefix, ext string, open OpenFunc) (export.Recorder, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create export directory: %w", err)
+	}
+	return &fileRecorder{dir: dir, prefix: prefix, ext: ext, open: open}, nil
+}
+
+func (r *fileRecorder) Open(start time.Time) (export.Writer, error) {
+	name := fmt.Sprintf("%s_%s.%s", r.prefix, start.UTC().Format("20060102T150405Z"), r.ext)
+	path := filepath.Join(r.dir, name)
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	writer, err := r.open(file, start)
+	if err != nil {
+		file.Close()
+		os.Remove(path)
+		return nil, err
+	}
+	slog.Info("Session recording started", "file", path)
+	return writer, nil
+}
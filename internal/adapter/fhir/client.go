@@ -0,0 +1,244 @@
+package fhir
+
+import (
+	"backend_gen/internal/adapter/encoding"
+	"backend_gen/internal/ports/websocket"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Размер очереди Observation на отправку
+const queueSize = 16
+
+// Encodings форматы сообщений, которые переносит транспорт: Observation
+// собирается из MessageData в JSON
+var Encodings = []string{encoding.FormatJSON}
+
+// ClientConfig параметры отправки Observation на FHIR сервер
+type ClientConfig struct {
+	Observation  Config
+	Retries      int
+	RetryBackoff time.Duration
+	Timeout      time.Duration
+}
+
+// client собирает Observation по окнам из JSON сообщений сессии и отправляет
+// их POST запросом в [base]/Observation. Реализует порт websocket.Client
+type client struct {
+	cfg  ClientConfig
+	http *http.Client
+
+	mu     sync.Mutex
+	url    string
+	token  string
+	window *Window
+	queue  chan *Observation
+	done   chan struct{}
+}
+
+// NewClient создает транспорт FHIR
+func NewClient(cfg ClientConfig) websocket.Client {
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = 500 * time.Millisecond
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	return &client{cfg: cfg, http: &http.Client{Timeout: cfg.Timeout}}
+}
+
+// Connect запоминает базовый адрес FHIR сервера; токен передаётся как Bearer
+func (c *client) Connect(rawURL string, token string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.queue != nil {
+		return fmt.Errorf("already connected")
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid fhir url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported fhir url scheme %q", u.Scheme)
+	}
+
+	c.url = strings.TrimSuffix(rawURL, "/") + "/Observation"
+	c.token = token
+	c.window = nil
+	c.queue = make(chan *Observation, queueSize)
+	c.done = make(chan struct{})
+	go c.run(c.queue, c.done)
+
+	slog.Info("FHIR transport started", "url", c.url)
+	return nil
+}
+
+// Disconnect отправляет последнее неполное окно и ждёт окончания отправки
+func (c *client) Disconnect() error {
+	c.mu.Lock()
+	queue, done := c.queue, c.done
+	if queue != nil && c.window != nil {
+		if obs := c.window.Flush(); obs != nil {
+			select {
+			case queue <- obs:
+			default:
+				slog.Error("FHIR queue is full, last observation dropped")
+			}
+		}
+	}
+	c.queue = nil
+	c.mu.Unlock()
+
+	if queue == nil {
+		return nil
+	}
+	close(queue)
+	<-done
+	slog.Info("FHIR transport stopped")
+	return nil
+}
+
+func (c *client) IsConnected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.queue != nil
+}
+
+// SendMessage добавляет JSON сообщение в текущее окно
+func (c *client) SendMessage(message []byte) error {
+	m, err := decodeMessage(message)
+	if err != nil {
+		return fmt.Errorf("fhir transport requires json encoding: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.queue == nil {
+		return fmt.Errorf("not connected")
+	}
+	if c.window == nil {
+		// Начало сессии восстанавливаем по первому сообщению
+		c.window = NewWindow(c.cfg.Observation, time.Now().Add(-seconds(m.SecFromStart)))
+	}
+	if obs := c.window.Add(m); obs != nil {
+		select {
+		case c.queue <- obs:
+		default:
+			return fmt.Errorf("fhir queue is full, observation dropped")
+		}
+	}
+	return nil
+}
+
+// SendBinary не поддерживается: окно собирается из JSON сообщений
+func (c *client) SendBinary(message []byte) error {
+	return fmt.Errorf("fhir transport requires json encoding")
+}
+
+func (c *client) run(queue chan *Observation, done chan struct{}) {
+	defer close(done)
+	for obs := range queue {
+		if err := c.post(obs); err != nil {
+			slog.Error("Failed to post FHIR observation", "id", obs.ID, "error", err)
+		}
+	}
+}
+
+// post отправляет Observation с повторами при сетевых ошибках, 429 и 5xx
+func (c *client) post(obs *Observation) error {
+	body, err := json.Marshal(obs)
+	if err != nil {
+		return err
+	}
+
+	backoff := c.cfg.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		var retry bool
+		retry, err = c.send(body)
+		if err == nil {
+			slog.Debug("FHIR observation posted", "id", obs.ID)
+			return nil
+		}
+		if !retry || attempt >= c.cfg.Retries {
+			return err
+		}
+		slog.Warn("Retrying FHIR observation", "id", obs.ID, "attempt", attempt+1, "error", err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (c *client) send(body []byte) (retry bool, err error) {
+	req, err := http.NewRequest(http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/fhir+json")
+	req.Header.Set("Accept", "application/fhir+json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("unexpected HTTP status %s", resp.Status)
+	}
+	return false, fmt.Errorf("observation rejected with HTTP status %s", resp.Status)
+}
+
+// decodeMessage разбирает JSON сообщение; null в каналах - потеря сигнала (NaN)
+func decodeMessage(data []byte) (websocket.MessageData, error) {
+	var raw struct {
+		SensorID     string  `json:"sensorID"`
+		SecFromStart float64 `json:"secFromStart"`
+		Data         struct {
+			BPMChild  *float64 `json:"bpmChild"`
+			Uterus    *float64 `json:"uterus"`
+			Spasms    *float64 `json:"spasms"`
+			BPMMother *float64 `json:"bpmMother"`
+			BPMChild2 *float64 `json:"bpmChild2"`
+		} `json:"data"`
+		Annotations []websocket.Annotation `json:"annotations"`
+		Marks       []websocket.Mark       `json:"marks"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return websocket.MessageData{}, err
+	}
+
+	nan := func(v *float64) float64 {
+		if v == nil {
+			return math.NaN()
+		}
+		return *v
+	}
+	return websocket.MessageData{
+		SensorID:     raw.SensorID,
+		SecFromStart: raw.SecFromStart,
+		Data: websocket.SensorData{
+			BPMChild:  nan(raw.Data.BPMChild),
+			Uterus:    nan(raw.Data.Uterus),
+			Spasms:    nan(raw.Data.Spasms),
+			BPMMother: raw.Data.BPMMother,
+			BPMChild2: raw.Data.BPMChild2,
+		},
+		Annotations: raw.Annotations,
+		Marks:       raw.Marks,
+	}, nil
+}
@@ -0,0 +1,38 @@
+package fhir
+
+import (
+	"backend_gen/internal/ports/export"
+	"backend_gen/internal/ports/websocket"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// ndjsonWriter пишет Observation по окнам в формате NDJSON (FHIR Bulk Data)
+type ndjsonWriter struct {
+	out    io.WriteCloser
+	enc    *json.Encoder
+	window *Window
+}
+
+// NewNDJSONWriter создает запись Observation для сессии, начавшейся в start
+func NewNDJSONWriter(out io.WriteCloser, cfg Config, start time.Time) export.Writer {
+	return &ndjsonWriter{out: out, enc: json.NewEncoder(out), window: NewWindow(cfg, start)}
+}
+
+func (w *ndjsonWriter) Write(message websocket.MessageData) error {
+	if obs := w.window.Add(message); obs != nil {
+		return w.enc.Encode(obs)
+	}
+	return nil
+}
+
+func (w *ndjsonWriter) Close() error {
+	if obs := w.window.Flush(); obs != nil {
+		if err := w.enc.Encode(obs); err != nil {
+			w.out.Close()
+			return err
+		}
+	}
+	return w.out.Close()
+}
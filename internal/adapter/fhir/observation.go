@@ -0,0 +1,299 @@
+package fhir
+
+import (
+	"backend_gen/internal/ports/websocket"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Системы кодирования
+const (
+	systemLOINC    = "http://loinc.org"
+	systemUCUM     = "http://unitsofmeasure.org"
+	systemCategory = "http://terminology.hl7.org/CodeSystem/observation-category"
+	// Локальные коды каналов и идентификаторы генератора
+	systemChannel     = "urn:ctg-generator:channel"
+	systemSensor      = "urn:ctg-generator:sensor"
+	systemObservation = "urn:ctg-generator:observation"
+)
+
+// Observation ресурс FHIR R4 Observation (используемое подмножество полей)
+type Observation struct {
+	ResourceType    string            `json:"resourceType"`
+	ID              string            `json:"id,omitempty"`
+	Identifier      []Identifier      `json:"identifier,omitempty"`
+	Status          string            `json:"status"`
+	Category        []CodeableConcept `json:"category,omitempty"`
+	Code            CodeableConcept   `json:"code"`
+	Subject         *Reference        `json:"subject,omitempty"`
+	EffectivePeriod *Period           `json:"effectivePeriod,omitempty"`
+	Issued          string            `json:"issued,omitempty"`
+	Device          *Reference        `json:"device,omitempty"`
+	Note            []Annotation      `json:"note,omitempty"`
+	Component       []Component       `json:"component"`
+}
+
+type Coding struct {
+	System  string `json:"system,omitempty"`
+	Code    string `json:"code"`
+	Display string `json:"display,omitempty"`
+}
+
+type CodeableConcept struct {
+	Coding []Coding `json:"coding,omitempty"`
+	Text   string   `json:"text,omitempty"`
+}
+
+type Identifier struct {
+	System string `json:"system,omitempty"`
+	Value  string `json:"value"`
+}
+
+type Reference struct {
+	Reference  string      `json:"reference,omitempty"`
+	Identifier *Identifier `json:"identifier,omitempty"`
+	Display    string      `json:"display,omitempty"`
+}
+
+type Period struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+type Quantity struct {
+	Value  float64 `json:"value"`
+	Unit   string  `json:"unit,omitempty"`
+	System string  `json:"system,omitempty"`
+	Code   string  `json:"code,omitempty"`
+}
+
+// SampledData ряд отсчётов с постоянным шагом; E - значение не получено
+type SampledData struct {
+	Origin     Quantity `json:"origin"`
+	Period     float64  `json:"period"` // миллисекунды
+	Dimensions int      `json:"dimensions"`
+	Data       string   `json:"data"`
+}
+
+type Component struct {
+	Code             CodeableConcept `json:"code"`
+	ValueSampledData *SampledData    `json:"valueSampledData"`
+}
+
+type Annotation struct {
+	Time string `json:"time,omitempty"`
+	Text string `json:"text"`
+}
+
+// channel канал SensorData: код компонента и единица измерения
+type channel struct {
+	code     Coding
+	text     string
+	unit     string
+	unitCode string
+	value    func(websocket.SensorData) (float64, bool)
+}
+
+// Каналы: ЧСС - LOINC, токограмма - локальные коды
+var channels = []channel{
+	{
+		code: Coding{System: systemLOINC, Code: "55283-6", Display: "Fetal heart rate"},
+		text: "Fetal heart rate", unit: "beats/minute", unitCode: "/min",
+		value: func(d websocket.SensorData) (float64, bool) { return d.BPMChild, true },
+	},
+	{
+		code: Coding{System: systemLOINC, Code: "55283-6", Display: "Fetal heart rate"},
+		text: "Fetal heart rate, second fetus", unit: "beats/minute", unitCode: "/min",
+		value: func(d websocket.SensorData) (float64, bool) { return optional(d.BPMChild2) },
+	},
+	{
+		code: Coding{System: systemLOINC, Code: "8867-4", Display: "Heart rate"},
+		text: "Maternal heart rate", unit: "beats/minute", unitCode: "/min",
+		value: func(d websocket.SensorData) (float64, bool) { return optional(d.BPMMother) },
+	},
+	{
+		code: Coding{System: systemChannel, Code: "uterine-activity", Display: "Uterine activity"},
+		text: "Uterine activity", unit: "mmHg", unitCode: "mm[Hg]",
+		value: func(d websocket.SensorData) (float64, bool) { return d.Uterus, true },
+	},
+	{
+		code: Coding{System: systemChannel, Code: "contraction-intensity", Display: "Uterine contraction intensity"},
+		text: "Uterine contraction intensity", unit: "1", unitCode: "1",
+		value: func(d websocket.SensorData) (float64, bool) { return d.Spasms, true },
+	},
+}
+
+func optional(v *float64) (float64, bool) {
+	if v == nil {
+		return 0, false
+	}
+	return *v, true
+}
+
+// Config параметры ресурсов Observation
+type Config struct {
+	SensorID string
+	// PatientID идентификатор пациентки для subject ("" = без subject)
+	PatientID string
+	// Window длительность окна одного Observation
+	Window time.Duration
+	// Period шаг отсчётов SampledData
+	Period time.Duration
+}
+
+func (cfg Config) withDefaults() Config {
+	if cfg.Window <= 0 {
+		cfg.Window = 10 * time.Second
+	}
+	if cfg.Period <= 0 {
+		cfg.Period = 120 * time.Millisecond
+	}
+	return cfg
+}
+
+// Window накапливает сообщения сессии и собирает из них Observation по окнам
+type Window struct {
+	cfg     Config
+	start   time.Time
+	index   int
+	samples []websocket.MessageData
+}
+
+// NewWindow создает накопитель для сессии, начавшейся в start
+func NewWindow(cfg Config, start time.Time) *Window {
+	return &Window{cfg: cfg.withDefaults(), start: start}
+}
+
+// Add добавляет сообщение и возвращает Observation, если окно заполнено
+func (w *Window) Add(m websocket.MessageData) *Observation {
+	if len(w.samples) > 0 && m.SecFromStart-w.samples[0].SecFromStart >= w.cfg.Window.Seconds() {
+		obs := w.Flush()
+		w.samples = append(w.samples, m)
+		return obs
+	}
+	w.samples = append(w.samples, m)
+	return nil
+}
+
+// Flush собирает Observation из накопленных сообщений (nil, если их нет)
+func (w *Window) Flush() *Observation {
+	if len(w.samples) == 0 {
+		return nil
+	}
+	obs := w.build(w.samples)
+	w.samples = nil
+	w.index++
+	return obs
+}
+
+// build размещает отсчёты по времени сообщений с шагом Period от первого
+// сообщения окна: пропущенные тики передаются как E, как и потеря сигнала
+func (w *Window) build(samples []websocket.MessageData) *Observation {
+	first := samples[0].SecFromStart
+	for _, m := range samples {
+		first = math.Min(first, m.SecFromStart)
+	}
+	period := w.cfg.Period.Seconds()
+	ticks := make([]int, len(samples))
+	count := 0
+	for i, m := range samples {
+		ticks[i] = int(math.Round((m.SecFromStart - first) / period))
+		count = max(count, ticks[i]+1)
+	}
+	begin := w.start.Add(seconds(first))
+	end := begin.Add(time.Duration(count) * w.cfg.Period)
+	id := fmt.Sprintf("%s-%d-%d", w.cfg.SensorID, w.start.Unix(), w.index)
+
+	obs := &Observation{
+		ResourceType: "Observation",
+		ID:           sanitizeID(id),
+		Identifier:   []Identifier{{System: systemObservation, Value: id}},
+		Status:       "final",
+		Category: []CodeableConcept{{
+			Coding: []Coding{{System: systemCategory, Code: "procedure", Display: "Procedure"}},
+		}},
+		Code: CodeableConcept{
+			Coding: []Coding{{System: systemChannel, Code: "ctg", Display: "Cardiotocography"}},
+			Text:   "Cardiotocography",
+		},
+		EffectivePeriod: &Period{Start: begin.Format(time.RFC3339Nano), End: end.Format(time.RFC3339Nano)},
+		Issued:          time.Now().Format(time.RFC3339Nano),
+		Device: &Reference{
+			Identifier: &Identifier{System: systemSensor, Value: w.cfg.SensorID},
+			Display:    "CTG sensor " + w.cfg.SensorID,
+		},
+	}
+	if w.cfg.PatientID != "" {
+		obs.Subject = &Reference{Reference: "Patient/" + w.cfg.PatientID}
+	}
+
+	for _, ch := range channels {
+		data := make([]string, count)
+		for i := range data {
+			data[i] = "E"
+		}
+		present := false
+		for i, m := range samples {
+			v, ok := ch.value(m.Data)
+			present = present || ok
+			if ok && !math.IsNaN(v) {
+				data[ticks[i]] = strconv.FormatFloat(v, 'f', 2, 64)
+			}
+		}
+		// Необязательные каналы (мать, второй плод) включаются, только если они есть в данных
+		if !present {
+			continue
+		}
+		obs.Component = append(obs.Component, Component{
+			Code: CodeableConcept{Coding: []Coding{ch.code}, Text: ch.text},
+			ValueSampledData: &SampledData{
+				Origin:     Quantity{Value: 0, Unit: ch.unit, System: systemUCUM, Code: ch.unitCode},
+				Period:     float64(w.cfg.Period.Milliseconds()),
+				Dimensions: 1,
+				Data:       strings.Join(data, " "),
+			},
+		})
+	}
+
+	for _, m := range samples {
+		for _, a := range m.Annotations {
+			text := fmt.Sprintf("annotation %s %s", a.Type, a.Kind)
+			if a.End != nil {
+				text += fmt.Sprintf(" until %s", w.start.Add(seconds(*a.End)).Format(time.RFC3339))
+			}
+			if a.Channel != "" {
+				text += " channel=" + a.Channel
+			}
+			obs.Note = append(obs.Note, Annotation{Time: w.start.Add(seconds(a.Start)).Format(time.RFC3339Nano), Text: text})
+		}
+		for _, mark := range m.Marks {
+			text := fmt.Sprintf("mark %s %s", mark.Type, mark.Source)
+			if mark.Note != "" {
+				text += ": " + mark.Note
+			}
+			obs.Note = append(obs.Note, Annotation{Time: w.start.Add(seconds(mark.SecFromStart)).Format(time.RFC3339Nano), Text: text})
+		}
+	}
+	return obs
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// sanitizeID приводит строку к формату id FHIR: [A-Za-z0-9-.]{1,64}
+func sanitizeID(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '.') {
+			b[i] = '-'
+		}
+	}
+	if len(b) > 64 {
+		b = b[len(b)-64:]
+	}
+	return string(b)
+}
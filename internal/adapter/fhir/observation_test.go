@@ -0,0 +1,87 @@
+package fhir
+
+import (
+	"backend_gen/internal/ports/websocket"
+	"math"
+	"testing"
+	"time"
+)
+
+func ptr(v float64) *float64 { return &v }
+
+func sample(sec, bpm float64) websocket.MessageData {
+	return websocket.MessageData{SecFromStart: sec, Data: websocket.SensorData{BPMChild: bpm, Uterus: 10, Spasms: 0}}
+}
+
+// component данные компонента по тексту канала ("" = канала нет)
+func component(obs *Observation, text string) *SampledData {
+	for _, c := range obs.Component {
+		if c.Code.Text == text {
+			return c.ValueSampledData
+		}
+	}
+	return nil
+}
+
+func TestWindowPlacesSamplesByTime(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	w := NewWindow(Config{SensorID: "s1", Window: 10 * time.Second, Period: 250 * time.Millisecond}, start)
+
+	// Два тика пропущены, у третьего отсчёта потеря сигнала
+	for _, m := range []websocket.MessageData{sample(1, 140), sample(1.25, 141), sample(2, 142), sample(2.25, math.NaN())} {
+		if obs := w.Add(m); obs != nil {
+			t.Fatalf("window flushed early at %.2fs", m.SecFromStart)
+		}
+	}
+	obs := w.Flush()
+	if obs == nil {
+		t.Fatal("no observation")
+	}
+
+	fhr := component(obs, "Fetal heart rate")
+	if fhr == nil {
+		t.Fatal("fetal heart rate component is missing")
+	}
+	if want := "140.00 141.00 E E 142.00 E"; fhr.Data != want {
+		t.Errorf("data = %q, want %q", fhr.Data, want)
+	}
+	if fhr.Period != 250 {
+		t.Errorf("period = %v ms", fhr.Period)
+	}
+	if obs.EffectivePeriod.Start != "2024-03-01T12:00:01Z" || obs.EffectivePeriod.End != "2024-03-01T12:00:02.5Z" {
+		t.Errorf("effective period = %+v", obs.EffectivePeriod)
+	}
+	if component(obs, "Maternal heart rate") != nil {
+		t.Error("absent optional channel must be omitted")
+	}
+	if w.Flush() != nil {
+		t.Error("second flush must be empty")
+	}
+}
+
+func TestWindowOptionalChannelAndSplit(t *testing.T) {
+	w := NewWindow(Config{SensorID: "s1", Window: time.Second, Period: 500 * time.Millisecond}, time.Unix(0, 0))
+
+	first := sample(0.5, 140)
+	first.Data.BPMMother = ptr(80)
+	w.Add(first)
+	w.Add(sample(1, 141))
+	obs := w.Add(sample(1.5, 142))
+	if obs == nil {
+		t.Fatal("window must flush after its duration")
+	}
+	if got := component(obs, "Fetal heart rate").Data; got != "140.00 141.00" {
+		t.Errorf("first window data = %q", got)
+	}
+	if got := component(obs, "Maternal heart rate").Data; got != "80.00 E" {
+		t.Errorf("maternal data = %q", got)
+	}
+
+	next := w.Flush()
+	if got := component(next, "Fetal heart rate").Data; got != "142.00" {
+		t.Errorf("second window data = %q", got)
+	}
+	if obs.ID == next.ID {
+		t.Errorf("windows share id %q", obs.ID)
+	}
+}
@@ -0,0 +1,254 @@
+package generator
+
+import (
+	"backend_gen/internal/ports/generator"
+	"backend_gen/internal/ports/websocket"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Виды артефактов (websocket.Annotation.Kind для типа artifact)
+const (
+	artifactSignalLoss      = "signal_loss"
+	artifactSpike           = "spike"
+	artifactHalving         = "halving"
+	artifactDoubling        = "doubling"
+	artifactMaternalCapture = "maternal_capture"
+)
+
+// Пульс матери, подставляемый при захвате, если у базового генератора нет
+// собственного канала пульса матери: блуждает около этого значения
+const fallbackMaternalBPM = 80.0
+
+// ArtifactConfig частоты артефактов (событий в час) и способ кодирования потери сигнала
+type ArtifactConfig struct {
+	LossAsNaN              bool // true = потеря сигнала передаётся как NaN, иначе нулями
+	SignalLossPerHour      float64
+	SpikesPerHour          float64
+	HalvingDoublingPerHour float64
+	MaternalCapturePerHour float64
+	// UterusLossPerHour потеря сигнала датчика тонуса, независимая от FHR
+	UterusLossPerHour float64
+}
+
+// artifactRate частота артефакта одного вида, событий в час
+type artifactRate struct {
+	kind    string
+	perHour float64
+}
+
+// artifactTrack артефакты одного канала. Датчики независимы, поэтому у каждого
+// канала свой активный артефакт и свой набор возможных артефактов
+type artifactTrack struct {
+	channel string // websocket.Annotation.Channel ("" для основного канала)
+	rates   []artifactRate
+	active  *episode
+}
+
+// artifactGenerator оборачивает любой генератор и добавляет в FHR каналы
+// артефакты реальных датчиков: потерю сигнала, выбросы, ошибки удвоения/деления
+// частоты и захват материнского пульса, а в канал тонуса - потерю сигнала
+type artifactGenerator struct {
+	base generator.DataGenerator
+	cfg  ArtifactConfig
+	rng  *rand.Rand
+
+	lastTimestamp float64
+
+	// Каналы FHR, FHR второго плода и тонуса
+	fhr, fhr2, uterus artifactTrack
+
+	// Материнский пульс, подставляемый при захвате, если у базового
+	// генератора нет собственного канала пульса матери
+	maternalBPM float64
+
+	annotations []websocket.Annotation
+}
+
+// NewArtifactGenerator создает слой артефактов поверх генератора base
+func NewArtifactGenerator(base generator.DataGenerator, cfg ArtifactConfig) generator.DataGenerator {
+	slog.Info("Artifact layer enabled",
+		"signal_loss_per_hour", cfg.SignalLossPerHour,
+		"spikes_per_hour", cfg.SpikesPerHour,
+		"halving_doubling_per_hour", cfg.HalvingDoublingPerHour,
+		"maternal_capture_per_hour", cfg.MaternalCapturePerHour,
+		"uterus_loss_per_hour", cfg.UterusLossPerHour,
+		"loss_as_nan", cfg.LossAsNaN)
+
+	fhrRates := []artifactRate{
+		{artifactSignalLoss, cfg.SignalLossPerHour},
+		{artifactSpike, cfg.SpikesPerHour},
+		{artifactHalving, cfg.HalvingDoublingPerHour / 2},
+		{artifactDoubling, cfg.HalvingDoublingPerHour / 2},
+		{artifactMaternalCapture, cfg.MaternalCapturePerHour},
+	}
+	return &artifactGenerator{
+		base:        base,
+		cfg:         cfg,
+		rng:         rand.New(rand.NewSource(time.Now().UnixNano())),
+		fhr:         artifactTrack{rates: fhrRates},
+		fhr2:        artifactTrack{channel: "bpmChild2", rates: fhrRates},
+		uterus:      artifactTrack{channel: "uterus", rates: []artifactRate{{artifactSignalLoss, cfg.UterusLossPerHour}}},
+		maternalBPM: fallbackMaternalBPM,
+	}
+}
+
+// GenerateNext генерирует точку базовым генератором и искажает её
+func (g *artifactGenerator) GenerateNext(timestamp float64) websocket.SensorData {
+	data := g.base.GenerateNext(timestamp)
+
+	dt := timestamp - g.lastTimestamp
+	g.lastTimestamp = timestamp
+
+	// Материнский пульс медленно блуждает около fallbackMaternalBPM
+	g.maternalBPM += g.rng.Float64()*0.6 - 0.3
+	g.maternalBPM = math.Min(math.Max(g.maternalBPM, 70), 95)
+
+	data.BPMChild = g.distort(&g.fhr, data.BPMChild, data.BPMMother, timestamp, dt)
+	if data.BPMChild2 != nil {
+		bpm2 := g.distort(&g.fhr2, *data.BPMChild2, data.BPMMother, timestamp, dt)
+		data.BPMChild2 = &bpm2
+	}
+	data.Uterus = g.distort(&g.uterus, data.Uterus, nil, timestamp, dt)
+	return data
+}
+
+// distort обновляет артефакт канала track и применяет его к значению bpm
+// (для канала тонуса - к значению тонуса)
+func (g *artifactGenerator) distort(track *artifactTrack, bpm float64, mother *float64, timestamp, dt float64) float64 {
+	if track.active != nil && timestamp > track.active.end {
+		track.active = nil
+	}
+	if track.active == nil {
+		g.startArtifact(track, timestamp, dt)
+	}
+	if track.active == nil || timestamp < track.active.start {
+		return bpm
+	}
+
+	switch track.active.kind {
+	case artifactSignalLoss:
+		if g.cfg.LossAsNaN {
+			return math.NaN()
+		}
+		return 0
+	case artifactSpike:
+		return bpm + track.active.amplitude
+	case artifactHalving:
+		return bpm / 2
+	case artifactDoubling:
+		return bpm * 2
+	case artifactMaternalCapture:
+		// Совпадение каналов: датчик плода записывает пульс матери
+		if mother != nil {
+			return *mother
+		}
+		return g.maternalBPM + g.rng.Float64()*2 - 1
+	}
+	return bpm
+}
+
+// startArtifact с вероятностью, пропорциональной частоте, начинает новый артефакт канала track
+func (g *artifactGenerator) startArtifact(track *artifactTrack, t, dt float64) {
+	if dt <= 0 {
+		return
+	}
+	r := g.rng.Float64()
+	for _, rate := range track.rates {
+		p := rate.perHour * dt / 3600
+		if r >= p {
+			r -= p
+			continue
+		}
+
+		e := &episode{kind: rate.kind, start: t}
+		switch rate.kind {
+		case artifactSignalLoss:
+			e.end = t + 2 + g.rng.Float64()*28 // 2-30 сек
+		case artifactSpike:
+			// Одиночный выброс на один отсчёт, ±30-60 BPM
+			e.end = t
+			e.amplitude = 30 + g.rng.Float64()*30
+			if g.rng.Float64() < 0.5 {
+				e.amplitude = -e.amplitude
+			}
+		case artifactHalving, artifactDoubling:
+			e.end = t + 5 + g.rng.Float64()*15 // 5-20 сек
+		case artifactMaternalCapture:
+			e.end = t + 20 + g.rng.Float64()*40 // 20-60 сек
+		}
+		track.active = e
+
+		end := e.end
+		g.annotations = append(g.annotations, websocket.Annotation{
+			Type:    websocket.AnnotationArtifact,
+			Kind:    e.kind,
+			Start:   e.start,
+			End:     &end,
+			Channel: track.channel,
+		})
+		return
+	}
+}
+
+// DrainAnnotations объединяет разметку базового генератора и артефактов
+func (g *artifactGenerator) DrainAnnotations() []websocket.Annotation {
+	var annotations []websocket.Annotation
+	if source, ok := g.base.(generator.AnnotationSource); ok {
+		annotations = source.DrainAnnotations()
+	}
+	annotations = append(annotations, g.annotations...)
+	g.annotations = nil
+	return annotations
+}
+
+// DrainMarks передаёт отметки базового генератора без изменений
+func (g *artifactGenerator) DrainMarks() []websocket.Mark {
+	if source, ok := g.base.(generator.MarkSource); ok {
+		return source.DrainMarks()
+	}
+	return nil
+}
+
+// Reset сбрасывает базовый генератор и состояние артефактов
+func (g *artifactGenerator) Reset() {
+	g.base.Reset()
+	g.rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+	g.lastTimestamp = 0
+	g.fhr.active, g.fhr2.active, g.uterus.active = nil, nil, nil
+	g.maternalBPM = fallbackMaternalBPM
+	g.annotations = nil
+}
+
+// SetProfile передаёт профиль пациентки базовому генератору; ok = false,
+// если базовый генератор профиль не учитывает
+func (g *artifactGenerator) SetProfile(profile generator.Profile) (generator.ProfileNorms, bool) {
+	if aware, ok := g.base.(generator.ProfileAware); ok {
+		return aware.SetProfile(profile)
+	}
+	return generator.ProfileNorms{}, false
+}
+
+// SetFetalState передаёт смену состояния плода базовому генератору
+func (g *artifactGenerator) SetFetalState(state string) error {
+	if control, ok := g.base.(generator.FetalStateControl); ok {
+		return control.SetFetalState(state)
+	}
+	return fmt.Errorf("generator does not support fetal state switching")
+}
+
+// FetalState возвращает состояние плода базового генератора
+func (g *artifactGenerator) FetalState() string {
+	if control, ok := g.base.(generator.FetalStateControl); ok {
+		return control.FetalState()
+	}
+	return ""
+}
+
+// SetParameters передаёт параметры базовому генератору
+func (g *artifactGenerator) SetParameters(params generator.GenerationParameters) {
+	g.base.SetParameters(params)
+}
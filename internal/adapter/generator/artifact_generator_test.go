@@ -0,0 +1,192 @@
+package generator
+
+import (
+	"backend_gen/internal/ports/generator"
+	"backend_gen/internal/ports/websocket"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// Частота, при которой артефакт начинается в каждую секунду
+const alwaysPerHour = 3600.0
+
+// constGenerator базовый генератор с постоянной точкой
+type constGenerator struct {
+	data   websocket.SensorData
+	resets int
+}
+
+func (g *constGenerator) GenerateNext(float64) websocket.SensorData {
+	data := g.data
+	if g.data.BPMChild2 != nil {
+		bpm2 := *g.data.BPMChild2
+		data.BPMChild2 = &bpm2
+	}
+	return data
+}
+
+func (g *constGenerator) Reset()                                       { g.resets++ }
+func (g *constGenerator) SetParameters(generator.GenerationParameters) {}
+
+func testArtifacts(base *constGenerator, cfg ArtifactConfig) *artifactGenerator {
+	g := NewArtifactGenerator(base, cfg).(*artifactGenerator)
+	g.rng = rand.New(rand.NewSource(1))
+	return g
+}
+
+func twinsPoint() *constGenerator {
+	bpm2 := 145.0
+	return &constGenerator{data: websocket.SensorData{BPMChild: 140, BPMChild2: &bpm2, Uterus: 20, Spasms: 20}}
+}
+
+// lostValue проверяет, что значение передано как потеря сигнала
+func lostValue(v float64, lossAsNaN bool) bool {
+	if lossAsNaN {
+		return math.IsNaN(v)
+	}
+	return v == 0
+}
+
+func TestArtifactSignalLoss(t *testing.T) {
+	for _, lossAsNaN := range []bool{false, true} {
+		g := testArtifacts(twinsPoint(), ArtifactConfig{
+			LossAsNaN:         lossAsNaN,
+			SignalLossPerHour: alwaysPerHour,
+			UterusLossPerHour: alwaysPerHour,
+		})
+
+		// В первой точке интервал нулевой: артефакт не начинается
+		if data := g.GenerateNext(0); data.BPMChild != 140 || data.Uterus != 20 {
+			t.Errorf("loss as NaN %v: first point distorted: %+v", lossAsNaN, data)
+		}
+		for k := 1; k < 60; k++ {
+			data := g.GenerateNext(float64(k))
+			if !lostValue(data.BPMChild, lossAsNaN) || !lostValue(*data.BPMChild2, lossAsNaN) || !lostValue(data.Uterus, lossAsNaN) {
+				t.Fatalf("loss as NaN %v at %d s: %+v", lossAsNaN, k, data)
+			}
+			if data.Spasms != 20 {
+				t.Fatalf("spasms distorted at %d s: %v", k, data.Spasms)
+			}
+		}
+
+		// Датчики независимы: у каждого канала своя разметка потери
+		channels := make(map[string]int)
+		for _, a := range g.DrainAnnotations() {
+			channels[a.Channel]++
+			if a.Type != websocket.AnnotationArtifact || a.Kind != artifactSignalLoss {
+				t.Errorf("annotation %+v, want signal loss", a)
+			}
+			if d := *a.End - a.Start; d < 2 || d > 30 {
+				t.Errorf("signal loss on %q lasts %v s, want 2-30", a.Channel, d)
+			}
+		}
+		for _, channel := range []string{"", "bpmChild2", "uterus"} {
+			if channels[channel] == 0 {
+				t.Errorf("loss as NaN %v: no signal loss on channel %q", lossAsNaN, channel)
+			}
+		}
+	}
+}
+
+func TestArtifactUterusLossOnly(t *testing.T) {
+	g := testArtifacts(twinsPoint(), ArtifactConfig{UterusLossPerHour: alwaysPerHour})
+	for k := range 30 {
+		data := g.GenerateNext(float64(k))
+		if data.BPMChild != 140 || *data.BPMChild2 != 145 {
+			t.Fatalf("FHR distorted at %d s: %+v", k, data)
+		}
+		if k > 0 && data.Uterus != 0 {
+			t.Fatalf("uterus at %d s = %v, want signal loss", k, data.Uterus)
+		}
+	}
+	for _, a := range g.DrainAnnotations() {
+		if a.Channel != "uterus" {
+			t.Errorf("annotation on channel %q, want uterus only", a.Channel)
+		}
+	}
+}
+
+func TestArtifactKinds(t *testing.T) {
+	mother := 90.0
+	cases := []struct {
+		name   string
+		cfg    ArtifactConfig
+		mother *float64
+		check  func(bpm float64, kind string) bool
+	}{
+		{"spike", ArtifactConfig{SpikesPerHour: alwaysPerHour}, nil, func(bpm float64, kind string) bool {
+			d := math.Abs(bpm - 140)
+			return kind == artifactSpike && d >= 30 && d <= 60
+		}},
+		{"halving and doubling", ArtifactConfig{HalvingDoublingPerHour: alwaysPerHour}, nil, func(bpm float64, kind string) bool {
+			return kind == artifactHalving && bpm == 70 || kind == artifactDoubling && bpm == 280
+		}},
+		// Пульс матери берётся из канала матери, а без него - около fallbackMaternalBPM
+		{"maternal capture", ArtifactConfig{MaternalCapturePerHour: alwaysPerHour}, &mother, func(bpm float64, kind string) bool {
+			return kind == artifactMaternalCapture && bpm == mother
+		}},
+		{"maternal capture fallback", ArtifactConfig{MaternalCapturePerHour: alwaysPerHour}, nil, func(bpm float64, kind string) bool {
+			return kind == artifactMaternalCapture && bpm >= 69 && bpm <= 96
+		}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			base := &constGenerator{data: websocket.SensorData{BPMChild: 140, BPMMother: tc.mother, Uterus: 20}}
+			g := testArtifacts(base, tc.cfg)
+			g.GenerateNext(0)
+
+			var kind string
+			for k := 1; k < 120; k++ {
+				data := g.GenerateNext(float64(k))
+				// Вид текущего артефакта - из последней разметки, начавшейся не позже k
+				for _, a := range g.DrainAnnotations() {
+					kind = a.Kind
+				}
+				if !tc.check(data.BPMChild, kind) {
+					t.Fatalf("FHR at %d s = %v with %s", k, data.BPMChild, kind)
+				}
+				if data.Uterus != 20 {
+					t.Fatalf("uterus distorted at %d s: %v", k, data.Uterus)
+				}
+			}
+		})
+	}
+}
+
+func TestArtifactDisabledRates(t *testing.T) {
+	base := twinsPoint()
+	g := testArtifacts(base, ArtifactConfig{})
+	for k := range 600 {
+		if data := g.GenerateNext(float64(k)); data.BPMChild != 140 || *data.BPMChild2 != 145 || data.Uterus != 20 {
+			t.Fatalf("distorted at %d s: %+v", k, data)
+		}
+	}
+	if annotations := g.DrainAnnotations(); len(annotations) != 0 {
+		t.Errorf("annotations without artifacts: %+v", annotations)
+	}
+}
+
+func TestArtifactReset(t *testing.T) {
+	base := twinsPoint()
+	g := testArtifacts(base, ArtifactConfig{SignalLossPerHour: alwaysPerHour, UterusLossPerHour: alwaysPerHour})
+	for k := range 10 {
+		g.GenerateNext(float64(k))
+	}
+	g.maternalBPM = 95
+
+	g.Reset()
+	if base.resets != 1 {
+		t.Errorf("base resets = %d, want 1", base.resets)
+	}
+	if g.fhr.active != nil || g.fhr2.active != nil || g.uterus.active != nil || g.lastTimestamp != 0 {
+		t.Error("artifacts survived reset")
+	}
+	if g.maternalBPM != fallbackMaternalBPM || len(g.DrainAnnotations()) != 0 {
+		t.Errorf("maternal BPM after reset = %v", g.maternalBPM)
+	}
+	// Новая сессия начинается с чистого сигнала
+	if data := g.GenerateNext(0); data.BPMChild != 140 || data.Uterus != 20 {
+		t.Errorf("first point after reset distorted: %+v", data)
+	}
+}
@@ -0,0 +1,67 @@
+package generator
+
+import (
+	"backend_gen/internal/adapter/dataset"
+	"backend_gen/internal/ports/generator"
+	"backend_gen/internal/ports/websocket"
+	"log/slog"
+	"math/rand/v2"
+)
+
+// augmentGenerator воспроизводит аугментированные варианты реальной записи:
+// при каждом запуске сессии (Reset) создаётся новый вариант того же класса
+type augmentGenerator struct {
+	source *dataset.Recording
+	opts   dataset.AugmentOptions
+	rng    *rand.Rand
+	replay *replayGenerator
+	twins  bool
+}
+
+// NewAugmentGenerator создает генератор вариантов записи rec. seed = 0 - случайные
+// варианты, иначе последовательность вариантов воспроизводима
+func NewAugmentGenerator(rec *dataset.Recording, opts dataset.AugmentOptions, seed uint64, twins bool) (generator.DataGenerator, error) {
+	replay, err := newReplayGenerator(rec, twins)
+	if err != nil {
+		return nil, err
+	}
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	slog.Info("Augmentation enabled",
+		"class", rec.Class,
+		"patient", rec.Patient,
+		"recording", rec.ID,
+		"seed", seed,
+		"contraction_shapes", len(opts.Shapes))
+
+	g := &augmentGenerator{
+		source: rec,
+		opts:   opts,
+		rng:    rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15)),
+		replay: replay,
+		twins:  twins,
+	}
+	g.Reset()
+	return g, nil
+}
+
+// GenerateNext возвращает значения текущего варианта в момент timestamp
+func (g *augmentGenerator) GenerateNext(timestamp float64) websocket.SensorData {
+	return g.replay.GenerateNext(timestamp)
+}
+
+// Reset создает следующий вариант записи. Вариант без отсчётов пропускается,
+// и воспроизводится предыдущий
+func (g *augmentGenerator) Reset() {
+	replay, err := newReplayGenerator(dataset.Augment(g.source, g.opts, g.rng), g.twins)
+	if err != nil {
+		slog.Warn("Augmented variant skipped", "error", err)
+		return
+	}
+	g.replay = replay
+	slog.Debug("Augmented variant created", "duration_sec", replay.period)
+}
+
+// SetParameters не применим к воспроизведению реальной записи
+func (g *augmentGenerator) SetParameters(params generator.GenerationParameters) {}
@@ -3,12 +3,98 @@ package generator
 import (
 	"backend_gen/internal/ports/generator"
 	"backend_gen/internal/ports/websocket"
+	"fmt"
 	"log"
 	"math"
 	"math/rand"
 	"time"
 )
 
+// Длительность физиологического перехода при смене состояния плода через
+// SetFetalState, сек: базальный ритм, вариабельность и тонус меняются плавно
+const transitionTime = 180.0
+
+// Вариабельность пульса при гипоксии вне модели событий: диапазон ±6 (из анализа CSV)
+const hypoxiaVariability = 6.0
+
+// Стадии прогрессирующей гипоксии (только в модели событий CTGOptions.Events):
+// смещение базального ритма и вариабельность
+var hypoxiaStages = []struct {
+	kind      string
+	start     float64 // секунды от начала гипоксии
+	end       float64 // 0 = до конца сессии
+	bpmOffset float64
+	variation float64
+}{
+	{kind: "tachycardia", start: 0, end: 300, bpmOffset: 15, variation: 6},
+	{kind: "decline", start: 300, end: 600, bpmOffset: -10, variation: 4},
+	{kind: "bradycardia", start: 600, bpmOffset: -25, variation: 2.5},
+}
+
+// CTGOptions дополнительные каналы CTG генератора
+type CTGOptions struct {
+	// MaternalHR включает канал пульса матери
+	MaternalHR bool
+	// Twins включает второй канал FHR (двойня) с общим каналом тонуса матки
+	Twins bool
+	// Events включает модель событий: схватки с децелерациями и стадии
+	// прогрессирующей гипоксии. Без неё пульс и тонус держатся в окнах,
+	// откалиброванных по датасету (140/148 BPM, тонус 14.5/17)
+	Events bool
+}
+
+// Типы децелераций (websocket.Annotation.Kind для типа deceleration)
+const (
+	decelerationNone      = "none"
+	decelerationEarly     = "early"
+	decelerationLate      = "late"
+	decelerationVariable  = "variable"
+	decelerationProlonged = "prolonged"
+)
+
+// behaviour переопределяет поведение CTG генератора (фаза сценария).
+// Нулевые значения означают поведение по умолчанию для текущего состояния плода
+type behaviour struct {
+	baselineBPM         float64 // базальный ритм, BPM (0 = норма срока и стадии гипоксии)
+	baselineShift       float64 // смещение базального ритма, BPM
+	variability         float64 // множитель вариабельности (0 = 1)
+	sinusoidalAmplitude float64 // амплитуда синусоидального ритма, BPM
+	sinusoidalPeriod    float64 // период синусоидального ритма, сек
+	contractionInterval float64 // средний интервал между схватками, сек
+	contractionDuration float64 // средняя длительность схватки, сек (0 = 60-120)
+	contractionPeak     float64 // давление на пике схватки, mmHg
+	uterineTone         float64 // базальный тонус матки, mmHg
+	decelerations       string  // тип децелераций на схватку ("" = по состоянию плода)
+	decelerationRate    float64 // вероятность децелерации на схватку (0 = по типу)
+	decelerationDepth   float64 // средняя глубина децелерации, BPM (0 = по типу)
+	noAccelerations     bool    // шевеления без акцелераций (ареактивный плод)
+	maternalBPM         float64 // базальный пульс матери, BPM (0 = 70-95)
+}
+
+// levels уровни сигнала, задаваемые состоянием плода, стадией гипоксии и поведением
+type levels struct {
+	baseline  float64 // базальный ритм, BPM
+	variation float64 // амплитуда вариабельности, ±BPM
+	limit     float64 // допустимое отклонение от базального ритма, BPM
+	toneLow   float64 // диапазон базального тонуса матки, mmHg
+	toneHigh  float64
+}
+
+// stateTransition плавный переход уровней после SetFetalState
+type stateTransition struct {
+	from       levels
+	start, end float64
+}
+
+// approach сдвигает уровни к target на долю k (0..1)
+func (l *levels) approach(target levels, k float64) {
+	l.baseline += (target.baseline - l.baseline) * k
+	l.variation += (target.variation - l.variation) * k
+	l.limit += (target.limit - l.limit) * k
+	l.toneLow += (target.toneLow - l.toneLow) * k
+	l.toneHigh += (target.toneHigh - l.toneHigh) * k
+}
+
 // ctgGenerator реализует генерацию CTG данных
 // Может быть здоровый плод (60%) или с гипоксией (40%)
 type ctgGenerator struct {
@@ -17,125 +103,614 @@ type ctgGenerator struct {
 	// Параметры состояния плода
 	hasHypoxia bool // true = гипоксия, false = здоровый
 
+	// Дополнительные каналы
+	opts CTGOptions
+
+	// Переопределения поведения (сохраняются при Reset, как и режим)
+	behaviour behaviour
+
+	// Время перехода в состояние гипоксии: от него отсчитываются стадии
+	hypoxiaOnset float64
+
+	// Текущие уровни сигнала. Совпадают с целевыми (фазы сценария и стадии
+	// меняют их сразу), кроме перехода после SetFetalState
+	levels     levels
+	transition *stateTransition
+
+	// Состояние, запрошенное через SetFetalState и ещё не применённое
+	pendingState string
+
+	// Профиль пациентки и рассчитанные по нему нормы
+	profile generator.Profile
+	norms   generator.ProfileNorms
+
 	// Текущие значения
 	currentBPM    float64
 	currentUterus float64
+	currentMHR    float64 // базальный пульс матери без реакции на схватку
+
+	// Второй плод: смещение базального ритма относительно первого и своя децелерация
+	twinOffset       float64
+	twinDeceleration *episode
+
+	// Время предыдущего вызова GenerateNext (для расчёта вероятностей событий)
+	lastTimestamp float64
+
+	// Активные события (nil, если события нет)
+	contraction  *episode
+	deceleration *episode
+	acceleration *episode
+
+	// Индекс следующей стадии гипоксии, о которой нужно сообщить
+	nextStage int
+
+	// Эталонная разметка, ещё не отданная через DrainAnnotations
+	annotations []websocket.Annotation
+	// Отметки шевелений, ещё не отданные через DrainMarks
+	marks []websocket.Mark
+}
+
+// episode событие с заранее известными началом, концом и амплитудой
+type episode struct {
+	kind      string
+	start     float64
+	end       float64
+	amplitude float64
+
+	// Прирост пульса матери на пике схватки
+	maternalRise float64
+}
+
+// shape возвращает форму события в момент t (0..1), 0 вне события
+func (e *episode) shape(t float64) float64 {
+	if t < e.start || t > e.end {
+		return 0
+	}
+	x := (t - e.start) / (e.end - e.start)
+	if e.kind == decelerationVariable {
+		// Вариабельная децелерация: резкое падение и более плавное восстановление
+		if x < 0.2 {
+			return x / 0.2
+		}
+		return (1 - x) / 0.8
+	}
+	return math.Sin(math.Pi * math.Pow(x, 0.6))
 }
 
 // NewCTGGenerator создает новый CTG генератор с указанным режимом
 // hypoxiaMode: 0 = здоровый плод, 1 = гипоксия
-func NewCTGGenerator(hypoxiaMode int) generator.DataGenerator {
+func NewCTGGenerator(hypoxiaMode int, opts CTGOptions) generator.DataGenerator {
+	return newCTGGenerator(hypoxiaMode, opts)
+}
+
+// newCTGGenerator создает CTG генератор (для обёрток пакета, управляющих поведением)
+func newCTGGenerator(hypoxiaMode int, opts CTGOptions) *ctgGenerator {
 	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
 
 	// Определяем состояние плода на основе переменной окружения
 	hasHypoxia := hypoxiaMode == 1
 
+	norms := normsForProfile(DefaultProfile)
+
 	var initialBPM, initialUterus float64
 	if hasHypoxia {
 		// Параметры гипоксии (из анализа CSV: BPM ~148, Uterus ~17)
-		initialBPM = 148.0
+		initialBPM = norms.BaselineBPM + hypoxiaBaselineShift
 		initialUterus = 17.0
 		log.Println("🔴 CTG Generator: HYPOXIA mode (BPM: ~148, Uterus: ~17) [HYPOXIA_MODE=1]")
 	} else {
 		// Параметры здорового плода (из анализа CSV: BPM ~140, Uterus ~14.8)
-		initialBPM = 140.0
+		initialBPM = norms.BaselineBPM
 		initialUterus = 14.5
 		log.Println("🟢 CTG Generator: HEALTHY mode (BPM: ~140, Uterus: ~14.5) [HYPOXIA_MODE=0]")
 	}
 
-	return &ctgGenerator{
+	g := &ctgGenerator{
 		rng:           rng,
 		hasHypoxia:    hasHypoxia,
+		opts:          opts,
+		profile:       DefaultProfile,
+		norms:         norms,
 		currentBPM:    initialBPM,
 		currentUterus: initialUterus,
+		currentMHR:    norms.MaternalBPM,
+		twinOffset:    randomTwinOffset(rng),
+	}
+	g.levels = g.targetLevels()
+	return g
+}
+
+// randomTwinOffset выбирает различие базальных ритмов двойни: 3-8 BPM в любую сторону
+func randomTwinOffset(rng *rand.Rand) float64 {
+	offset := 3 + rng.Float64()*5
+	if rng.Float64() < 0.5 {
+		return -offset
 	}
+	return offset
 }
 
 // GenerateNext генерирует следующую точку данных
 func (g *ctgGenerator) GenerateNext(timestamp float64) websocket.SensorData {
-	g.update()
+	dt := timestamp - g.lastTimestamp
+	g.lastTimestamp = timestamp
+
+	g.applyPendingState(timestamp)
+	g.updateStage(timestamp)
+	if g.opts.Events {
+		g.updateEvents(timestamp, dt)
+	}
+	g.updateMovements(timestamp, dt)
+	g.update(timestamp, dt)
+
+	uterus := g.currentUterus
+	if g.contraction != nil {
+		uterus += g.contraction.amplitude * g.contraction.shape(timestamp)
+	}
 
-	// Генерируем Spasms для здорового плода (без схваток)
-	// Небольшие естественные колебания в покое
-	spasms := 20.0 + g.rng.Float64()*2 - 1.0 // 19-21, небольшие колебания
+	bpm := g.currentBPM
+	if g.deceleration != nil {
+		bpm -= g.deceleration.amplitude * g.deceleration.shape(timestamp)
+	}
+	if g.acceleration != nil {
+		bpm += g.acceleration.amplitude * g.acceleration.shape(timestamp)
+	}
+
+	// Spasms в покое: небольшие естественные колебания около 20,
+	// при схватке растут пропорционально её интенсивности
+	spasms := 20.0 + g.rng.Float64()*2 - 1.0
+	if uterus > 28 {
+		spasms += (uterus - 28) * 1.5
+	}
 
-	return websocket.SensorData{
-		BPMChild: g.currentBPM,
-		Uterus:   g.currentUterus,
+	data := websocket.SensorData{
+		BPMChild: bpm,
+		Uterus:   uterus,
 		Spasms:   math.Max(0, spasms),
 	}
+	if g.opts.MaternalHR {
+		mhr := g.maternalBPM(timestamp)
+		data.BPMMother = &mhr
+	}
+	if g.opts.Twins {
+		bpm2 := g.twinBPM(timestamp)
+		data.BPMChild2 = &bpm2
+	}
+	return data
 }
 
-// update обновляет состояние генератора
-func (g *ctgGenerator) update() {
+// twinBPM генерирует пульс второго плода: повторяет вариабельность первого
+// (общая реакция на состояние матери) со своим базальным ритмом, собственным
+// шумом и собственными децелерациями
+func (g *ctgGenerator) twinBPM(t float64) float64 {
+	bpm2 := g.currentBPM + g.twinOffset + g.rng.Float64()*3 - 1.5
+	if g.twinDeceleration != nil {
+		bpm2 -= g.twinDeceleration.amplitude * g.twinDeceleration.shape(t)
+	}
+	return bpm2
+}
+
+// maternalBPM генерирует пульс матери: базальный ритм от -10 до +15 BPM к норме
+// профиля (70-95 в 30 лет) с медленным дрейфом, учащающийся на 10-20 BPM на пике
+// схватки (боль и потуги)
+func (g *ctgGenerator) maternalBPM(t float64) float64 {
+	low, high := g.norms.MaternalBPM-10, g.norms.MaternalBPM+15
+	if g.behaviour.maternalBPM > 0 {
+		low, high = g.behaviour.maternalBPM-5, g.behaviour.maternalBPM+5
+	}
+	// К новому диапазону (лихорадка) пульс матери смещается постепенно
+	g.currentMHR += g.rng.Float64()*0.4 - 0.2
+	if g.currentMHR < low {
+		g.currentMHR = math.Min(g.currentMHR+0.2, low)
+	}
+	if g.currentMHR > high {
+		g.currentMHR = math.Max(g.currentMHR-0.2, high)
+	}
+
+	mhr := g.currentMHR + g.rng.Float64()*2 - 1
+	if g.contraction != nil {
+		mhr += g.contraction.maternalRise * g.contraction.shape(t)
+	}
+	return mhr
+}
+
+// DrainMarks отдаёт отметки шевелений плода, появившиеся с прошлого вызова
+func (g *ctgGenerator) DrainMarks() []websocket.Mark {
+	marks := g.marks
+	g.marks = nil
+	return marks
+}
+
+// updateMovements генерирует шевеления плода. Большинство шевелений здорового
+// плода сопровождается акцелерацией (реактивность); при гипоксии шевеления
+// реже и акцелерации на них почти не возникают
+func (g *ctgGenerator) updateMovements(t, dt float64) {
+	if g.acceleration != nil && t > g.acceleration.end {
+		g.acceleration = nil
+	}
+
+	// В среднем шевеление раз в 3 минуты у здорового плода и раз в 10 минут при гипоксии
+	meanInterval, accelerationChance := 180.0, 0.8
 	if g.hasHypoxia {
-		// === ГИПОКСИЯ: Повышенный тонус матки и нестабильный пульс ===
-		g.currentUterus += g.rng.Float64()*0.8 - 0.4 // Больше колебаний
+		meanInterval, accelerationChance = 600.0, 0.1
+	}
+	if g.behaviour.noAccelerations {
+		accelerationChance = 0
+	}
+	if g.rng.Float64() >= dt/meanInterval {
+		return
+	}
+
+	g.marks = append(g.marks, websocket.Mark{
+		Type:         websocket.MarkFetalMovement,
+		Source:       websocket.MarkSourceAuto,
+		SecFromStart: t,
+	})
+
+	// Во время децелерации акцелерация не накладывается
+	if g.acceleration != nil || g.deceleration != nil || g.rng.Float64() >= accelerationChance {
+		return
+	}
+	// Акцелерация удовлетворяет критерию срока: 15x15 для доношенного, 10x10 до 32 недель
+	g.acceleration = &episode{
+		start:     t,
+		end:       t + g.norms.AccelerationDuration + g.rng.Float64()*25,
+		amplitude: g.norms.AccelerationAmplitude + g.rng.Float64()*10,
+	}
+	criterion := fmt.Sprintf("%.0fx%.0f", g.norms.AccelerationAmplitude, g.norms.AccelerationDuration)
+	g.annotate(websocket.AnnotationAcceleration, criterion, "", g.acceleration)
+}
+
+// DrainAnnotations отдаёт эталонную разметку событий, начавшихся с прошлого вызова
+func (g *ctgGenerator) DrainAnnotations() []websocket.Annotation {
+	annotations := g.annotations
+	g.annotations = nil
+	return annotations
+}
 
-		// Диапазон для гипоксии (из анализа: среднее 17.09)
-		if g.currentUterus < 16.0 {
-			g.currentUterus = 16.0
+// updateStage сообщает о начале гипоксии, а в модели событий - о начале
+// очередной её стадии
+func (g *ctgGenerator) updateStage(t float64) {
+	if !g.hasHypoxia {
+		return
+	}
+	if !g.opts.Events {
+		// Гипоксия без стадий длится до конца сессии или до смены состояния
+		if g.nextStage == 0 {
+			g.annotations = append(g.annotations, websocket.Annotation{
+				Type:  websocket.AnnotationHypoxia,
+				Start: g.hypoxiaOnset,
+			})
+			g.nextStage = 1
+		}
+		return
+	}
+	for g.nextStage < len(hypoxiaStages) && t >= g.hypoxiaOnset+hypoxiaStages[g.nextStage].start {
+		stage := hypoxiaStages[g.nextStage]
+		annotation := websocket.Annotation{
+			Type:  websocket.AnnotationHypoxia,
+			Kind:  stage.kind,
+			Start: g.hypoxiaOnset + stage.start,
 		}
-		if g.currentUterus > 18.5 {
-			g.currentUterus = 18.5
+		if stage.end > 0 {
+			end := g.hypoxiaOnset + stage.end
+			annotation.End = &end
 		}
+		g.annotations = append(g.annotations, annotation)
+		g.nextStage++
+	}
+}
 
-		// Пульс при гипоксии: выше и с большей вариабельностью
-		baseBPM := 148.0                   // Из анализа: среднее 148.25
-		varDelta := g.rng.Float64()*12 - 6 // Диапазон ±6 (больше вариабельность)
+// currentStage возвращает текущую стадию гипоксии
+func (g *ctgGenerator) currentStage() int {
+	if g.nextStage == 0 {
+		return 0
+	}
+	return g.nextStage - 1
+}
+
+// updateEvents запускает и завершает схватки и связанные с ними децелерации
+func (g *ctgGenerator) updateEvents(t, dt float64) {
+	if g.contraction != nil && t > g.contraction.end {
+		g.contraction = nil
+	}
+	if g.deceleration != nil && t > g.deceleration.end {
+		g.deceleration = nil
+	}
+	if g.twinDeceleration != nil && t > g.twinDeceleration.end {
+		g.twinDeceleration = nil
+	}
+	if g.contraction != nil {
+		return
+	}
 
-		g.currentBPM = baseBPM + varDelta
+	// При гипоксии схватки чаще: в среднем раз в 2.5 минуты против 4 минут
+	meanInterval := 240.0
+	if g.hasHypoxia {
+		meanInterval = 150.0
+	}
+	meanInterval *= g.norms.ContractionInterval
+	if g.behaviour.contractionInterval > 0 {
+		meanInterval = g.behaviour.contractionInterval
+	}
+	if g.rng.Float64() >= dt/meanInterval {
+		return
+	}
 
-		// Пределы для гипоксии
-		if g.currentBPM < 140 {
-			g.currentBPM = 140
+	duration := 60 + g.rng.Float64()*60 // 60-120 сек
+	if g.behaviour.contractionDuration > 0 {
+		duration = g.behaviour.contractionDuration * (0.75 + g.rng.Float64()*0.5)
+	}
+	peak := 30 + g.rng.Float64()*3 // 30-33 mmHg
+	if g.hasHypoxia {
+		peak = 33 + g.rng.Float64()*3 // 33-36 mmHg
+	}
+	if g.behaviour.contractionPeak > 0 {
+		peak = g.behaviour.contractionPeak + g.rng.Float64()*3 - 1.5
+	}
+	g.contraction = &episode{
+		start:        t,
+		end:          t + duration,
+		amplitude:    peak - g.currentUterus,
+		maternalRise: 10 + g.rng.Float64()*10,
+	}
+	g.annotate(websocket.AnnotationContraction, "", "", g.contraction)
+
+	if g.opts.Twins && g.twinDeceleration == nil {
+		// Второй плод реагирует на схватку независимо: тип тот же, время и глубина свои
+		g.twinDeceleration = g.newDeceleration(t, duration)
+		if g.twinDeceleration != nil {
+			g.annotate(websocket.AnnotationDeceleration, g.twinDeceleration.kind, "bpmChild2", g.twinDeceleration)
+		}
+	}
+	if g.deceleration != nil {
+		return
+	}
+	g.deceleration = g.newDeceleration(t, duration)
+	if g.deceleration != nil {
+		g.annotate(websocket.AnnotationDeceleration, g.deceleration.kind, "", g.deceleration)
+	}
+}
+
+// newDeceleration решает, ответит ли плод децелерацией на схватку, начавшуюся
+// в момент t, и возвращает её (nil, если не ответит). Без переопределений
+// здоровый плод даёт ранние децелерации, плод с гипоксией - поздние
+func (g *ctgGenerator) newDeceleration(t, duration float64) *episode {
+	kind := g.behaviour.decelerations
+	if kind == "" {
+		kind = decelerationEarly
+		if g.hasHypoxia {
+			kind = decelerationLate
 		}
-		if g.currentBPM > 156 {
-			g.currentBPM = 156
+	}
+
+	var rate float64
+	e := &episode{kind: kind}
+	switch kind {
+	case decelerationEarly:
+		// Ранняя: зеркально повторяет схватку (5-15 BPM)
+		rate = 0.5
+		e.start, e.end = t, t+duration
+		e.amplitude = 5 + g.rng.Float64()*10
+	case decelerationLate:
+		// Поздняя: запаздывает относительно схватки, глубже (10-40 BPM)
+		rate = 0.8
+		lag := 20 + g.rng.Float64()*10
+		e.start, e.end = t+lag, t+lag+duration
+		e.amplitude = 10 + g.rng.Float64()*30
+	case decelerationVariable:
+		// Вариабельная (сдавление пуповины): короткая, глубокая, в любой момент схватки
+		rate = 0.6
+		e.start = t + g.rng.Float64()*duration/2
+		e.end = e.start + 15 + g.rng.Float64()*45
+		e.amplitude = 20 + g.rng.Float64()*40
+	case decelerationProlonged:
+		// Пролонгированная: 2-5 минут на 30-50 BPM
+		rate = 0.3
+		e.start, e.end = t, t+120+g.rng.Float64()*180
+		e.amplitude = 30 + g.rng.Float64()*20
+	default:
+		return nil
+	}
+
+	if g.behaviour.decelerationRate > 0 {
+		rate = g.behaviour.decelerationRate
+	}
+	if g.rng.Float64() >= rate {
+		return nil
+	}
+	if g.behaviour.decelerationDepth > 0 {
+		e.amplitude = g.behaviour.decelerationDepth * (0.7 + g.rng.Float64()*0.6)
+	}
+	return e
+}
+
+// annotate добавляет событие канала channel ("" - основной канал) в эталонную разметку
+func (g *ctgGenerator) annotate(eventType, kind, channel string, e *episode) {
+	end := e.end
+	g.annotations = append(g.annotations, websocket.Annotation{
+		Type:    eventType,
+		Kind:    kind,
+		Start:   e.start,
+		End:     &end,
+		Channel: channel,
+	})
+}
+
+// update обновляет состояние генератора
+func (g *ctgGenerator) update(t, dt float64) {
+	g.levels = g.targetLevels()
+	if g.transition != nil {
+		if t >= g.transition.end {
+			g.transition = nil
+		} else {
+			// Полуволна косинуса: переход без скачка в начале и ровно к цели в конце
+			x := (t - g.transition.start) / (g.transition.end - g.transition.start)
+			target := g.levels
+			g.levels = g.transition.from
+			g.levels.approach(target, (1-math.Cos(math.Pi*x))/2)
 		}
+	}
 
+	if g.hasHypoxia {
+		// === ГИПОКСИЯ: Повышенный тонус матки и нестабильный пульс ===
+		g.currentUterus += g.rng.Float64()*0.8 - 0.4 // Больше колебаний
 	} else {
 		// === ЗДОРОВЫЙ ПЛОД: Низкий тонус и стабильный пульс ===
 		g.currentUterus += g.rng.Float64()*0.6 - 0.3 // Небольшой дрейф
+	}
+	g.currentUterus = math.Min(math.Max(g.currentUterus, g.levels.toneLow), g.levels.toneHigh)
 
-		// Диапазон для здорового плода (из анализа: среднее 14.80)
-		if g.currentUterus < 13.5 {
-			g.currentUterus = 13.5
-		}
-		if g.currentUterus > 15.5 {
-			g.currentUterus = 15.5
-		}
+	baseBPM, variation, limit := g.levels.baseline, g.levels.variation, g.levels.limit
+	varDelta := g.rng.Float64()*2*variation - variation
+	g.currentBPM = math.Min(math.Max(baseBPM+varDelta, baseBPM-limit), baseBPM+limit)
 
-		// Пульс здорового плода
-		baseBPM := 140.0                    // Из анализа: среднее 139.85
-		varDelta := g.rng.Float64()*9 - 4.5 // Диапазон ±4.5 (норма)
+	// Синусоидальный ритм: ровная волна поверх сниженной вариабельности
+	if g.behaviour.sinusoidalAmplitude > 0 && g.behaviour.sinusoidalPeriod > 0 {
+		g.currentBPM += g.behaviour.sinusoidalAmplitude * math.Sin(2*math.Pi*t/g.behaviour.sinusoidalPeriod)
+	}
+}
 
-		g.currentBPM = baseBPM + varDelta
+// targetLevels рассчитывает уровни сигнала для текущего состояния, стадии и поведения
+func (g *ctgGenerator) targetLevels() levels {
+	var l levels
+	if g.hasHypoxia {
+		// Диапазон тонуса для гипоксии (из анализа: среднее 17.09)
+		l.toneLow, l.toneHigh = 16.0, 18.5
 
-		// Пределы для здорового
-		if g.currentBPM < 135 {
-			g.currentBPM = 135
-		}
-		if g.currentBPM > 145 {
-			g.currentBPM = 145
+		// Пульс при гипоксии: выше и с большей вариабельностью
+		// (вариабельность масштабируется нормой срока беременности)
+		l.baseline = g.norms.BaselineBPM + hypoxiaBaselineShift // Из анализа: среднее 148.25
+		l.variation = hypoxiaVariability * g.norms.Variability / termVariability
+		l.limit = 8 // Пределы для гипоксии
+		if g.opts.Events {
+			// В модели событий ритм смещается по стадиям, вариабельность снижается
+			stage := hypoxiaStages[g.currentStage()]
+			l.baseline += stage.bpmOffset
+			l.variation = stage.variation * g.norms.Variability / termVariability
 		}
+	} else {
+		// Диапазон тонуса для здорового плода (из анализа: среднее 14.80)
+		l.toneLow, l.toneHigh = 13.5, 15.5
+
+		// Пульс здорового плода (норма для срока беременности)
+		l.baseline = g.norms.BaselineBPM
+		l.variation = g.norms.Variability
+		l.limit = 5 // Пределы для здорового
+	}
+
+	if g.behaviour.uterineTone > 0 {
+		l.toneLow, l.toneHigh = g.behaviour.uterineTone-1, g.behaviour.uterineTone+1
+	}
+	if g.behaviour.baselineBPM > 0 {
+		l.baseline = g.behaviour.baselineBPM
+	}
+	l.baseline += g.behaviour.baselineShift
+	if g.behaviour.variability > 0 {
+		l.variation *= g.behaviour.variability
+		l.limit = math.Max(l.limit, l.variation)
 	}
+	return l
 }
 
-// Reset сбрасывает генератор в начальное состояние (сохраняет текущий режим)
+// Reset сбрасывает генератор в начальное состояние. Режим сохраняется, если
+// через SetFetalState не запрошен другой
 func (g *ctgGenerator) Reset() {
 	g.rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+	g.lastTimestamp = 0
+	g.contraction = nil
+	g.deceleration = nil
+	g.acceleration = nil
+	g.nextStage = 0
+	g.hypoxiaOnset = 0
+	g.annotations = nil
+	g.marks = nil
+	g.currentMHR = g.norms.MaternalBPM
+	g.twinOffset = randomTwinOffset(g.rng)
+	g.twinDeceleration = nil
+	g.transition = nil
+	// Запрошенное состояние применяется сразу: новая сессия начинается в нём
+	if g.pendingState != "" {
+		g.hasHypoxia = g.pendingState == generator.FetalStateHypoxia
+		g.pendingState = ""
+	}
 
-	// НЕ меняем режим! Сохраняем g.hasHypoxia как есть
+	// Начальные уровни режима: прежнего или только что применённого
 	if g.hasHypoxia {
-		g.currentBPM = 148.0
+		g.currentBPM = g.norms.BaselineBPM + hypoxiaBaselineShift
 		g.currentUterus = 17.0
 		log.Println("🔄 CTG Generator RESET: HYPOXIA mode")
 	} else {
-		g.currentBPM = 140.0
+		g.currentBPM = g.norms.BaselineBPM
 		g.currentUterus = 14.5
 		log.Println("🔄 CTG Generator RESET: HEALTHY mode")
 	}
+	g.levels = g.targetLevels()
+}
+
+// setState переключает состояние плода; стадии гипоксии отсчитываются от момента t
+func (g *ctgGenerator) setState(hypoxia bool, t float64) {
+	if g.hasHypoxia == hypoxia {
+		return
+	}
+	g.hasHypoxia = hypoxia
+	g.hypoxiaOnset = t
+	g.nextStage = 0
+}
+
+// SetFetalState запрашивает смену состояния плода во время сессии. Состояние
+// меняется со следующей точки, уровни сигнала переходят к новым плавно за
+// transitionTime, а сама смена попадает в эталонную разметку. Запрос, не
+// применённый до Reset, применяется при нём: новая сессия начинается в этом состоянии
+func (g *ctgGenerator) SetFetalState(state string) error {
+	if state != generator.FetalStateHealthy && state != generator.FetalStateHypoxia {
+		return fmt.Errorf("unknown fetal state %q", state)
+	}
+	g.pendingState = state
+	return nil
+}
+
+// FetalState возвращает текущее (или уже запрошенное) состояние плода
+func (g *ctgGenerator) FetalState() string {
+	if g.pendingState != "" {
+		return g.pendingState
+	}
+	if g.hasHypoxia {
+		return generator.FetalStateHypoxia
+	}
+	return generator.FetalStateHealthy
+}
+
+// applyPendingState применяет запрошенное состояние в момент t
+func (g *ctgGenerator) applyPendingState(t float64) {
+	if g.pendingState == "" {
+		return
+	}
+	hypoxia := g.pendingState == generator.FetalStateHypoxia
+	g.pendingState = ""
+	if hypoxia == g.hasHypoxia {
+		return
+	}
+
+	g.transition = &stateTransition{from: g.levels, start: t, end: t + transitionTime}
+	g.setState(hypoxia, t)
+	end := t + transitionTime
+	g.annotations = append(g.annotations, websocket.Annotation{
+		Type:  websocket.AnnotationStateChange,
+		Kind:  g.FetalState(),
+		Start: t,
+		End:   &end,
+	})
+	log.Printf("🔀 CTG Generator state switched to %s at %.1f sec", g.FetalState(), t)
+}
+
+// SetProfile пересчитывает нормы под профиль пациентки (сохраняется при Reset)
+func (g *ctgGenerator) SetProfile(profile generator.Profile) (generator.ProfileNorms, bool) {
+	g.profile = profile
+	g.norms = normsForProfile(profile)
+	log.Printf("🤰 CTG Generator profile: %.1f weeks, baseline %.0f BPM, variability ±%.1f, acceleration %.0fx%.0f, maternal %.0f BPM",
+		profile.GestationalWeeks, g.norms.BaselineBPM, g.norms.Variability,
+		g.norms.AccelerationAmplitude, g.norms.AccelerationDuration, g.norms.MaternalBPM)
+	return g.norms, true
 }
 
 // SetParameters устанавливает параметры генерации (для совместимости с интерфейсом)
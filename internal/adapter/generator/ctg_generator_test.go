@@ -0,0 +1,246 @@
+package generator
+
+import (
+	"backend_gen/internal/ports/generator"
+	"backend_gen/internal/ports/websocket"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// testCTG CTG генератор с фиксированным зерном
+func testCTG(hypoxiaMode int, opts CTGOptions) *ctgGenerator {
+	g := newCTGGenerator(hypoxiaMode, opts)
+	seed(g, 1)
+	return g
+}
+
+// seed задаёт зерно генератора; смещение ритма второго плода выбирается заново,
+// так как при создании и Reset оно берётся из случайного зерна
+func seed(g *ctgGenerator, value int64) {
+	g.rng = rand.New(rand.NewSource(value))
+	g.twinOffset = randomTwinOffset(g.rng)
+}
+
+// run генерирует точки в целые секунды from..to-1
+func run(g generator.DataGenerator, from, to int) []websocket.SensorData {
+	data := make([]websocket.SensorData, 0, to-from)
+	for k := from; k < to; k++ {
+		data = append(data, g.GenerateNext(float64(k)))
+	}
+	return data
+}
+
+// ofType разметка заданного типа
+func ofType(annotations []websocket.Annotation, annotationType string) []websocket.Annotation {
+	var result []websocket.Annotation
+	for _, a := range annotations {
+		if a.Type == annotationType {
+			result = append(result, a)
+		}
+	}
+	return result
+}
+
+func TestCTGEventAnnotations(t *testing.T) {
+	g := testCTG(0, CTGOptions{Events: true, Twins: true})
+	g.behaviour = behaviour{contractionInterval: 60, decelerations: decelerationEarly, decelerationRate: 1}
+	data := run(g, 0, 1200)
+	annotations := g.DrainAnnotations()
+
+	contractions := ofType(annotations, websocket.AnnotationContraction)
+	if len(contractions) < 5 {
+		t.Fatalf("contractions = %d, want at least 5", len(contractions))
+	}
+	starts := make(map[float64]bool)
+	for _, c := range contractions {
+		starts[c.Start] = true
+		if d := *c.End - c.Start; d < 60 || d > 120 {
+			t.Errorf("contraction at %v lasts %v s, want 60-120", c.Start, d)
+		}
+		// На середине схватки тонус заметно выше базального
+		if mid := int((c.Start + *c.End) / 2); mid < len(data) && data[mid].Uterus < 24 {
+			t.Errorf("uterus at the middle of contraction %v = %v", c.Start, data[mid].Uterus)
+		}
+	}
+
+	// Каждая схватка даёт раннюю децелерацию у обоих плодов, совпадающую со схваткой
+	channels := make(map[string]int)
+	for _, d := range ofType(annotations, websocket.AnnotationDeceleration) {
+		channels[d.Channel]++
+		if d.Kind != decelerationEarly || !starts[d.Start] {
+			t.Errorf("deceleration %+v does not mirror a contraction", d)
+		}
+	}
+	if channels[""] == 0 || channels["bpmChild2"] == 0 || len(channels) != 2 {
+		t.Errorf("decelerations by channel = %v, want both fetuses", channels)
+	}
+	if len(g.DrainAnnotations()) != 0 {
+		t.Error("annotations returned twice")
+	}
+}
+
+func TestCTGHypoxiaWithoutEvents(t *testing.T) {
+	g := testCTG(1, CTGOptions{})
+	data := run(g, 0, 600)
+
+	// Без модели событий гипоксия отмечается один раз, без стадий и конца
+	annotations := g.DrainAnnotations()
+	hypoxia := ofType(annotations, websocket.AnnotationHypoxia)
+	if len(hypoxia) != 1 || hypoxia[0].Start != 0 || hypoxia[0].Kind != "" || hypoxia[0].End != nil {
+		t.Errorf("hypoxia annotations = %+v, want one open annotation at 0", hypoxia)
+	}
+	if n := len(ofType(annotations, websocket.AnnotationContraction)); n != 0 {
+		t.Errorf("contractions without events = %d", n)
+	}
+	for k, d := range data {
+		if d.Uterus < 16 || d.Uterus > 18.5 {
+			t.Fatalf("uterus at %d s = %v, want 16-18.5", k, d.Uterus)
+		}
+	}
+}
+
+func TestCTGHypoxiaStages(t *testing.T) {
+	g := testCTG(1, CTGOptions{Events: true})
+	run(g, 0, 700)
+
+	var got []string
+	for _, a := range ofType(g.DrainAnnotations(), websocket.AnnotationHypoxia) {
+		got = append(got, a.Kind)
+		stage := hypoxiaStages[len(got)-1]
+		if a.Start != stage.start || (a.End == nil) != (stage.end == 0) {
+			t.Errorf("stage %s at %v, want at %v", a.Kind, a.Start, stage.start)
+		}
+	}
+	if want := []string{"tachycardia", "decline", "bradycardia"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("stages = %v, want %v", got, want)
+	}
+	// Брадикардия: 140 + 8 - 25
+	if g.levels.baseline != 123 {
+		t.Errorf("baseline in bradycardia = %v, want 123", g.levels.baseline)
+	}
+}
+
+func TestCTGFetalStateTransition(t *testing.T) {
+	g := testCTG(0, CTGOptions{})
+	run(g, 0, 100)
+	g.DrainAnnotations()
+
+	if err := g.SetFetalState("unknown"); err == nil {
+		t.Error("unknown fetal state accepted")
+	}
+	if err := g.SetFetalState(generator.FetalStateHypoxia); err != nil {
+		t.Fatal(err)
+	}
+	// Запрошенное состояние видно сразу, применяется со следующей точки
+	if g.FetalState() != generator.FetalStateHypoxia || g.hasHypoxia {
+		t.Errorf("fetal state = %s, hypoxia applied = %v", g.FetalState(), g.hasHypoxia)
+	}
+
+	g.GenerateNext(100)
+	annotations := g.DrainAnnotations()
+	if len(annotations) < 2 {
+		t.Fatalf("annotations = %+v, want state change and hypoxia", annotations)
+	}
+	if a := annotations[0]; a.Type != websocket.AnnotationStateChange || a.Kind != generator.FetalStateHypoxia ||
+		a.Start != 100 || *a.End != 100+transitionTime {
+		t.Errorf("state change = %+v", a)
+	}
+	if a := annotations[1]; a.Type != websocket.AnnotationHypoxia || a.Start != 100 {
+		t.Errorf("hypoxia onset = %+v, want at 100 s", a)
+	}
+
+	// Базальный ритм переходит от 140 к 148 плавно: на середине перехода - половина пути
+	run(g, 101, 191)
+	if math.Abs(g.levels.baseline-144) > 1e-9 {
+		t.Errorf("baseline in the middle of the transition = %v, want 144", g.levels.baseline)
+	}
+	data := run(g, 191, 400)
+	if g.transition != nil || g.levels.baseline != 148 {
+		t.Errorf("baseline after the transition = %v, want 148", g.levels.baseline)
+	}
+	for _, d := range data[100:] {
+		if d.BPMChild < 140 || d.BPMChild > 156 {
+			t.Fatalf("FHR after the transition = %v, want 148±8", d.BPMChild)
+		}
+	}
+
+	// Повторный запрос того же состояния не меняет ничего
+	if err := g.SetFetalState(generator.FetalStateHypoxia); err != nil {
+		t.Fatal(err)
+	}
+	g.GenerateNext(400)
+	if n := len(ofType(g.DrainAnnotations(), websocket.AnnotationStateChange)); n != 0 {
+		t.Errorf("state changes for the same state = %d", n)
+	}
+}
+
+func TestCTGResetAppliesPendingState(t *testing.T) {
+	g := testCTG(0, CTGOptions{})
+	run(g, 0, 100)
+	if err := g.SetFetalState(generator.FetalStateHypoxia); err != nil {
+		t.Fatal(err)
+	}
+
+	// Запрос, не применённый до Reset, задаёт состояние новой сессии без перехода
+	g.Reset()
+	seed(g, 1)
+	if !g.hasHypoxia || g.pendingState != "" || g.currentBPM != 148 || g.currentUterus != 17 {
+		t.Errorf("after reset: hypoxia %v, pending %q, FHR %v, uterus %v",
+			g.hasHypoxia, g.pendingState, g.currentBPM, g.currentUterus)
+	}
+	g.GenerateNext(0)
+	annotations := g.DrainAnnotations()
+	if len(annotations) != 1 || annotations[0].Type != websocket.AnnotationHypoxia || annotations[0].Start != 0 {
+		t.Errorf("annotations after reset = %+v, want hypoxia from 0 s", annotations)
+	}
+	if g.transition != nil {
+		t.Error("reset started a transition")
+	}
+
+	// Без запроса Reset сохраняет режим
+	g.Reset()
+	if !g.hasHypoxia || g.FetalState() != generator.FetalStateHypoxia {
+		t.Errorf("reset changed fetal state to %s", g.FetalState())
+	}
+}
+
+func TestCTGProfile(t *testing.T) {
+	g := testCTG(0, CTGOptions{MaternalHR: true})
+	norms, ok := g.SetProfile(generator.Profile{GestationalWeeks: 28, MaternalAge: 20})
+	if !ok || norms.BaselineBPM != 152 {
+		t.Fatalf("norms = %+v, ok = %v", norms, ok)
+	}
+	data := run(g, 0, 1800)
+
+	// Акцелерации по критерию срока до 32 недель
+	accelerations := ofType(g.DrainAnnotations(), websocket.AnnotationAcceleration)
+	if len(accelerations) == 0 {
+		t.Fatal("no accelerations in 30 minutes")
+	}
+	for _, a := range accelerations {
+		if a.Kind != "10x10" || *a.End-a.Start < 10 {
+			t.Errorf("acceleration %+v, want 10x10", a)
+		}
+	}
+	for k, d := range data {
+		if d.BPMMother == nil || *d.BPMMother < 72 || *d.BPMMother > 99 {
+			t.Fatalf("maternal HR at %d s = %v, want 73-98", k, d.BPMMother)
+		}
+	}
+	if len(g.DrainMarks()) < len(accelerations) {
+		t.Error("accelerations without fetal movement marks")
+	}
+}
+
+func TestCTGDeterministic(t *testing.T) {
+	opts := CTGOptions{MaternalHR: true, Twins: true, Events: true}
+	a, b := testCTG(1, opts), testCTG(1, opts)
+	if !reflect.DeepEqual(run(a, 0, 900), run(b, 0, 900)) {
+		t.Error("same seed produced different data")
+	}
+	if !reflect.DeepEqual(a.DrainAnnotations(), b.DrainAnnotations()) {
+		t.Error("same seed produced different annotations")
+	}
+}
@@ -0,0 +1,56 @@
+package generator
+
+import (
+	"backend_gen/internal/ports/generator"
+	"math"
+)
+
+// Нормы доношенного плода (40 недель), на которые откалиброван CTG генератор
+const (
+	termWeeks       = 40.0
+	termBaselineBPM = 140.0 // Из анализа: среднее 139.85
+	termVariability = 4.5   // Диапазон ±4.5 (норма)
+
+	// Смещение базального ритма при гипоксии относительно здорового плода (148 против 140)
+	hypoxiaBaselineShift = 8.0
+
+	// Пульс матери в покое в 30 лет; с возрастом снижается примерно на 0.3 BPM в год
+	maternalBPMAt30 = 80.0
+)
+
+// DefaultProfile профиль по умолчанию: доношенная беременность
+var DefaultProfile = generator.Profile{GestationalWeeks: termWeeks, Parity: 1}
+
+// normsForProfile рассчитывает нормы CTG для срока беременности:
+//   - базальный ритм снижается с созреванием парасимпатической регуляции,
+//     примерно на 1 BPM в неделю: ~160 в 20 недель, ~140 к 40 неделям;
+//   - вариабельность растёт с 24 до 34 недель и дальше не меняется;
+//   - до 32 недель акцелерацией считается подъём 10 BPM на 10 сек, после - 15 на 15;
+//   - пульс матери в покое снижается с возрастом (без возраста - как в 30 лет)
+func normsForProfile(profile generator.Profile) generator.ProfileNorms {
+	weeks := profile.GestationalWeeks
+	if weeks <= 0 {
+		weeks = termWeeks
+	}
+
+	norms := generator.ProfileNorms{
+		BaselineBPM:           math.Min(math.Max(termBaselineBPM+(termWeeks-weeks), 130), 160),
+		Variability:           2.5 + (termVariability-2.5)*math.Min(math.Max((weeks-24)/10, 0), 1),
+		AccelerationAmplitude: 15,
+		AccelerationDuration:  15,
+		ContractionInterval:   1,
+		MaternalBPM:           maternalBPMAt30,
+	}
+	if profile.MaternalAge > 0 {
+		norms.MaternalBPM = math.Min(math.Max(maternalBPMAt30+0.3*float64(30-profile.MaternalAge), 74), 86)
+	}
+	if weeks < 32 {
+		norms.AccelerationAmplitude = 10
+		norms.AccelerationDuration = 10
+	}
+	// У первородящих схватки в среднем реже
+	if profile.Parity == 0 {
+		norms.ContractionInterval = 1.2
+	}
+	return norms
+}
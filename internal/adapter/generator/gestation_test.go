@@ -0,0 +1,61 @@
+package generator
+
+import (
+	"backend_gen/internal/ports/generator"
+	"math"
+	"testing"
+)
+
+func TestNormsForProfile(t *testing.T) {
+	term := generator.ProfileNorms{
+		BaselineBPM:           140,
+		Variability:           4.5,
+		AccelerationAmplitude: 15,
+		AccelerationDuration:  15,
+		ContractionInterval:   1,
+		MaternalBPM:           80,
+	}
+	cases := []struct {
+		name    string
+		profile generator.Profile
+		want    generator.ProfileNorms
+	}{
+		{"default", DefaultProfile, term},
+		// Срок не указан - нормы доношенного плода
+		{"unknown term", generator.Profile{Parity: 1}, term},
+		{"post-term", generator.Profile{GestationalWeeks: 42, Parity: 1}, generator.ProfileNorms{
+			BaselineBPM: 138, Variability: 4.5, AccelerationAmplitude: 15, AccelerationDuration: 15,
+			ContractionInterval: 1, MaternalBPM: 80,
+		}},
+		// До 32 недель критерий акцелерации 10x10, первородящая, молодая мать
+		{"preterm", generator.Profile{GestationalWeeks: 28, MaternalAge: 20, Parity: 0}, generator.ProfileNorms{
+			BaselineBPM: 152, Variability: 3.3, AccelerationAmplitude: 10, AccelerationDuration: 10,
+			ContractionInterval: 1.2, MaternalBPM: 83,
+		}},
+		// Пределы: базальный ритм не выше 160, пульс матери не ниже 74
+		{"limits", generator.Profile{GestationalWeeks: 18, MaternalAge: 60, Parity: 2}, generator.ProfileNorms{
+			BaselineBPM: 160, Variability: 2.5, AccelerationAmplitude: 10, AccelerationDuration: 10,
+			ContractionInterval: 1, MaternalBPM: 74,
+		}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := normsForProfile(tc.profile)
+			for _, v := range []struct {
+				name      string
+				got, want float64
+			}{
+				{"baseline", got.BaselineBPM, tc.want.BaselineBPM},
+				{"variability", got.Variability, tc.want.Variability},
+				{"acceleration amplitude", got.AccelerationAmplitude, tc.want.AccelerationAmplitude},
+				{"acceleration duration", got.AccelerationDuration, tc.want.AccelerationDuration},
+				{"contraction interval", got.ContractionInterval, tc.want.ContractionInterval},
+				{"maternal BPM", got.MaternalBPM, tc.want.MaternalBPM},
+			} {
+				if math.Abs(v.got-v.want) > 1e-9 {
+					t.Errorf("%s = %v, want %v", v.name, v.got, v.want)
+				}
+			}
+		})
+	}
+}
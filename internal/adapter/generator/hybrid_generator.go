@@ -0,0 +1,369 @@
+package generator
+
+import (
+	"backend_gen/internal/adapter/dataset"
+	"backend_gen/internal/ports/generator"
+	"backend_gen/internal/ports/websocket"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+)
+
+// Попыток выбрать сегмент, прежде чем заполнить время синтетикой
+const hybridAttempts = 20
+
+// HybridOptions параметры гибридного генератора
+type HybridOptions struct {
+	DatasetDir string
+	// Class класс записей в начале сессии; меняется через SetFetalState
+	Class string
+	// SegmentSec длительность реального сегмента, TransitionSec - синтетического перехода
+	SegmentSec    float64
+	TransitionSec float64
+	// MaxMissing сегменты с большей долей потери сигнала FHR не используются
+	MaxMissing float64
+	// Seed начальное значение выбора сегментов (0 - случайное)
+	Seed uint64
+	// LossAsNaN true = потеря сигнала передаётся как NaN, иначе нулями
+	LossAsNaN bool
+}
+
+// recordingRef запись датасета
+type recordingRef struct {
+	class, patient, id string
+}
+
+func (r recordingRef) String() string {
+	return r.class + "/" + r.patient + "/" + r.id
+}
+
+// hybridRecording запись датасета, загруженная при создании генератора:
+// посекундные отсчёты с time_sec = 1, потеря сигнала - NaN
+type hybridRecording struct {
+	ref         recordingRef
+	fhr, uterus []float64
+}
+
+// hybridGenerator склеивает случайные сегменты реальных записей класса
+// синтетическими переходами. Сегменты берутся со случайным смещением, поэтому
+// поток не повторяется и не ограничен по длительности. Записи загружаются
+// при создании: GenerateNext не обращается к диску
+type hybridGenerator struct {
+	opts  HybridOptions
+	pool  map[string][]hybridRecording // класс -> записи
+	rng   *rand.Rand
+	class string
+	last  recordingRef
+
+	// Посекундные отсчёты от момента offset; потеря сигнала - NaN
+	offset float64
+	fhr    []float64
+	uterus []float64
+
+	annotations []websocket.Annotation
+}
+
+// NewHybridGenerator создает гибридный генератор по записям каталога датасета
+func NewHybridGenerator(opts HybridOptions) (generator.DataGenerator, error) {
+	if opts.SegmentSec <= 0 || opts.TransitionSec <= 0 {
+		return nil, fmt.Errorf("segment and transition durations must be positive")
+	}
+	pool, err := loadHybridPool(opts.DatasetDir, int(opts.SegmentSec))
+	if err != nil {
+		return nil, err
+	}
+	if len(pool[opts.Class]) == 0 {
+		return nil, fmt.Errorf("no %s recordings found under %s", opts.Class, opts.DatasetDir)
+	}
+
+	seed := opts.Seed
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	slog.Info("Hybrid generator created",
+		"class", opts.Class,
+		"recordings", len(pool[opts.Class]),
+		"segment_sec", opts.SegmentSec,
+		"transition_sec", opts.TransitionSec,
+		"seed", seed)
+
+	return newHybridGenerator(opts, pool, seed), nil
+}
+
+func newHybridGenerator(opts HybridOptions, pool map[string][]hybridRecording, seed uint64) *hybridGenerator {
+	return &hybridGenerator{
+		opts:  opts,
+		pool:  pool,
+		rng:   rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15)),
+		class: opts.Class,
+	}
+}
+
+// loadHybridPool загружает записи каталога не короче minSec секунд
+func loadHybridPool(dir string, minSec int) (map[string][]hybridRecording, error) {
+	files, err := dataset.ScanFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	pool := make(map[string][]hybridRecording)
+	for _, file := range files {
+		if file.Channel != dataset.ChannelFHR {
+			continue
+		}
+		rec, err := dataset.LoadRecording(dir, file.Class, file.Patient, file.ID)
+		if err != nil {
+			slog.Warn("Recording skipped", "class", file.Class, "patient", file.Patient, "id", file.ID, "error", err)
+			continue
+		}
+		n := int(rec.Duration())
+		if n < minSec {
+			continue
+		}
+		r := hybridRecording{
+			ref:    recordingRef{file.Class, file.Patient, file.ID},
+			fhr:    make([]float64, n),
+			uterus: make([]float64, n),
+		}
+		for k := range n {
+			t := float64(k + 1)
+			r.fhr[k] = sampleAt(rec.FHR, t, true)
+			r.uterus[k] = sampleAt(rec.Uterus, t, false)
+		}
+		pool[file.Class] = append(pool[file.Class], r)
+	}
+	return pool, nil
+}
+
+// GenerateNext возвращает значения в момент timestamp, при необходимости
+// добавляя следующий переход и сегмент. Потеря сигнала передаётся нулём
+// или NaN по LossAsNaN
+func (g *hybridGenerator) GenerateNext(timestamp float64) websocket.SensorData {
+	i := int(math.Max(timestamp-g.offset, 0))
+	for i+1 >= len(g.fhr) {
+		g.extend()
+	}
+	// Прошедшие отсчёты больше не нужны
+	if i > 600 {
+		g.fhr, g.uterus = g.fhr[i:], g.uterus[i:]
+		g.offset += float64(i)
+		i = 0
+	}
+
+	frac := timestamp - g.offset - float64(i)
+	bpm := interpolate(g.fhr[i], g.fhr[i+1], frac)
+	uterus := interpolate(g.uterus[i], g.uterus[i+1], frac)
+	data := websocket.SensorData{
+		BPMChild: bpm,
+		Uterus:   uterus,
+		Spasms:   spasmsFromUterus(uterus),
+	}
+	if !g.opts.LossAsNaN {
+		if math.IsNaN(data.BPMChild) {
+			data.BPMChild = 0
+		}
+		if math.IsNaN(data.Uterus) {
+			data.Uterus = 0
+		}
+	}
+	return data
+}
+
+// interpolate линейно интерполирует между соседними секундами; пропуск даёт NaN
+func interpolate(a, b, frac float64) float64 {
+	if math.IsNaN(a) || math.IsNaN(b) {
+		return math.NaN()
+	}
+	return a + (b-a)*math.Max(frac, 0)
+}
+
+// extend добавляет переход (кроме начала сессии) и следующий сегмент
+func (g *hybridGenerator) extend() {
+	now := g.offset + float64(len(g.fhr))
+	fhr, uterus, ref, ok := g.pickSegment()
+	if !ok {
+		slog.Warn("No suitable real segment found, filling with synthetic signal", "class", g.class)
+		fhr, uterus = g.bridge(nil, nil, g.opts.SegmentSec)
+		g.append(fhr, uterus, "synthetic", now)
+		return
+	}
+
+	if len(g.fhr) > 0 {
+		bridgeFHR, bridgeUterus := g.bridge(fhr, uterus, g.opts.TransitionSec)
+		g.append(bridgeFHR, bridgeUterus, "transition", now)
+		now += float64(len(bridgeFHR))
+	}
+	g.append(fhr, uterus, ref, now)
+}
+
+// append добавляет отсчёты и размечает их как сегмент kind
+func (g *hybridGenerator) append(fhr, uterus []float64, kind string, start float64) {
+	g.fhr = append(g.fhr, fhr...)
+	g.uterus = append(g.uterus, uterus...)
+	end := start + float64(len(fhr))
+	g.annotations = append(g.annotations, websocket.Annotation{
+		Type:  websocket.AnnotationSegment,
+		Kind:  kind,
+		Start: start,
+		End:   &end,
+	})
+}
+
+// pickSegment выбирает случайный сегмент записи текущего класса, отличной от
+// предыдущей. kind описывает источник: класс/пациент/запись@секунда
+func (g *hybridGenerator) pickSegment() (fhr, uterus []float64, kind string, ok bool) {
+	recs := g.pool[g.class]
+	n := int(g.opts.SegmentSec)
+	for range hybridAttempts {
+		rec := recs[g.rng.IntN(len(recs))]
+		if rec.ref == g.last && len(recs) > 1 {
+			continue
+		}
+
+		start := g.rng.IntN(len(rec.fhr) - n + 1)
+		fhr, uterus = rec.fhr[start:start+n], rec.uterus[start:start+n]
+		missing := 0
+		for _, v := range fhr {
+			if math.IsNaN(v) {
+				missing++
+			}
+		}
+		if float64(missing)/float64(n) > g.opts.MaxMissing {
+			continue
+		}
+		g.last = rec.ref
+		return fhr, uterus, fmt.Sprintf("%s@%d", rec.ref, start), true
+	}
+	return nil, nil, "", false
+}
+
+// sampleAt возвращает значение ряда в момент t; пропуск - NaN. Для FHR
+// (zeroIsLoss) ноль тоже означает потерю сигнала, тонус 0 допустим
+func sampleAt(s dataset.Series, t float64, zeroIsLoss bool) float64 {
+	v, ok := s.At(t)
+	if !ok || (zeroIsLoss && v == 0) {
+		return math.NaN()
+	}
+	return v
+}
+
+// bridge строит синтетический переход длительностью duration от конца
+// накопленного сигнала к началу следующего сегмента (nil - к тем же уровням)
+func (g *hybridGenerator) bridge(nextFHR, nextUterus []float64, duration float64) ([]float64, []float64) {
+	n := int(duration)
+	// Без предыдущего сигнала (синтетика в начале сессии) - типичные уровни
+	prevFHR, prevUterus := []float64{140}, []float64{15}
+	if len(g.fhr) > 0 {
+		prevFHR, prevUterus = g.fhr, g.uterus
+	}
+	return g.bridgeChannel(prevFHR, nextFHR, n, 1, 10), g.bridgeChannel(prevUterus, nextUterus, n, 0.3, 3)
+}
+
+// bridgeChannel соединяет каналы плавной сменой уровня (полуволна косинуса) и
+// шумом AR(1) с амплитудой вариабельности соседних участков в пределах
+// [minSigma, maxSigma]. Переход непрерывно продолжает prev и подводит к next
+func (g *hybridGenerator) bridgeChannel(prev, next []float64, n int, minSigma, maxSigma float64) []float64 {
+	const window = 30 // секунд для оценки уровня и вариабельности
+	fromLevel, fromSigma, fromLast := edgeStats(prev[max(len(prev)-window, 0):], true)
+	toLevel, toSigma, toFirst := fromLevel, fromSigma, fromLevel
+	if next != nil {
+		toLevel, toSigma, toFirst = edgeStats(next[:min(window, len(next))], false)
+	}
+	sigma := math.Min(math.Max((fromSigma+toSigma)/2, minSigma), maxSigma)
+
+	// Шум начинается с отклонения последнего отсчёта от уровня
+	const phi = 0.9
+	noise := make([]float64, n+2)
+	noise[0] = fromLast - fromLevel
+	for k := 1; k < len(noise); k++ {
+		noise[k] = phi*noise[k-1] + g.rng.NormFloat64()*sigma*math.Sqrt(1-phi*phi)
+	}
+	// Линейная поправка сводит шум на конце к отклонению первого отсчёта next
+	correction := (toFirst - toLevel) - noise[n+1]
+
+	values := make([]float64, n)
+	for k := range values {
+		w := float64(k+1) / float64(n+1)
+		level := fromLevel + (toLevel-fromLevel)*(1-math.Cos(math.Pi*w))/2
+		values[k] = level + noise[k+1] + correction*w
+	}
+	return values
+}
+
+// edgeStats возвращает уровень, разброс и крайний достоверный отсчёт участка:
+// последний при last = true, иначе первый
+func edgeStats(values []float64, last bool) (level, sigma, edge float64) {
+	var valid []float64
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			valid = append(valid, v)
+		}
+	}
+	if len(valid) == 0 {
+		return 140, 0, 140
+	}
+	for _, v := range valid {
+		level += v
+	}
+	level /= float64(len(valid))
+	for _, v := range valid {
+		sigma += (v - level) * (v - level)
+	}
+	sigma = math.Sqrt(sigma / float64(len(valid)))
+	edge = valid[0]
+	if last {
+		edge = valid[len(valid)-1]
+	}
+	return level, sigma, edge
+}
+
+// DrainAnnotations возвращает разметку сегментов и переходов
+func (g *hybridGenerator) DrainAnnotations() []websocket.Annotation {
+	annotations := g.annotations
+	g.annotations = nil
+	return annotations
+}
+
+// Reset начинает новую сессию с нового случайного сегмента. Класс сохраняется
+func (g *hybridGenerator) Reset() {
+	g.offset = 0
+	g.fhr, g.uterus = nil, nil
+	g.annotations = nil
+}
+
+// SetFetalState меняет класс записей: следующий сегмент после текущего
+// берётся из нового класса через синтетический переход
+func (g *hybridGenerator) SetFetalState(state string) error {
+	class := dataset.ClassRegular
+	switch state {
+	case generator.FetalStateHealthy:
+	case generator.FetalStateHypoxia:
+		class = dataset.ClassHypoxia
+	default:
+		return fmt.Errorf("unknown fetal state %q", state)
+	}
+	if len(g.pool[class]) == 0 {
+		return fmt.Errorf("no %s recordings in dataset", class)
+	}
+	if class != g.class {
+		end := g.offset + float64(len(g.fhr))
+		g.annotations = append(g.annotations, websocket.Annotation{
+			Type:  websocket.AnnotationStateChange,
+			Kind:  state,
+			Start: end,
+		})
+	}
+	g.class = class
+	return nil
+}
+
+// FetalState возвращает состояние, соответствующее классу записей
+func (g *hybridGenerator) FetalState() string {
+	if g.class == dataset.ClassHypoxia {
+		return generator.FetalStateHypoxia
+	}
+	return generator.FetalStateHealthy
+}
+
+// SetParameters не применим к записям датасета
+func (g *hybridGenerator) SetParameters(params generator.GenerationParameters) {}
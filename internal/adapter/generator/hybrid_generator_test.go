@@ -0,0 +1,230 @@
+package generator
+
+import (
+	"backend_gen/internal/adapter/dataset"
+	"backend_gen/internal/ports/generator"
+	"backend_gen/internal/ports/websocket"
+	"math"
+	"strings"
+	"testing"
+)
+
+// constRecording запись с постоянными уровнями FHR и тонуса
+func constRecording(class, id string, n int, fhr, uterus float64) hybridRecording {
+	r := hybridRecording{
+		ref:    recordingRef{class, "1", id},
+		fhr:    make([]float64, n),
+		uterus: make([]float64, n),
+	}
+	for k := range n {
+		r.fhr[k], r.uterus[k] = fhr, uterus
+	}
+	return r
+}
+
+func testHybrid(pool map[string][]hybridRecording, opts HybridOptions) *hybridGenerator {
+	if opts.Class == "" {
+		opts.Class = dataset.ClassRegular
+	}
+	opts.SegmentSec, opts.TransitionSec = 20, 10
+	if opts.MaxMissing == 0 {
+		opts.MaxMissing = 0.2
+	}
+	return newHybridGenerator(opts, pool, 42)
+}
+
+// generate возвращает FHR в целые секунды 0..n-1
+func generate(g generator.DataGenerator, n int) []float64 {
+	values := make([]float64, n)
+	for k := range values {
+		values[k] = g.GenerateNext(float64(k)).BPMChild
+	}
+	return values
+}
+
+func segments(annotations []websocket.Annotation) []websocket.Annotation {
+	var result []websocket.Annotation
+	for _, a := range annotations {
+		if a.Type == websocket.AnnotationSegment {
+			result = append(result, a)
+		}
+	}
+	return result
+}
+
+func TestHybridSegmentSelection(t *testing.T) {
+	pool := map[string][]hybridRecording{dataset.ClassRegular: {
+		constRecording(dataset.ClassRegular, "a", 100, 120, 10),
+		constRecording(dataset.ClassRegular, "b", 100, 160, 10),
+	}}
+	g := testHybrid(pool, HybridOptions{})
+	values := generate(g, 200)
+
+	got := segments(g.DrainAnnotations())
+	if len(got) < 5 {
+		t.Fatalf("segments = %d, want at least 5", len(got))
+	}
+	var previous string
+	for i, a := range got {
+		// Сегменты и переходы идут встык: реальный, переход, реальный...
+		if i > 0 && a.Start != *got[i-1].End {
+			t.Errorf("segment %d starts at %v, previous ends at %v", i, a.Start, *got[i-1].End)
+		}
+		if i%2 == 1 {
+			if a.Kind != "transition" || *a.End-a.Start != 10 {
+				t.Errorf("segment %d = %s %v..%v, want a 10 s transition", i, a.Kind, a.Start, *a.End)
+			}
+			continue
+		}
+		if *a.End-a.Start != 20 || !strings.HasPrefix(a.Kind, "regular/1/") {
+			t.Errorf("segment %d = %s %v..%v, want a 20 s real segment", i, a.Kind, a.Start, *a.End)
+		}
+		// Запись не повторяется подряд, если есть другая
+		recording := strings.Split(a.Kind, "@")[0]
+		if recording == previous {
+			t.Errorf("segment %d repeats recording %s", i, recording)
+		}
+		previous = recording
+
+		want := 120.0
+		if recording == "regular/1/b" {
+			want = 160
+		}
+		for s := int(a.Start); s < int(*a.End) && s < len(values); s++ {
+			if values[s] != want {
+				t.Fatalf("segment %s at %d s = %v, want %v", a.Kind, s, values[s], want)
+			}
+		}
+	}
+}
+
+func TestHybridBridgeContinuity(t *testing.T) {
+	pool := map[string][]hybridRecording{dataset.ClassRegular: {
+		constRecording(dataset.ClassRegular, "a", 100, 120, 10),
+		constRecording(dataset.ClassRegular, "b", 100, 160, 40),
+	}}
+	g := testHybrid(pool, HybridOptions{})
+
+	// Переход между уровнями 120 и 160 плавный: без скачков на границах
+	values := generate(g, 300)
+	for k := 1; k < len(values); k++ {
+		if d := math.Abs(values[k] - values[k-1]); d > 10 {
+			t.Errorf("jump %v bpm at %d s: %v -> %v", d, k, values[k-1], values[k])
+		}
+	}
+}
+
+func TestHybridDeterministic(t *testing.T) {
+	pool := map[string][]hybridRecording{dataset.ClassRegular: {
+		constRecording(dataset.ClassRegular, "a", 100, 120, 10),
+		constRecording(dataset.ClassRegular, "b", 100, 160, 40),
+	}}
+	a := generate(testHybrid(pool, HybridOptions{}), 150)
+	b := generate(testHybrid(pool, HybridOptions{}), 150)
+	for k := range a {
+		if a[k] != b[k] {
+			t.Fatalf("same seed differs at %d s: %v != %v", k, a[k], b[k])
+		}
+	}
+}
+
+func TestHybridClassSwitch(t *testing.T) {
+	pool := map[string][]hybridRecording{
+		dataset.ClassRegular: {constRecording(dataset.ClassRegular, "a", 100, 140, 10)},
+		dataset.ClassHypoxia: {constRecording(dataset.ClassHypoxia, "h", 100, 90, 10)},
+	}
+	g := testHybrid(pool, HybridOptions{})
+	generate(g, 10)
+	g.DrainAnnotations()
+
+	if err := g.SetFetalState("unknown"); err == nil {
+		t.Error("unknown fetal state accepted")
+	}
+	if err := g.SetFetalState(generator.FetalStateHypoxia); err != nil {
+		t.Fatal(err)
+	}
+	if g.FetalState() != generator.FetalStateHypoxia {
+		t.Errorf("fetal state = %s", g.FetalState())
+	}
+	for k := 10; k < 100; k++ {
+		g.GenerateNext(float64(k))
+	}
+
+	// Смена отмечена на конце текущего сегмента, дальше идут записи нового класса
+	annotations := g.DrainAnnotations()
+	if a := annotations[0]; a.Type != websocket.AnnotationStateChange || a.Kind != generator.FetalStateHypoxia || a.Start != 20 {
+		t.Errorf("first annotation = %+v, want state change at 20 s", a)
+	}
+	for _, a := range segments(annotations) {
+		if a.Kind != "transition" && !strings.HasPrefix(a.Kind, "hypoxia/") {
+			t.Errorf("segment %s after the switch to hypoxia", a.Kind)
+		}
+	}
+	if v := g.GenerateNext(99).BPMChild; v != 90 {
+		t.Errorf("FHR after the switch = %v, want 90", v)
+	}
+
+	delete(pool, dataset.ClassRegular)
+	if err := g.SetFetalState(generator.FetalStateHealthy); err == nil {
+		t.Error("switch to a class without recordings accepted")
+	}
+}
+
+func TestHybridSyntheticFallback(t *testing.T) {
+	// Все сегменты с потерей FHR сверх MaxMissing: время заполняется синтетикой
+	lost := constRecording(dataset.ClassRegular, "lost", 100, math.NaN(), 10)
+	g := testHybrid(map[string][]hybridRecording{dataset.ClassRegular: {lost}}, HybridOptions{})
+	values := generate(g, 30)
+
+	got := segments(g.DrainAnnotations())
+	if len(got) == 0 || got[0].Kind != "synthetic" {
+		t.Fatalf("segments = %+v, want synthetic", got)
+	}
+	for k, v := range values {
+		if math.IsNaN(v) || v < 100 || v > 180 {
+			t.Fatalf("synthetic FHR at %d s = %v", k, v)
+		}
+	}
+}
+
+func TestHybridSignalLoss(t *testing.T) {
+	rec := constRecording(dataset.ClassRegular, "a", 100, 130, 0)
+	for k := 0; k < 100; k += 2 {
+		rec.fhr[k] = math.NaN()
+	}
+	pool := map[string][]hybridRecording{dataset.ClassRegular: {rec}}
+
+	for _, lossAsNaN := range []bool{false, true} {
+		g := testHybrid(pool, HybridOptions{MaxMissing: 1, LossAsNaN: lossAsNaN})
+		var lost int
+		for k := range 20 {
+			data := g.GenerateNext(float64(k))
+			// Тонус 0 - допустимое значение, а не потеря
+			if data.Uterus != 0 {
+				t.Errorf("uterus at %d s = %v, want 0", k, data.Uterus)
+			}
+			switch {
+			case lossAsNaN && math.IsNaN(data.BPMChild), !lossAsNaN && data.BPMChild == 0:
+				lost++
+			case data.BPMChild != 130:
+				t.Errorf("loss as NaN %v: FHR at %d s = %v", lossAsNaN, k, data.BPMChild)
+			}
+		}
+		if lost == 0 {
+			t.Errorf("loss as NaN %v: no signal loss reported", lossAsNaN)
+		}
+	}
+}
+
+func TestSampleAt(t *testing.T) {
+	s := dataset.Series{Time: []float64{1, 2, 3}, Value: []float64{0, 12, 0}}
+	if v := sampleAt(s, 1, false); v != 0 {
+		t.Errorf("uterus zero = %v, want 0", v)
+	}
+	if v := sampleAt(s, 1, true); !math.IsNaN(v) {
+		t.Errorf("FHR zero = %v, want NaN", v)
+	}
+	if v := sampleAt(s, 10, false); !math.IsNaN(v) {
+		t.Errorf("outside the series = %v, want NaN", v)
+	}
+}
@@ -0,0 +1,196 @@
+package generator
+
+import (
+	"sort"
+	"time"
+)
+
+// ptr возвращает указатель на значение (для параметров фаз пресетов)
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// presets встроенный каталог клинических картин. Пресеты доступны по имени
+// так же, как сценарии из файлов; файл с тем же именем переопределяет пресет
+var presets = map[string]*Scenario{
+	"normal-reactive": {
+		Description: "Normal reactive trace of a healthy term fetus",
+		Expected: []string{
+			"baseline 110-160 bpm (140 at term)",
+			"moderate variability 5-25 bpm",
+			"accelerations with fetal movements (15x15 at term, 10x10 before 32 weeks)",
+			"occasional early decelerations mirroring contractions",
+		},
+		Loop: true,
+		Phases: []Phase{
+			{Name: "normal", Duration: 20 * time.Minute, State: stateHealthy},
+		},
+	},
+	"sinusoidal": {
+		Description: "Sinusoidal pattern (severe fetal anaemia, e.g. Rh isoimmunisation or feto-maternal haemorrhage)",
+		Expected: []string{
+			"smooth regular sine wave of 5-15 bpm amplitude",
+			"3-5 cycles per minute (period about 15 s)",
+			"absent short-term variability",
+			"no accelerations",
+			"pattern persists for more than 30 minutes",
+		},
+		Loop: true,
+		Phases: []Phase{
+			{
+				Name:                "sinusoidal",
+				Duration:            30 * time.Minute,
+				State:               stateHealthy,
+				BaselineBPM:         ptr(135.0),
+				Variability:         ptr(0.15),
+				SinusoidalAmplitude: ptr(8.0),
+				SinusoidalPeriod:    ptr(15 * time.Second),
+				Accelerations:       ptr(false),
+				Decelerations:       ptr(decelerationNone),
+			},
+		},
+	},
+	"saltatory": {
+		Description: "Saltatory pattern: episodes of increased variability (acute hypoxia, e.g. during second stage)",
+		Expected: []string{
+			"variability amplitude above 25 bpm",
+			"episodes lasting more than 30 minutes are pathological",
+			"normal baseline between episodes",
+		},
+		Loop: true,
+		Phases: []Phase{
+			{Name: "normal", Duration: 10 * time.Minute, State: stateHealthy},
+			{Name: "saltatory", Duration: 30 * time.Minute, Variability: ptr(4.0)},
+		},
+	},
+	"maternal-fever-tachycardia": {
+		Description: "Fetal tachycardia secondary to maternal fever (chorioamnionitis)",
+		Expected: []string{
+			"fetal baseline gradually rising above 160 bpm",
+			"maternal tachycardia above 100 bpm (bpmMother channel)",
+			"slightly reduced variability",
+			"accelerations may persist",
+		},
+		Phases: []Phase{
+			{Name: "normal", Duration: 10 * time.Minute, State: stateHealthy},
+			{
+				Name:          "fever onset",
+				Duration:      15 * time.Minute,
+				BaselineShift: ptr(15.0),
+				MaternalBPM:   ptr(105.0),
+			},
+			{
+				Name:          "fetal tachycardia",
+				Duration:      30 * time.Minute,
+				BaselineShift: ptr(32.0),
+				Variability:   ptr(0.8),
+				MaternalBPM:   ptr(118.0),
+			},
+		},
+	},
+	"uterine-hyperstimulation": {
+		Description: "Uterine hyperstimulation (tachysystole, e.g. oxytocin overdose) leading to fetal compromise",
+		Expected: []string{
+			"more than 5 contractions in 10 minutes, averaged over a 30-minute window",
+			"raised uterine tone between contractions",
+			"late decelerations appearing after prolonged tachysystole",
+			"reduced variability in the compromise phase",
+		},
+		Phases: []Phase{
+			{
+				Name:                "tachysystole",
+				Duration:            15 * time.Minute,
+				State:               stateHealthy,
+				ContractionInterval: ptr(30 * time.Second),
+				ContractionDuration: ptr(50 * time.Second),
+				UterineTone:         ptr(20.0),
+				ContractionPeak:     ptr(45.0),
+			},
+			{
+				Name:             "fetal compromise",
+				Duration:         20 * time.Minute,
+				State:            stateHypoxia,
+				Decelerations:    ptr(decelerationLate),
+				DecelerationRate: ptr(0.9),
+				Accelerations:    ptr(false),
+			},
+		},
+	},
+	"cord-compression": {
+		Description: "Umbilical cord compression (oligohydramnios, cord entanglement)",
+		Expected: []string{
+			"variable decelerations: abrupt drop and quick recovery, 15-60 s",
+			"deceleration depth 20-60 bpm, timing unrelated to contraction peak",
+			"normal baseline and variability between decelerations",
+			"accelerations preserved",
+		},
+		Loop: true,
+		Phases: []Phase{
+			{
+				Name:                "cord compression",
+				Duration:            20 * time.Minute,
+				State:               stateHealthy,
+				Decelerations:       ptr(decelerationVariable),
+				DecelerationRate:    ptr(0.85),
+				ContractionInterval: ptr(90 * time.Second),
+			},
+		},
+	},
+	"placental-abruption": {
+		Description: "Placental abruption: uterine hypertonus with progressive fetal hypoxia ending in bradycardia",
+		Expected: []string{
+			"raised uterine tone with frequent low-amplitude contractions",
+			"recurrent late decelerations, then prolonged decelerations",
+			"progressive loss of variability",
+			"terminal bradycardia below 100 bpm",
+		},
+		Phases: []Phase{
+			{Name: "normal", Duration: 5 * time.Minute, State: stateHealthy},
+			{
+				Name:                "abruption",
+				Duration:            10 * time.Minute,
+				State:               stateHypoxia,
+				UterineTone:         ptr(25.0),
+				ContractionInterval: ptr(30 * time.Second),
+				ContractionPeak:     ptr(38.0),
+				Decelerations:       ptr(decelerationLate),
+				DecelerationRate:    ptr(1.0),
+				Variability:         ptr(0.6),
+				Accelerations:       ptr(false),
+			},
+			{
+				Name:          "decompensation",
+				Duration:      10 * time.Minute,
+				Decelerations: ptr(decelerationProlonged),
+				Variability:   ptr(0.3),
+			},
+			{
+				Name:          "terminal bradycardia",
+				Duration:      10 * time.Minute,
+				BaselineBPM:   ptr(85.0),
+				Decelerations: ptr(decelerationNone),
+				Variability:   ptr(0.2),
+			},
+		},
+	},
+}
+
+func init() {
+	for name, preset := range presets {
+		preset.Name = name
+		preset.Preset = true
+		if _, err := preset.compile(); err != nil {
+			panic("invalid preset " + name + ": " + err.Error())
+		}
+	}
+}
+
+// presetNames возвращает имена пресетов по алфавиту
+func presetNames() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
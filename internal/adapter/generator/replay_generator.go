@@ -0,0 +1,93 @@
+package generator
+
+import (
+	"backend_gen/internal/adapter/dataset"
+	"backend_gen/internal/ports/generator"
+	"backend_gen/internal/ports/websocket"
+	"fmt"
+	"log/slog"
+	"math"
+)
+
+// replayGenerator воспроизводит реальную запись из датасета в реальном времени.
+// По окончании записи воспроизведение начинается сначала
+type replayGenerator struct {
+	rec   *dataset.Recording
+	twins bool
+
+	// Время первого и последнего отсчёта записи и период повтора: последний
+	// отсчёт длится секунду, поэтому период на секунду длиннее их разницы
+	start, end, period float64
+}
+
+// NewReplayGenerator создает генератор воспроизведения записи rec.
+// twins включает второй канал FHR, если запись его содержит
+func NewReplayGenerator(rec *dataset.Recording, twins bool) (generator.DataGenerator, error) {
+	g, err := newReplayGenerator(rec, twins)
+	if err != nil {
+		return nil, err
+	}
+	if twins && rec.FHR2 == nil {
+		slog.Warn("Twin mode requested, but recording has no second FHR channel",
+			"class", rec.Class, "patient", rec.Patient, "recording", rec.ID)
+	}
+	slog.Info("Replay generator created",
+		"class", rec.Class,
+		"patient", rec.Patient,
+		"recording", rec.ID,
+		"duration_sec", g.period,
+		"twins", twins && rec.FHR2 != nil,
+		"maternal_hr", rec.MHR != nil)
+
+	return g, nil
+}
+
+// newReplayGenerator создает генератор без журнала; запись без отсчётов не принимается
+func newReplayGenerator(rec *dataset.Recording, twins bool) (*replayGenerator, error) {
+	start, end, ok := rec.Span()
+	if !ok {
+		return nil, fmt.Errorf("recording %s has no samples", rec.ID)
+	}
+	return &replayGenerator{rec: rec, twins: twins, start: start, end: end, period: end - start + 1}, nil
+}
+
+// GenerateNext возвращает значения записи в момент timestamp.
+// Потеря сигнала (пропуск в записи) передаётся нулём, как в исходных CSV
+func (g *replayGenerator) GenerateNext(timestamp float64) websocket.SensorData {
+	// Отсчёты в CSV начинаются с time_sec = 1; последний держится до повтора
+	t := math.Min(g.start+math.Mod(timestamp, g.period), g.end)
+
+	bpm, _ := g.rec.FHR.At(t)
+	uterus, _ := g.rec.Uterus.At(t)
+
+	data := websocket.SensorData{
+		BPMChild: bpm,
+		Uterus:   uterus,
+		Spasms:   spasmsFromUterus(uterus),
+	}
+	if g.twins && g.rec.FHR2 != nil {
+		bpm2, _ := g.rec.FHR2.At(t)
+		data.BPMChild2 = &bpm2
+	}
+	// Пульс матери есть только в импортированных записях
+	if g.rec.MHR != nil {
+		mhr, _ := g.rec.MHR.At(t)
+		data.BPMMother = &mhr
+	}
+	return data
+}
+
+// spasmsFromUterus оценивает интенсивность схватки по тонусу реальной записи
+func spasmsFromUterus(uterus float64) float64 {
+	spasms := 20.0
+	if uterus > 28 {
+		spasms += (uterus - 28) * 1.5
+	}
+	return spasms
+}
+
+// Reset ничего не делает: позиция воспроизведения определяется временем
+func (g *replayGenerator) Reset() {}
+
+// SetParameters не применим к воспроизведению реальной записи
+func (g *replayGenerator) SetParameters(params generator.GenerationParameters) {}
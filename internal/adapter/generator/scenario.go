@@ -0,0 +1,392 @@
+package generator
+
+import (
+	"backend_gen/internal/ports/generator"
+	"backend_gen/internal/ports/websocket"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Состояния плода в фазе сценария
+const (
+	stateHealthy = generator.FetalStateHealthy
+	stateHypoxia = generator.FetalStateHypoxia
+)
+
+// Scenario сценарий генерации: последовательность фаз с заданным поведением.
+//
+//	description: 10 минут нормы, затем поздние децелерации и брадикардия
+//	phases:
+//	  - name: normal
+//	    duration: 10m
+//	  - name: late decelerations
+//	    duration: 10m
+//	    state: hypoxia
+//	    decelerations: late
+//	    deceleration_rate: 1
+//	  - name: bradycardia
+//	    duration: 5m
+//	    baseline_bpm: 95
+//	  - name: signal loss
+//	    duration: 30s
+//	    signal_loss: true
+type Scenario struct {
+	// Name имя сценария в каталоге (имя файла без расширения)
+	Name        string `yaml:"-"`
+	Description string `yaml:"description"`
+	// Expected признаки, которые должен увидеть анализатор КТГ
+	Expected []string `yaml:"expected"`
+	// Preset встроенный сценарий (не из файла)
+	Preset bool `yaml:"-"`
+	// Loop повторяет сценарий сначала; иначе после последней фазы
+	// генерация продолжается в её режиме
+	Loop   bool    `yaml:"loop"`
+	Phases []Phase `yaml:"phases"`
+}
+
+// Phase фаза сценария. Не указанные параметры наследуются от предыдущей фазы
+// (кроме signal_loss); нулевое значение возвращает поведение по умолчанию
+type Phase struct {
+	Name     string        `yaml:"name"`
+	Duration time.Duration `yaml:"duration"`
+	// State состояние плода: healthy или hypoxia (первая фаза по умолчанию healthy)
+	State string `yaml:"state"`
+
+	BaselineBPM         *float64       `yaml:"baseline_bpm"`
+	BaselineShift       *float64       `yaml:"baseline_shift"`
+	Variability         *float64       `yaml:"variability"` // множитель вариабельности
+	SinusoidalAmplitude *float64       `yaml:"sinusoidal_amplitude"`
+	SinusoidalPeriod    *time.Duration `yaml:"sinusoidal_period"`
+	ContractionInterval *time.Duration `yaml:"contraction_interval"`
+	ContractionDuration *time.Duration `yaml:"contraction_duration"` // средняя, ±25%
+	ContractionPeak     *float64       `yaml:"contraction_peak"`
+	UterineTone         *float64       `yaml:"uterine_tone"`
+	// Decelerations тип децелераций: none, early, late, variable, prolonged
+	Decelerations     *string  `yaml:"decelerations"`
+	DecelerationRate  *float64 `yaml:"deceleration_rate"`
+	DecelerationDepth *float64 `yaml:"deceleration_depth"`
+	Accelerations     *bool    `yaml:"accelerations"`
+	// MaternalBPM базальный пульс матери (канал bpmMother)
+	MaternalBPM *float64 `yaml:"maternal_bpm"`
+
+	// SignalLoss потеря сигнала FHR на всю фазу (передаётся нулём)
+	SignalLoss bool `yaml:"signal_loss"`
+}
+
+// scenarioPhase фаза с разрешённым наследованием параметров
+type scenarioPhase struct {
+	name       string
+	start      float64 // секунды от начала прохода сценария
+	end        float64
+	hypoxia    bool
+	behaviour  behaviour
+	signalLoss bool
+}
+
+// LoadScenario читает сценарий из YAML файла и проверяет его
+func LoadScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	scenario := &Scenario{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	// Опечатка в имени параметра не должна молча менять сценарий
+	decoder.KnownFields(true)
+	if err := decoder.Decode(scenario); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	scenario.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+	if _, err := scenario.compile(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return scenario, nil
+}
+
+// Info возвращает описание сценария для каталога
+func (s *Scenario) Info() generator.ScenarioInfo {
+	info := generator.ScenarioInfo{
+		Name:        s.Name,
+		Description: s.Description,
+		Expected:    s.Expected,
+		Preset:      s.Preset,
+		Loop:        s.Loop,
+	}
+	phases, _ := s.compile()
+	for _, phase := range phases {
+		duration := time.Duration((phase.end - phase.start) * float64(time.Second))
+		info.Duration += duration
+		info.Phases = append(info.Phases, generator.PhaseInfo{Name: phase.name, Duration: duration})
+	}
+	return info
+}
+
+// compile проверяет фазы и разрешает наследование параметров
+func (s *Scenario) compile() ([]scenarioPhase, error) {
+	if len(s.Phases) == 0 {
+		return nil, fmt.Errorf("scenario has no phases")
+	}
+
+	phases := make([]scenarioPhase, 0, len(s.Phases))
+	var (
+		start   float64
+		hypoxia bool
+		b       behaviour
+	)
+	for i, p := range s.Phases {
+		name := p.Name
+		if name == "" {
+			name = fmt.Sprintf("phase %d", i+1)
+		}
+		if p.Duration <= 0 {
+			return nil, fmt.Errorf("phase %q: duration must be positive", name)
+		}
+
+		switch p.State {
+		case "":
+		case stateHealthy:
+			hypoxia = false
+		case stateHypoxia:
+			hypoxia = true
+		default:
+			return nil, fmt.Errorf("phase %q: unknown state %q", name, p.State)
+		}
+
+		if err := p.apply(&b); err != nil {
+			return nil, fmt.Errorf("phase %q: %w", name, err)
+		}
+
+		end := start + p.Duration.Seconds()
+		phases = append(phases, scenarioPhase{
+			name:       name,
+			start:      start,
+			end:        end,
+			hypoxia:    hypoxia,
+			behaviour:  b,
+			signalLoss: p.SignalLoss,
+		})
+		start = end
+	}
+	return phases, nil
+}
+
+// apply переносит заданные в фазе параметры в поведение генератора
+func (p *Phase) apply(b *behaviour) error {
+	for _, v := range []struct {
+		name  string
+		value *float64
+		dst   *float64
+	}{
+		{"baseline_bpm", p.BaselineBPM, &b.baselineBPM},
+		{"variability", p.Variability, &b.variability},
+		{"sinusoidal_amplitude", p.SinusoidalAmplitude, &b.sinusoidalAmplitude},
+		{"contraction_peak", p.ContractionPeak, &b.contractionPeak},
+		{"uterine_tone", p.UterineTone, &b.uterineTone},
+		{"deceleration_depth", p.DecelerationDepth, &b.decelerationDepth},
+		{"maternal_bpm", p.MaternalBPM, &b.maternalBPM},
+	} {
+		if v.value == nil {
+			continue
+		}
+		if *v.value < 0 {
+			return fmt.Errorf("%s must not be negative", v.name)
+		}
+		*v.dst = *v.value
+	}
+
+	if p.BaselineShift != nil {
+		b.baselineShift = *p.BaselineShift
+	}
+	if p.SinusoidalPeriod != nil {
+		if *p.SinusoidalPeriod < 0 {
+			return fmt.Errorf("sinusoidal_period must not be negative")
+		}
+		b.sinusoidalPeriod = p.SinusoidalPeriod.Seconds()
+	}
+	if p.ContractionInterval != nil {
+		if *p.ContractionInterval < 0 {
+			return fmt.Errorf("contraction_interval must not be negative")
+		}
+		b.contractionInterval = p.ContractionInterval.Seconds()
+	}
+	if p.ContractionDuration != nil {
+		if *p.ContractionDuration < 0 {
+			return fmt.Errorf("contraction_duration must not be negative")
+		}
+		b.contractionDuration = p.ContractionDuration.Seconds()
+	}
+	if p.Decelerations != nil {
+		switch *p.Decelerations {
+		case "", decelerationNone, decelerationEarly, decelerationLate, decelerationVariable, decelerationProlonged:
+			b.decelerations = *p.Decelerations
+		default:
+			return fmt.Errorf("unknown decelerations %q", *p.Decelerations)
+		}
+	}
+	if p.DecelerationRate != nil {
+		if *p.DecelerationRate < 0 || *p.DecelerationRate > 1 {
+			return fmt.Errorf("deceleration_rate must be in range 0..1")
+		}
+		b.decelerationRate = *p.DecelerationRate
+	}
+	if p.Accelerations != nil {
+		b.noAccelerations = !*p.Accelerations
+	}
+	return nil
+}
+
+// scenarioPlayer воспроизводит сценарий: переключает поведение CTG генератора
+// по фазам и отмечает начало каждой фазы в эталонной разметке
+type scenarioPlayer struct {
+	ctg    *ctgGenerator
+	name   string
+	loop   bool
+	phases []scenarioPhase
+	total  float64
+
+	// Текущая фаза и номер прохода сценария (-1, пока фаза не применена)
+	current int
+	cycle   int
+
+	annotations []websocket.Annotation
+}
+
+// NewScenarioPlayer создает генератор, воспроизводящий сценарий
+func NewScenarioPlayer(scenario *Scenario, opts CTGOptions) (generator.DataGenerator, error) {
+	phases, err := scenario.compile()
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("Scenario player created",
+		"scenario", scenario.Name,
+		"phases", len(phases),
+		"duration_sec", phases[len(phases)-1].end,
+		"loop", scenario.Loop)
+
+	// Фазы задают схватки и децелерации, поэтому модель событий включена всегда
+	opts.Events = true
+	// Пульс матери, заданный фазой, должен попасть в поток
+	for _, phase := range phases {
+		if phase.behaviour.maternalBPM > 0 {
+			opts.MaternalHR = true
+		}
+	}
+	return &scenarioPlayer{
+		ctg:     newCTGGenerator(0, opts),
+		name:    scenario.Name,
+		loop:    scenario.Loop,
+		phases:  phases,
+		total:   phases[len(phases)-1].end,
+		current: -1,
+	}, nil
+}
+
+// GenerateNext применяет фазу, соответствующую timestamp, и генерирует точку
+func (p *scenarioPlayer) GenerateNext(timestamp float64) websocket.SensorData {
+	cycle, elapsed := 0, timestamp
+	if p.loop {
+		cycle = int(math.Floor(timestamp / p.total))
+		elapsed = timestamp - float64(cycle)*p.total
+	}
+
+	// После последней фазы (без повтора) остаёмся в ней
+	index := len(p.phases) - 1
+	for i, phase := range p.phases {
+		if elapsed < phase.end {
+			index = i
+			break
+		}
+	}
+	if index != p.current || cycle != p.cycle {
+		p.enter(index, cycle, timestamp)
+	}
+
+	data := p.ctg.GenerateNext(timestamp)
+	if p.phases[index].signalLoss {
+		data.BPMChild = 0
+		if data.BPMChild2 != nil {
+			lost := 0.0
+			data.BPMChild2 = &lost
+		}
+	}
+	return data
+}
+
+// enter переключает генератор на фазу index прохода cycle
+func (p *scenarioPlayer) enter(index, cycle int, timestamp float64) {
+	phase := p.phases[index]
+	p.current, p.cycle = index, cycle
+	p.ctg.setState(phase.hypoxia, timestamp)
+	p.ctg.behaviour = phase.behaviour
+
+	offset := float64(cycle) * p.total
+	annotation := websocket.Annotation{
+		Type:  websocket.AnnotationPhase,
+		Kind:  phase.name,
+		Start: offset + phase.start,
+	}
+	if p.loop || index < len(p.phases)-1 {
+		end := offset + phase.end
+		annotation.End = &end
+	}
+	p.annotations = append(p.annotations, annotation)
+	if phase.signalLoss {
+		annotation.Type = websocket.AnnotationArtifact
+		annotation.Kind = artifactSignalLoss
+		p.annotations = append(p.annotations, annotation)
+	}
+
+	slog.Info("Scenario phase started", "scenario", p.name, "phase", phase.name, "cycle", cycle)
+}
+
+// DrainAnnotations объединяет разметку фаз и событий CTG генератора
+func (p *scenarioPlayer) DrainAnnotations() []websocket.Annotation {
+	annotations := append(p.annotations, p.ctg.DrainAnnotations()...)
+	p.annotations = nil
+	return annotations
+}
+
+// DrainMarks отдаёт отметки шевелений CTG генератора
+func (p *scenarioPlayer) DrainMarks() []websocket.Mark {
+	return p.ctg.DrainMarks()
+}
+
+// Reset возвращает сценарий к первой фазе
+func (p *scenarioPlayer) Reset() {
+	p.ctg.Reset()
+	p.current = -1
+	p.cycle = 0
+	p.annotations = nil
+}
+
+// SetFetalState меняет состояние плода до начала следующей фазы сценария
+func (p *scenarioPlayer) SetFetalState(state string) error {
+	return p.ctg.SetFetalState(state)
+}
+
+// FetalState возвращает текущее состояние плода
+func (p *scenarioPlayer) FetalState() string {
+	return p.ctg.FetalState()
+}
+
+// SetProfile передаёт профиль пациентки CTG генератору
+func (p *scenarioPlayer) SetProfile(profile generator.Profile) (generator.ProfileNorms, bool) {
+	return p.ctg.SetProfile(profile)
+}
+
+// SetParameters передаёт параметры CTG генератору
+func (p *scenarioPlayer) SetParameters(params generator.GenerationParameters) {
+	p.ctg.SetParameters(params)
+}
@@ -0,0 +1,99 @@
+package generator
+
+import (
+	"backend_gen/internal/ports/generator"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+// Допустимое имя сценария: имя файла без расширения и без путей
+var scenarioNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// scenarioCatalog каталог сценариев: встроенные пресеты и YAML файлы в директории dir
+type scenarioCatalog struct {
+	dir  string
+	opts CTGOptions
+	// wrap оборачивает генератор сценария (например, слоем артефактов)
+	wrap func(generator.DataGenerator) generator.DataGenerator
+}
+
+// NewScenarioCatalog создает каталог из пресетов и файлов *.yaml и *.yml в dir.
+// Файлы читаются при каждом обращении, поэтому их можно менять без перезапуска
+func NewScenarioCatalog(
+	dir string,
+	opts CTGOptions,
+	wrap func(generator.DataGenerator) generator.DataGenerator,
+) generator.ScenarioCatalog {
+	return &scenarioCatalog{dir: dir, opts: opts, wrap: wrap}
+}
+
+func (c *scenarioCatalog) List() ([]generator.ScenarioInfo, error) {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	byName := make(map[string]generator.ScenarioInfo)
+	for _, name := range presetNames() {
+		byName[name] = presets[name].Info()
+	}
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		scenario, err := LoadScenario(filepath.Join(c.dir, entry.Name()))
+		if err != nil {
+			// Один сломанный файл не должен скрывать остальные сценарии
+			slog.Warn("Skipping invalid scenario", "error", err)
+			continue
+		}
+		byName[scenario.Name] = scenario.Info()
+	}
+
+	infos := make([]generator.ScenarioInfo, 0, len(byName))
+	for _, info := range byName {
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos, nil
+}
+
+func (c *scenarioCatalog) Open(name string) (generator.DataGenerator, error) {
+	if !scenarioNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid scenario name %q", name)
+	}
+
+	var path string
+	for _, ext := range []string{".yaml", ".yml"} {
+		candidate := filepath.Join(c.dir, name+ext)
+		if _, err := os.Stat(candidate); err == nil {
+			path = candidate
+			break
+		}
+	}
+
+	scenario, ok := presets[name]
+	if path != "" {
+		var err error
+		if scenario, err = LoadScenario(path); err != nil {
+			return nil, err
+		}
+	} else if !ok {
+		return nil, fmt.Errorf("%w: %s", generator.ErrScenarioNotFound, name)
+	}
+
+	player, err := NewScenarioPlayer(scenario, c.opts)
+	if err != nil {
+		return nil, err
+	}
+	if c.wrap != nil {
+		return c.wrap(player), nil
+	}
+	return player, nil
+}
@@ -0,0 +1,192 @@
+package generator
+
+import (
+	"backend_gen/internal/ports/generator"
+	"backend_gen/internal/ports/websocket"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// openScenario открывает сценарий каталога с фиксированным зерном
+func openScenario(t *testing.T, dir, name string) *scenarioPlayer {
+	t.Helper()
+	gen, err := NewScenarioCatalog(dir, CTGOptions{}, nil).Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	player := gen.(*scenarioPlayer)
+	seed(player.ctg, 1)
+	return player
+}
+
+func writeScenario(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPresetPlayback(t *testing.T) {
+	for _, name := range presetNames() {
+		t.Run(name, func(t *testing.T) {
+			player := openScenario(t, t.TempDir(), name)
+			info := presets[name].Info()
+
+			var phases []websocket.Annotation
+			for k := 0; k < int(info.Duration.Seconds()); k++ {
+				data := player.GenerateNext(float64(k))
+				if math.IsNaN(data.BPMChild) || data.BPMChild <= 0 || data.BPMChild > 250 || data.Uterus < 0 {
+					t.Fatalf("implausible point at %d s: %+v", k, data)
+				}
+				// Канал матери включается только пресетом, задающим её пульс
+				if (data.BPMMother != nil) != (name == "maternal-fever-tachycardia") {
+					t.Fatalf("maternal channel at %d s: %v", k, data.BPMMother)
+				}
+				// Состояние плода соответствует текущей фазе
+				if player.ctg.hasHypoxia != player.phases[player.current].hypoxia {
+					t.Fatalf("fetal state at %d s differs from phase %s", k, player.phases[player.current].name)
+				}
+				phases = append(phases, ofType(player.DrainAnnotations(), websocket.AnnotationPhase)...)
+			}
+
+			// Каждая фаза отмечена один раз, встык, в порядке сценария
+			if len(phases) != len(info.Phases) {
+				t.Fatalf("phase annotations = %+v, want %d", phases, len(info.Phases))
+			}
+			var start float64
+			for i, a := range phases {
+				if a.Kind != info.Phases[i].Name || a.Start != start {
+					t.Errorf("phase %d = %s at %v, want %s at %v", i, a.Kind, a.Start, info.Phases[i].Name, start)
+				}
+				start += info.Phases[i].Duration.Seconds()
+				last := i == len(phases)-1
+				if a.End == nil != (last && !info.Loop) {
+					t.Errorf("phase %s end = %v", a.Kind, a.End)
+				}
+			}
+		})
+	}
+}
+
+func TestPresetHyperstimulation(t *testing.T) {
+	player := openScenario(t, t.TempDir(), "uterine-hyperstimulation")
+	run(player, 0, 900)
+
+	// Тахисистолия: больше 5 схваток за 10 минут
+	var contractions int
+	for _, a := range ofType(player.DrainAnnotations(), websocket.AnnotationContraction) {
+		if a.Start < 900 {
+			contractions++
+		}
+	}
+	if contractions < 8 {
+		t.Errorf("contractions in 15 minutes = %d, want more than 5 per 10 minutes", contractions)
+	}
+}
+
+func TestScenarioFileLoop(t *testing.T) {
+	dir := t.TempDir()
+	// Файл с именем пресета переопределяет пресет
+	writeScenario(t, dir, "normal-reactive.yaml", `
+description: test
+loop: true
+phases:
+  - name: normal
+    duration: 10s
+  - name: lost
+    duration: 5s
+    signal_loss: true
+    state: hypoxia
+`)
+	infos, err := NewScenarioCatalog(dir, CTGOptions{}, nil).List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, info := range infos {
+		if info.Name == "normal-reactive" && (info.Preset || info.Description != "test") {
+			t.Errorf("file does not override the preset: %+v", info)
+		}
+	}
+
+	player := openScenario(t, dir, "normal-reactive")
+	data := run(player, 0, 30)
+	for k, d := range data {
+		lost := k%15 >= 10
+		if (d.BPMChild == 0) != lost {
+			t.Errorf("FHR at %d s = %v, signal loss expected %v", k, d.BPMChild, lost)
+		}
+	}
+
+	var got []string
+	var starts []float64
+	for _, a := range player.DrainAnnotations() {
+		if a.Type == websocket.AnnotationPhase || a.Type == websocket.AnnotationArtifact {
+			got = append(got, a.Type+":"+a.Kind)
+			starts = append(starts, a.Start)
+		}
+	}
+	want := []string{"phase:normal", "phase:lost", "artifact:signal_loss", "phase:normal", "phase:lost", "artifact:signal_loss"}
+	wantStarts := []float64{0, 10, 10, 15, 25, 25}
+	if len(got) != len(want) {
+		t.Fatalf("annotations = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] || starts[i] != wantStarts[i] {
+			t.Errorf("annotation %d = %s at %v, want %s at %v", i, got[i], starts[i], want[i], wantStarts[i])
+		}
+	}
+
+	// Reset возвращает сценарий к первой фазе
+	player.Reset()
+	player.GenerateNext(0)
+	if a := player.DrainAnnotations(); len(a) == 0 || a[0].Kind != "normal" || a[0].Start != 0 {
+		t.Errorf("annotations after reset = %+v", a)
+	}
+}
+
+func TestScenarioCatalogErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeScenario(t, dir, "typo.yaml", "phases:\n  - duration: 1m\n    baseline: 120\n")
+	writeScenario(t, dir, "state.yml", "phases:\n  - duration: 1m\n    state: sleepy\n")
+	writeScenario(t, dir, "empty.yaml", "description: nothing\n")
+	catalog := NewScenarioCatalog(dir, CTGOptions{}, nil)
+
+	if _, err := catalog.Open("../typo"); err == nil {
+		t.Error("path in the scenario name accepted")
+	}
+	if _, err := catalog.Open("missing"); !errors.Is(err, generator.ErrScenarioNotFound) {
+		t.Errorf("missing scenario error = %v", err)
+	}
+	for _, name := range []string{"typo", "state", "empty"} {
+		if _, err := catalog.Open(name); err == nil {
+			t.Errorf("invalid scenario %s opened", name)
+		}
+	}
+
+	// Сломанные файлы не скрывают пресеты
+	infos, err := catalog.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != len(presets) {
+		t.Errorf("scenarios = %d, want %d presets", len(infos), len(presets))
+	}
+}
+
+func TestScenarioCatalogWrap(t *testing.T) {
+	var wrapped int
+	catalog := NewScenarioCatalog(t.TempDir(), CTGOptions{}, func(g generator.DataGenerator) generator.DataGenerator {
+		wrapped++
+		return NewArtifactGenerator(g, ArtifactConfig{})
+	})
+	gen, err := catalog.Open("sinusoidal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := gen.(*artifactGenerator); !ok || wrapped != 1 {
+		t.Errorf("scenario generator %T not wrapped", gen)
+	}
+}
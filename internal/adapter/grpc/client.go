@@ -0,0 +1,278 @@
+package grpc
+
+import (
+	ctgv1 "backend_gen/api/proto/ctg/v1"
+	"backend_gen/internal/adapter/encoding"
+	"backend_gen/internal/ports/websocket"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// Ключи метаданных вызова Ingest
+const (
+	MetadataSensorID      = "x-sensor-id"
+	MetadataAuthorization = "authorization"
+)
+
+// Сколько ждать заголовков ответа при подключении: сервер может отложить их
+// до конца потока, поэтому по истечении поток считается открытым
+const headersTimeout = 2 * time.Second
+
+// Сколько ждать итога сервера при отключении
+const closeTimeout = 5 * time.Second
+
+// Encodings форматы сообщений, которые переносит транспорт: поток Sample
+// принимает только MessageData в protobuf
+var Encodings = []string{encoding.FormatProtobuf}
+
+// Config параметры gRPC транспорта
+type Config struct {
+	SensorID string
+	// Buffer размер очереди отправки; при переполнении сообщение отбрасывается
+	Buffer int
+	// Insecure отключает проверку TLS сертификата для https адреса
+	Insecure bool
+}
+
+// client отправляет сообщения в клиентский поток Ingestion.Ingest
+// (api/proto/ingest.proto). Сообщения должны быть в формате Protobuf
+// (websocket.encoding = protobuf). Управление потоком выполняет HTTP/2:
+// пока сервер не освободит окно, отправка ждёт, а новые сообщения копятся
+// в очереди размером Buffer
+type client struct {
+	cfg Config
+
+	mu     sync.Mutex
+	stream *stream
+}
+
+// stream состояние открытого вызова Ingest
+type stream struct {
+	call    ctgv1.Ingestion_IngestClient
+	conn    *grpc.ClientConn
+	samples chan *ctgv1.Sample
+	cancel  context.CancelFunc
+	done    chan struct{}
+
+	sequence uint64 // последний отправленный номер
+	result   *ctgv1.IngestResponse
+}
+
+// NewClient создает gRPC транспорт
+func NewClient(cfg Config) websocket.Client {
+	if cfg.Buffer <= 0 {
+		cfg.Buffer = 256
+	}
+	return &client{cfg: cfg}
+}
+
+// Connect открывает поток по адресу вида http://host:port (HTTP/2 без TLS)
+// или https://host:port. Токен передаётся в метаданных authorization
+func (c *client) Connect(rawURL string, token string) error {
+	c.mu.Lock()
+	connected := c.stream != nil
+	c.mu.Unlock()
+	if connected {
+		return fmt.Errorf("already connected")
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid server url: %w", err)
+	}
+	var creds credentials.TransportCredentials
+	switch u.Scheme {
+	case "http":
+		creds = insecure.NewCredentials()
+	case "https":
+		creds = credentials.NewTLS(&tls.Config{InsecureSkipVerify: c.cfg.Insecure})
+	default:
+		return fmt.Errorf("unsupported server url scheme %q", u.Scheme)
+	}
+
+	conn, err := grpc.NewClient(u.Host, grpc.WithTransportCredentials(creds), grpc.WithUserAgent("ctg-generator"))
+	if err != nil {
+		return err
+	}
+	md := metadata.Pairs(MetadataSensorID, c.cfg.SensorID)
+	if token != "" {
+		md.Set(MetadataAuthorization, "Bearer "+token)
+	}
+	ctx, cancel := context.WithCancel(metadata.NewOutgoingContext(context.Background(), md))
+
+	slog.Info("Opening gRPC stream", "url", rawURL, "sensor_id", c.cfg.SensorID)
+	call, err := ctgv1.NewIngestionClient(conn).Ingest(ctx)
+	if err == nil {
+		err = opened(call)
+	}
+	if err != nil {
+		cancel()
+		conn.Close()
+		return err
+	}
+
+	st := &stream{
+		call:    call,
+		conn:    conn,
+		samples: make(chan *ctgv1.Sample, c.cfg.Buffer),
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	c.mu.Lock()
+	c.stream = st
+	c.mu.Unlock()
+	go c.send(st)
+
+	slog.Info("gRPC stream opened", "url", rawURL)
+	return nil
+}
+
+// opened ждёт заголовки ответа: поток, завершённый сразу (например, из-за
+// неверного токена), возвращает статус сервера
+func opened(call ctgv1.Ingestion_IngestClient) error {
+	headers := make(chan metadata.MD, 1)
+	go func() {
+		md, _ := call.Header()
+		headers <- md
+	}()
+
+	select {
+	case md := <-headers:
+		if md != nil {
+			return nil
+		}
+		if _, err := call.CloseAndRecv(); err != nil {
+			return err
+		}
+		return fmt.Errorf("server closed the stream")
+	case <-time.After(headersTimeout):
+		return nil
+	}
+}
+
+// Disconnect закрывает поток со стороны клиента и ждёт итог сервера
+func (c *client) Disconnect() error {
+	c.mu.Lock()
+	st := c.stream
+	c.stream = nil
+	if st != nil {
+		close(st.samples)
+	}
+	c.mu.Unlock()
+
+	if st == nil {
+		return nil
+	}
+	select {
+	case <-st.done:
+	case <-time.After(closeTimeout):
+		slog.Warn("gRPC server did not close the stream in time")
+		st.cancel()
+		<-st.done
+	}
+	if st.result != nil {
+		slog.Info("gRPC stream closed", "sent", st.sequence,
+			"accepted", st.result.GetAccepted(), "last_sequence", st.result.GetLastSequence())
+		if st.result.GetAccepted() < st.sequence {
+			slog.Warn("gRPC server accepted fewer messages than sent",
+				"sent", st.sequence, "accepted", st.result.GetAccepted())
+		}
+	}
+	return nil
+}
+
+func (c *client) IsConnected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stream != nil
+}
+
+// SendMessage не поддерживается: API приёма принимает только Protobuf
+func (c *client) SendMessage(message []byte) error {
+	return fmt.Errorf("grpc transport requires protobuf encoding")
+}
+
+// SendBinary ставит сообщение в очередь потока; при переполненной очереди
+// (сервер не успевает освобождать окно HTTP/2) сообщение отбрасывается
+func (c *client) SendBinary(message []byte) error {
+	data := new(ctgv1.MessageData)
+	if err := proto.Unmarshal(message, data); err != nil {
+		return fmt.Errorf("invalid protobuf message: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	st := c.stream
+	if st == nil {
+		return fmt.Errorf("not connected")
+	}
+	sample := &ctgv1.Sample{Sequence: st.sequence + 1, Message: data}
+	select {
+	case st.samples <- sample:
+		st.sequence = sample.Sequence
+		return nil
+	default:
+		return fmt.Errorf("send queue is full")
+	}
+}
+
+// send пишет сообщения из очереди в поток; закрытие очереди завершает поток
+// и получает итог сервера
+func (c *client) send(st *stream) {
+	defer func() {
+		st.cancel()
+		st.conn.Close()
+		close(st.done)
+	}()
+
+	for sample := range st.samples {
+		if err := st.call.Send(sample); err != nil {
+			// Send возвращает io.EOF, если сервер завершил поток: статус берём из ответа
+			_, err = st.call.CloseAndRecv()
+			c.lost(st, err)
+			// Дочитываем очередь до закрытия, чтобы не держать сообщения
+			for range st.samples {
+			}
+			return
+		}
+	}
+
+	result, err := st.call.CloseAndRecv()
+	if err != nil {
+		slog.Error("gRPC stream finished with error", "error", err)
+		return
+	}
+	st.result = result
+}
+
+// lost отмечает поток закрытым, если он завершился не по Disconnect
+func (c *client) lost(st *stream, err error) {
+	c.mu.Lock()
+	active := c.stream == st
+	if active {
+		c.stream = nil
+		close(st.samples)
+	}
+	c.mu.Unlock()
+
+	if !active {
+		return
+	}
+	if err != nil {
+		slog.Error("gRPC stream lost", "error", err)
+	} else {
+		slog.Warn("gRPC stream closed by server")
+	}
+}
@@ -0,0 +1,229 @@
+package grpc
+
+import (
+	ctgv1 "backend_gen/api/proto/ctg/v1"
+	"backend_gen/internal/adapter/encoding"
+	"backend_gen/internal/ports/websocket"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// ingestion тестовый сервер приёма: сохраняет метаданные и принятые Sample
+type ingestion struct {
+	ctgv1.UnimplementedIngestionServer
+
+	// handle заменяет приём по умолчанию
+	handle func(ctgv1.Ingestion_IngestServer) error
+
+	mu       sync.Mutex
+	metadata metadata.MD
+	samples  []*ctgv1.Sample
+	closed   chan struct{}
+}
+
+func (s *ingestion) Ingest(stream ctgv1.Ingestion_IngestServer) error {
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	s.mu.Lock()
+	s.metadata = md
+	s.mu.Unlock()
+	if s.handle != nil {
+		return s.handle(stream)
+	}
+
+	defer close(s.closed)
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+	var last uint64
+	for {
+		sample, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		s.mu.Lock()
+		s.samples = append(s.samples, sample)
+		s.mu.Unlock()
+		last = sample.GetSequence()
+	}
+	s.mu.Lock()
+	accepted := uint64(len(s.samples))
+	s.mu.Unlock()
+	return stream.SendAndClose(&ctgv1.IngestResponse{Accepted: accepted, LastSequence: last})
+}
+
+// startServer запускает gRPC сервер на свободном порту и возвращает адрес для Connect
+func startServer(t *testing.T, s *ingestion) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s.closed = make(chan struct{})
+	server := grpc.NewServer()
+	ctgv1.RegisterIngestionServer(server, s)
+	go server.Serve(ln)
+	t.Cleanup(server.Stop)
+	return "http://" + ln.Addr().String()
+}
+
+func encode(t *testing.T, m websocket.MessageData) []byte {
+	t.Helper()
+	data, err := proto.Marshal(encoding.ProtoMessage(m))
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return data
+}
+
+func TestIngestStream(t *testing.T) {
+	server := &ingestion{}
+	c := NewClient(Config{SensorID: "s1"})
+	if err := c.Connect(startServer(t, server), "secret"); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if !c.IsConnected() {
+		t.Fatal("client is not connected")
+	}
+
+	const count = 3
+	for i := range count {
+		m := websocket.MessageData{SensorID: "s1", SecFromStart: float64(i) * 0.25,
+			Data: websocket.SensorData{BPMChild: 140 + float64(i), Uterus: 12}}
+		if err := c.SendBinary(encode(t, m)); err != nil {
+			t.Fatalf("send: %v", err)
+		}
+	}
+	if err := c.SendMessage([]byte("{}")); err == nil {
+		t.Error("text messages must be rejected")
+	}
+	if err := c.SendBinary([]byte{0xff}); err == nil {
+		t.Error("invalid protobuf must be rejected")
+	}
+	if err := c.Disconnect(); err != nil {
+		t.Fatalf("disconnect: %v", err)
+	}
+	<-server.closed
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if got := server.metadata.Get(MetadataSensorID); len(got) != 1 || got[0] != "s1" {
+		t.Errorf("sensor id metadata = %v", got)
+	}
+	if got := server.metadata.Get(MetadataAuthorization); len(got) != 1 || got[0] != "Bearer secret" {
+		t.Errorf("authorization metadata = %v", got)
+	}
+	if len(server.samples) != count {
+		t.Fatalf("server received %d samples, want %d", len(server.samples), count)
+	}
+	for i, sample := range server.samples {
+		if sample.GetSequence() != uint64(i+1) {
+			t.Errorf("sample %d has sequence %d", i, sample.GetSequence())
+		}
+		m := encoding.FromProto(sample.GetMessage())
+		if m.SensorID != "s1" || m.Data.BPMChild != 140+float64(i) || m.SecFromStart != float64(i)*0.25 {
+			t.Errorf("sample %d = %+v", i, m)
+		}
+	}
+	c.(*client).mu.Lock()
+	defer c.(*client).mu.Unlock()
+	if c.(*client).stream != nil {
+		t.Error("stream is kept after Disconnect")
+	}
+}
+
+func TestUnauthenticated(t *testing.T) {
+	server := &ingestion{handle: func(ctgv1.Ingestion_IngestServer) error {
+		return status.Error(codes.Unauthenticated, "invalid sensor token")
+	}}
+	c := NewClient(Config{SensorID: "s1"})
+	err := c.Connect(startServer(t, server), "wrong")
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("connect error = %v, want Unauthenticated", err)
+	}
+	if c.IsConnected() {
+		t.Error("client is connected after rejected stream")
+	}
+}
+
+func TestServerAbort(t *testing.T) {
+	server := &ingestion{handle: func(stream ctgv1.Ingestion_IngestServer) error {
+		if err := stream.SendHeader(metadata.MD{}); err != nil {
+			return err
+		}
+		if _, err := stream.Recv(); err != nil {
+			return err
+		}
+		return status.Error(codes.ResourceExhausted, "quota exceeded")
+	}}
+	c := NewClient(Config{SensorID: "s1"})
+	if err := c.Connect(startServer(t, server), ""); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+
+	message := encode(t, websocket.MessageData{SensorID: "s1", Data: websocket.SensorData{BPMChild: 140}})
+	deadline := time.Now().Add(5 * time.Second)
+	for c.IsConnected() {
+		if time.Now().After(deadline) {
+			t.Fatal("client did not notice aborted stream")
+		}
+		_ = c.SendBinary(message)
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err := c.SendBinary(message); err == nil || !strings.Contains(err.Error(), "not connected") {
+		t.Errorf("send after abort = %v", err)
+	}
+	if err := c.Disconnect(); err != nil {
+		t.Errorf("disconnect after abort: %v", err)
+	}
+}
+
+func TestSendQueueFull(t *testing.T) {
+	release := make(chan struct{})
+	server := &ingestion{handle: func(stream ctgv1.Ingestion_IngestServer) error {
+		if err := stream.SendHeader(metadata.MD{}); err != nil {
+			return err
+		}
+		// Сервер не читает поток: окно HTTP/2 заполняется, отправка встаёт
+		select {
+		case <-release:
+		case <-stream.Context().Done():
+		}
+		return nil
+	}}
+	c := NewClient(Config{SensorID: "s1", Buffer: 4})
+	if err := c.Connect(startServer(t, server), ""); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer func() {
+		close(release)
+		c.Disconnect()
+	}()
+
+	// Заметки раздувают сообщение, чтобы быстро исчерпать окно
+	m := websocket.MessageData{SensorID: "s1", Marks: []websocket.Mark{{Type: "event", Note: strings.Repeat("x", 32<<10)}}}
+	message := encode(t, m)
+	for i := range 1000 {
+		if err := c.SendBinary(message); err != nil {
+			if !strings.Contains(err.Error(), "send queue is full") {
+				t.Fatalf("send %d: %v", i, err)
+			}
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("send queue never filled up")
+}
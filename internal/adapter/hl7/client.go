@@ -0,0 +1,183 @@
+package hl7
+
+import (
+	"backend_gen/internal/ports/websocket"
+	"bufio"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ErrRejected сообщение отклонено получателем (ACK с кодом AE/AR/CE/CR)
+var ErrRejected = errors.New("hl7 message rejected")
+
+// Encodings форматы сообщений, которые переносит транспорт
+var Encodings = []string{FormatHL7}
+
+// ClientConfig параметры MLLP транспорта
+type ClientConfig struct {
+	// AckTimeout ожидание ACK на каждое сообщение
+	AckTimeout time.Duration
+	// Retries повторов при таймауте ACK и коде AR (временный отказ)
+	Retries int
+}
+
+// client отправляет сообщения ORU^R01 по MLLP и ждёт ACK на каждое.
+// Сообщения должны быть закодированы в HL7 (websocket.encoding = hl7)
+type client struct {
+	cfg ClientConfig
+
+	mu sync.Mutex
+	// session сессия открыта (между Connect и Disconnect); соединение conn
+	// после сбоя сбрасывается и переоткрывается следующей отправкой
+	session bool
+	addr    string
+	conn    net.Conn
+	reader  *bufio.Reader
+}
+
+// NewClient создает MLLP транспорт
+func NewClient(cfg ClientConfig) websocket.Client {
+	if cfg.AckTimeout <= 0 {
+		cfg.AckTimeout = 5 * time.Second
+	}
+	return &client{cfg: cfg}
+}
+
+// Connect подключается к получателю по адресу вида mllp://host:port
+func (c *client) Connect(rawURL string, token string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.session {
+		return fmt.Errorf("already connected")
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid mllp url: %w", err)
+	}
+	if u.Scheme != "mllp" && u.Scheme != "tcp" {
+		return fmt.Errorf("unsupported mllp url scheme %q", u.Scheme)
+	}
+
+	c.addr = u.Host
+	if err := c.dialLocked(); err != nil {
+		return err
+	}
+	c.session = true
+	slog.Info("MLLP connection established", "addr", c.addr)
+	return nil
+}
+
+func (c *client) dialLocked() error {
+	conn, err := net.DialTimeout("tcp", c.addr, 10*time.Second)
+	if err != nil {
+		return err
+	}
+	c.conn = conn
+	c.reader = bufio.NewReader(conn)
+	return nil
+}
+
+func (c *client) Disconnect() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.session = false
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	c.reader = nil
+	slog.Info("MLLP disconnected")
+	return err
+}
+
+// IsConnected сообщает об открытой сессии: сброшенное после сбоя соединение
+// переоткрывается следующей отправкой
+func (c *client) IsConnected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.session
+}
+
+// SendMessage отправляет сообщение HL7 и ждёт ACK. При таймауте соединение
+// переоткрывается: ACK мог прийти позже и сбить сопоставление ответов.
+// Если соединение было сброшено прошлой отправкой, оно открывается заново
+func (c *client) SendMessage(message []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.session {
+		return fmt.Errorf("not connected")
+	}
+	header, err := ParseHeader(message)
+	if err != nil {
+		return fmt.Errorf("mllp transport requires hl7 encoding: %w", err)
+	}
+	if c.conn == nil {
+		if err := c.dialLocked(); err != nil {
+			return fmt.Errorf("failed to reconnect: %w", err)
+		}
+		slog.Info("MLLP connection re-established", "addr", c.addr)
+	}
+
+	for attempt := 0; ; attempt++ {
+		ack, err := c.exchange(message)
+		if err == nil {
+			if ack.ControlID != header.ControlID {
+				err = fmt.Errorf("ACK for control ID %q, expected %q", ack.ControlID, header.ControlID)
+			} else if ack.Accepted() {
+				return nil
+			} else {
+				err = fmt.Errorf("%w: %s %s", ErrRejected, ack.Code, ack.Text)
+				// AE - ошибка в сообщении, повтор не поможет
+				if ack.Code != AckReject && ack.Code != AckCommitRej {
+					return err
+				}
+			}
+		}
+		if attempt >= c.cfg.Retries {
+			return err
+		}
+
+		slog.Warn("Retrying HL7 message", "control_id", header.ControlID, "attempt", attempt+1, "error", err)
+		if c.conn == nil {
+			if err := c.dialLocked(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// SendBinary не поддерживается: HL7 v2 - текстовый формат
+func (c *client) SendBinary(message []byte) error {
+	return fmt.Errorf("mllp transport requires hl7 encoding")
+}
+
+// exchange отправляет сообщение и читает ACK; при ошибке соединение закрывается
+func (c *client) exchange(message []byte) (Ack, error) {
+	_ = c.conn.SetDeadline(time.Now().Add(c.cfg.AckTimeout))
+	if err := WriteFrame(c.conn, message); err != nil {
+		c.dropLocked()
+		return Ack{}, err
+	}
+	frame, err := ReadFrame(c.reader)
+	if err != nil {
+		c.dropLocked()
+		return Ack{}, fmt.Errorf("failed to read ACK: %w", err)
+	}
+	return ParseAck(frame)
+}
+
+// dropLocked закрывает соединение после ошибки; переподключение - при повторе
+func (c *client) dropLocked() {
+	c.conn.Close()
+	c.conn = nil
+	c.reader = nil
+}
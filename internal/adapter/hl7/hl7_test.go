@@ -0,0 +1,390 @@
+package hl7
+
+import (
+	"backend_gen/internal/ports/websocket"
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"io"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
+func ptr(v float64) *float64 { return &v }
+
+func TestFrameRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	messages := []string{"MSH|^~\\&|A\rPID|1", "", strings.Repeat("x", 5000)}
+	// Мусор до начала блока пропускается
+	buf.WriteString("noise\r\n")
+	for _, m := range messages {
+		if err := WriteFrame(&buf, []byte(m)); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	r := bufio.NewReader(&buf)
+	for _, want := range messages {
+		got, err := ReadFrame(r)
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		if string(got) != want {
+			t.Errorf("frame = %q, want %q", got, want)
+		}
+	}
+	if _, err := ReadFrame(r); err != io.EOF {
+		t.Errorf("read after last frame = %v, want EOF", err)
+	}
+}
+
+func TestReadFrameErrors(t *testing.T) {
+	cases := map[string]string{
+		"truncated":  "\x0bMSH|^~\\&",
+		"missing CR": "\x0bMSH\x1cX",
+	}
+	for name, input := range cases {
+		if _, err := ReadFrame(bufio.NewReader(strings.NewReader(input))); err == nil || err == io.EOF {
+			t.Errorf("%s: err = %v", name, err)
+		}
+	}
+}
+
+func TestAckRoundTrip(t *testing.T) {
+	message := []byte("MSH|^~\\&|GEN|WARD|EMR|HOSP|20240101000000||ORU^R01^ORU_R01|42|P|2.5.1\rPID|1\r")
+	h, err := ParseHeader(message)
+	if err != nil {
+		t.Fatalf("parse header: %v", err)
+	}
+	want := Header{SendingApp: "GEN", SendingFacility: "WARD", ReceivingApp: "EMR", ReceivingFacility: "HOSP",
+		MessageType: "ORU^R01^ORU_R01", ControlID: "42", Version: "2.5.1"}
+	if h != want {
+		t.Fatalf("header = %+v, want %+v", h, want)
+	}
+
+	ack, err := ParseAck(BuildAck(h, AckAccept, "", "1"))
+	if err != nil {
+		t.Fatalf("parse ack: %v", err)
+	}
+	if !ack.Accepted() || ack.ControlID != "42" {
+		t.Errorf("ack = %+v", ack)
+	}
+
+	raw := BuildAck(h, AckError, "bad|value", "2")
+	ackHeader, err := ParseHeader(raw)
+	if err != nil {
+		t.Fatalf("parse ack header: %v", err)
+	}
+	if ackHeader.SendingApp != "EMR" || ackHeader.ReceivingApp != "GEN" || ackHeader.MessageType != "ACK^R01^ACK" {
+		t.Errorf("ack header = %+v", ackHeader)
+	}
+	ack, err = ParseAck(raw)
+	if err != nil {
+		t.Fatalf("parse ack: %v", err)
+	}
+	if ack.Accepted() || ack.Code != AckError || ack.Text != `bad\F\value` {
+		t.Errorf("ack = %+v", ack)
+	}
+
+	if _, err := ParseAck([]byte("MSH|^~\\&|X\r")); err == nil {
+		t.Error("ACK without MSA must be rejected")
+	}
+	if _, err := ParseHeader([]byte("PID|1")); err == nil {
+		t.Error("message without MSH must be rejected")
+	}
+}
+
+// testEncoder кодировщик с фиксированными временем и control ID
+func testEncoder() *encoder {
+	e := NewEncoder(Config{SendingFacility: "WARD", ReceivingApp: "EMR", ReceivingFacility: "HOSP", SensorID: "s1"}).(*encoder)
+	e.now = func() time.Time { return time.Date(2024, 3, 1, 12, 30, 15, 250e6, time.FixedZone("", 3*3600)) }
+	e.counter.Store(1000)
+	return e
+}
+
+func TestEncodeGolden(t *testing.T) {
+	m := websocket.MessageData{
+		SecFromStart: 12.5,
+		Data: websocket.SensorData{
+			BPMChild:  140.25,
+			Uterus:    18,
+			Spasms:    math.NaN(),
+			BPMMother: ptr(82),
+			BPMChild2: ptr(138.5),
+		},
+		Annotations: []websocket.Annotation{{Type: "artifact", Kind: "signal_loss", Start: 12, End: ptr(14), Channel: "bpmChild2"}},
+		Marks:       []websocket.Mark{{Type: "event", Source: "manual", SecFromStart: 12.25, Note: "a|b"}},
+	}
+	got, err := testEncoder().Encode(m)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+
+	path := filepath.Join("testdata", "oru_r01.hl7")
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read golden: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("ORU^R01 differs from %s:\n%s\nwant:\n%s", path,
+			strings.ReplaceAll(string(got), "\r", "\n"), strings.ReplaceAll(string(want), "\r", "\n"))
+	}
+
+	h, err := ParseHeader(got)
+	if err != nil {
+		t.Fatalf("parse header: %v", err)
+	}
+	if h.ControlID != "1001" || h.MessageType != "ORU^R01^ORU_R01" {
+		t.Errorf("header = %+v", h)
+	}
+}
+
+// listener тестовый получатель MLLP: отвечает на каждое сообщение кодом из replies
+type listener struct {
+	addr     string
+	replies  chan string
+	received chan Header
+}
+
+func startListener(t *testing.T) *listener {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	l := &listener{addr: ln.Addr().String(), replies: make(chan string, 16), received: make(chan Header, 16)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go l.serve(conn)
+		}
+	}()
+	return l
+}
+
+// serve отвечает кодом из replies; "drop" закрывает соединение без ответа,
+// "wrong-id" подтверждает чужой control ID
+func (l *listener) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		message, err := ReadFrame(r)
+		if err != nil {
+			return
+		}
+		h, _ := ParseHeader(message)
+		l.received <- h
+		reply := <-l.replies
+		switch reply {
+		case "drop":
+			return
+		case "wrong-id":
+			h.ControlID = "other"
+			reply = AckAccept
+		}
+		if err := WriteFrame(conn, BuildAck(h, reply, "test", "1")); err != nil {
+			return
+		}
+	}
+}
+
+func TestClientAck(t *testing.T) {
+	message, err := testEncoder().Encode(websocket.MessageData{Data: websocket.SensorData{BPMChild: 140, Uterus: 10}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name     string
+		retries  int
+		replies  []string
+		attempts int
+		wantErr  error
+	}{
+		{"accepted", 0, []string{AckAccept}, 1, nil},
+		{"commit accept", 0, []string{AckCommitAcc}, 1, nil},
+		{"reject then accept", 2, []string{AckReject, AckAccept}, 2, nil},
+		{"reconnect after drop", 1, []string{"drop", AckAccept}, 2, nil},
+		{"error is not retried", 3, []string{AckError}, 1, ErrRejected},
+		{"retries exhausted", 1, []string{AckReject, AckReject}, 2, ErrRejected},
+		{"wrong control id", 0, []string{"wrong-id"}, 1, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			l := startListener(t)
+			for _, r := range tc.replies {
+				l.replies <- r
+			}
+			c := NewClient(ClientConfig{AckTimeout: time.Second, Retries: tc.retries})
+			if err := c.Connect("mllp://"+l.addr, ""); err != nil {
+				t.Fatalf("connect: %v", err)
+			}
+			defer c.Disconnect()
+
+			err := c.SendMessage(message)
+			switch {
+			case tc.name == "wrong control id":
+				if err == nil || !strings.Contains(err.Error(), "control ID") {
+					t.Errorf("err = %v, want control ID mismatch", err)
+				}
+			case tc.wantErr != nil:
+				if !errors.Is(err, tc.wantErr) {
+					t.Errorf("err = %v, want %v", err, tc.wantErr)
+				}
+			case err != nil:
+				t.Errorf("send: %v", err)
+			}
+			if got := len(l.received); got != tc.attempts {
+				t.Errorf("listener received %d messages, want %d", got, tc.attempts)
+			}
+			for range tc.attempts {
+				if h := <-l.received; h.ControlID != "1001" {
+					t.Errorf("received control ID %q", h.ControlID)
+				}
+			}
+		})
+	}
+}
+
+func TestClientAckTimeout(t *testing.T) {
+	message, err := testEncoder().Encode(websocket.MessageData{Data: websocket.SensorData{BPMChild: 140, Uterus: 10}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := startListener(t)
+	c := NewClient(ClientConfig{AckTimeout: 100 * time.Millisecond}).(*client)
+	if err := c.Connect("mllp://"+l.addr, ""); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer c.Disconnect()
+
+	// Получатель молчит: ACK не приходит, соединение сбрасывается
+	if err := c.SendMessage(message); err == nil {
+		t.Fatal("send without ACK must fail")
+	}
+	c.mu.Lock()
+	dropped := c.conn == nil
+	c.mu.Unlock()
+	if !dropped {
+		t.Error("connection must be dropped after ACK timeout")
+	}
+	if err := c.SendBinary(message); err == nil {
+		t.Error("binary messages must be rejected")
+	}
+	if err := c.SendMessage([]byte("PID|1")); err == nil {
+		t.Error("non-HL7 message must be rejected")
+	}
+}
+
+// field возвращает поле n сегмента name (MSH-1 - сам разделитель, поэтому
+// у MSH номера полей на единицу больше индекса)
+func field(t *testing.T, message []byte, name string, n int) string {
+	t.Helper()
+	for _, segment := range strings.Split(string(message), "\r") {
+		fields := strings.Split(segment, "|")
+		if fields[0] != name {
+			continue
+		}
+		if name == "MSH" {
+			n--
+		}
+		return fields[n]
+	}
+	t.Fatalf("no %s segment", name)
+	return ""
+}
+
+func TestObservationTime(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	e := testEncoder()
+	e.StartSession(start)
+
+	// Время наблюдения не зависит от момента кодирования: отложенное
+	// или воспроизведённое сообщение сохраняет своё время
+	for _, sec := range []float64{90.5, 1.25} {
+		message, err := e.Encode(websocket.MessageData{SecFromStart: sec, Data: websocket.SensorData{BPMChild: 140, Uterus: 10}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := start.Add(time.Duration(sec * float64(time.Second))).Format(timeLayout)
+		if got := field(t, message, "MSH", 7); got != want {
+			t.Errorf("MSH-7 at %vs = %s, want %s", sec, got, want)
+		}
+		if got := field(t, message, "OBX", 14); got != want {
+			t.Errorf("OBX-14 at %vs = %s, want %s", sec, got, want)
+		}
+	}
+
+	// Без начала сессии оно отсчитывается от первого сообщения
+	e = testEncoder()
+	now := e.now()
+	var times []string
+	for _, sec := range []float64{12.5, 14} {
+		message, err := e.Encode(websocket.MessageData{SecFromStart: sec, Data: websocket.SensorData{BPMChild: 140, Uterus: 10}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		times = append(times, field(t, message, "MSH", 7))
+	}
+	if want := []string{now.Format(timeLayout), now.Add(1500 * time.Millisecond).Format(timeLayout)}; !reflect.DeepEqual(times, want) {
+		t.Errorf("times = %v, want %v", times, want)
+	}
+}
+
+func TestClientRedialsAfterFailedSend(t *testing.T) {
+	message, err := testEncoder().Encode(websocket.MessageData{Data: websocket.SensorData{BPMChild: 140, Uterus: 10}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := startListener(t)
+	l.replies <- "drop"
+	l.replies <- AckAccept
+	c := NewClient(ClientConfig{AckTimeout: time.Second})
+	if err := c.Connect("mllp://"+l.addr, ""); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer c.Disconnect()
+
+	// Повторов нет: отправка падает, но сессия продолжается
+	if err := c.SendMessage(message); err == nil {
+		t.Fatal("send to a dropped connection must fail")
+	}
+	if !c.IsConnected() {
+		t.Fatal("session must stay open after a failed send")
+	}
+	// Следующая отправка переоткрывает соединение
+	if err := c.SendMessage(message); err != nil {
+		t.Fatalf("send after drop: %v", err)
+	}
+	if len(l.received) != 2 {
+		t.Errorf("listener received %d messages, want 2", len(l.received))
+	}
+
+	if err := c.Disconnect(); err != nil {
+		t.Fatal(err)
+	}
+	if c.IsConnected() || c.SendMessage(message) == nil {
+		t.Error("send after disconnect must fail")
+	}
+}
@@ -0,0 +1,188 @@
+package hl7
+
+import (
+	"backend_gen/internal/ports/websocket"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// FormatHL7 имя формата в списке кодировщиков
+const FormatHL7 = "hl7"
+
+// Формат времени HL7 (DTM) с часовым поясом
+const timeLayout = "20060102150405.000-0700"
+
+// observation описание канала SensorData в сегменте OBX
+type observation struct {
+	// code идентификатор^текст^система кодирования (OBX-3)
+	code string
+	// units единица^текст^система (OBX-6)
+	units string
+	// subID номер плода или канала (OBX-4)
+	subID string
+	// normal референсный диапазон (OBX-7)
+	normal string
+}
+
+// Каналы: ЧСС плода и матери - LOINC, токограмма - локальные коды 99CTG
+var (
+	obsFetalHR     = observation{"55283-6^Fetal heart rate^LN", "/min^beats per minute^UCUM", "1", "110-160"}
+	obsFetalHR2    = observation{"55283-6^Fetal heart rate^LN", "/min^beats per minute^UCUM", "2", "110-160"}
+	obsMaternalHR  = observation{"8867-4^Heart rate^LN", "/min^beats per minute^UCUM", "", "60-100"}
+	obsUterine     = observation{"UA^Uterine activity^99CTG", "mm[Hg]^millimeter of mercury^UCUM", "", ""}
+	obsContraction = observation{"UC^Uterine contraction intensity^99CTG", "1^unitless^UCUM", "", ""}
+)
+
+// Config параметры сегментов MSH и PID
+type Config struct {
+	SendingApp        string
+	SendingFacility   string
+	ReceivingApp      string
+	ReceivingFacility string
+	// PatientID идентификатор пациентки (PID-3); "" = идентификатор датчика
+	PatientID string
+	SensorID  string
+}
+
+// encoder кодирует MessageData в ORU^R01 (HL7 v2.5.1). Каждое сообщение
+// получает свой control ID (MSH-10) для сопоставления с ACK
+type encoder struct {
+	cfg     Config
+	counter atomic.Uint64
+	now     func() time.Time
+
+	mu sync.Mutex
+	// start начало сессии: от него отсчитывается время наблюдения сообщений
+	start time.Time
+}
+
+// NewEncoder создает кодировщик ORU^R01
+func NewEncoder(cfg Config) websocket.Encoder {
+	if cfg.SendingApp == "" {
+		cfg.SendingApp = "CTG_GENERATOR"
+	}
+	if cfg.PatientID == "" {
+		cfg.PatientID = cfg.SensorID
+	}
+	e := &encoder{cfg: cfg, now: time.Now}
+	// Номера начинаются с момента запуска, чтобы не повторяться после перезапуска
+	e.counter.Store(uint64(time.Now().Unix()) * 1000)
+	return e
+}
+
+func (e *encoder) Name() string { return FormatHL7 }
+
+func (e *encoder) Binary() bool { return false }
+
+// StartSession задаёт начало сессии: MSH-7 и OBX-14 сообщения - начало
+// сессии плюс secFromStart, а не момент кодирования
+func (e *encoder) StartSession(start time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.start = start
+}
+
+// observedAt время наблюдения сообщения. Без StartSession начало сессии
+// отсчитывается от первого сообщения
+func (e *encoder) observedAt(secFromStart float64) time.Time {
+	offset := time.Duration(secFromStart * float64(time.Second))
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.start.IsZero() {
+		e.start = e.now().Add(-offset)
+	}
+	return e.start.Add(offset)
+}
+
+func (e *encoder) Encode(m websocket.MessageData) ([]byte, error) {
+	ts := e.observedAt(m.SecFromStart).Format(timeLayout)
+	controlID := strconv.FormatUint(e.counter.Add(1), 10)
+	sensorID := m.SensorID
+	if sensorID == "" {
+		sensorID = e.cfg.SensorID
+	}
+
+	var b strings.Builder
+	segment(&b, "MSH", `^~\&`, escape(e.cfg.SendingApp), escape(e.cfg.SendingFacility),
+		escape(e.cfg.ReceivingApp), escape(e.cfg.ReceivingFacility), ts, "",
+		"ORU^R01^ORU_R01", controlID, "P", "2.5.1")
+	segment(&b, "PID", "1", "", escape(e.cfg.PatientID)+"^^^"+escape(e.cfg.SendingFacility)+"^MR")
+	segment(&b, "OBR", "1", "", controlID, "CTG^Cardiotocography^99CTG", "", "", ts,
+		"", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "F")
+
+	set := 0
+	obx := func(obs observation, value float64) {
+		set++
+		status, formatted := "F", ""
+		if math.IsNaN(value) {
+			// Потеря сигнала: результат не получен (OBX-11 = X)
+			status = "X"
+		} else {
+			formatted = strconv.FormatFloat(value, 'f', 2, 64)
+		}
+		// OBX-14 время наблюдения, OBX-18 идентификатор датчика
+		segment(&b, "OBX", strconv.Itoa(set), "NM", obs.code, obs.subID, formatted, obs.units,
+			obs.normal, "", "", "", status, "", "", ts, "", "", "", escape(sensorID))
+	}
+	obx(obsFetalHR, m.Data.BPMChild)
+	if m.Data.BPMChild2 != nil {
+		obx(obsFetalHR2, *m.Data.BPMChild2)
+	}
+	if m.Data.BPMMother != nil {
+		obx(obsMaternalHR, *m.Data.BPMMother)
+	}
+	obx(obsUterine, m.Data.Uterus)
+	obx(obsContraction, m.Data.Spasms)
+
+	// Разметка и отметки - комментариями NTE
+	note := 0
+	for _, a := range m.Annotations {
+		note++
+		text := fmt.Sprintf("annotation %s %s start=%.1fs", a.Type, a.Kind, a.Start)
+		if a.End != nil {
+			text += fmt.Sprintf(" end=%.1fs", *a.End)
+		}
+		if a.Channel != "" {
+			text += " channel=" + a.Channel
+		}
+		segment(&b, "NTE", strconv.Itoa(note), "L", escape(text))
+	}
+	for _, mark := range m.Marks {
+		note++
+		text := fmt.Sprintf("mark %s %s at=%.1fs", mark.Type, mark.Source, mark.SecFromStart)
+		if mark.Note != "" {
+			text += " " + mark.Note
+		}
+		segment(&b, "NTE", strconv.Itoa(note), "L", escape(text))
+	}
+	return []byte(b.String()), nil
+}
+
+// segment дописывает сегмент с разделителем | и завершающим \r.
+// У MSH поле MSH-1 - сам разделитель, поэтому fields начинаются с MSH-2
+func segment(b *strings.Builder, name string, fields ...string) {
+	b.WriteString(name)
+	for _, f := range fields {
+		b.WriteByte('|')
+		b.WriteString(f)
+	}
+	b.WriteByte('\r')
+}
+
+// escape экранирует служебные символы HL7 в тексте
+func escape(s string) string {
+	return strings.NewReplacer(
+		`\`, `\E\`,
+		"|", `\F\`,
+		"^", `\S\`,
+		"&", `\T\`,
+		"~", `\R\`,
+		"\r", " ",
+		"\n", " ",
+	).Replace(s)
+}
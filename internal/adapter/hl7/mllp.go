@@ -0,0 +1,186 @@
+package hl7
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Байты обрамления MLLP: начало блока, конец блока и возврат каретки
+const (
+	mllpStart = 0x0b
+	mllpEnd   = 0x1c
+	mllpCR    = 0x0d
+)
+
+// Максимальный размер сообщения HL7, который принимает транспорт
+const maxMessageSize = 1 << 20
+
+// Коды подтверждения MSA-1
+const (
+	AckAccept      = "AA"
+	AckError       = "AE"
+	AckReject      = "AR"
+	AckCommitAcc   = "CA"
+	AckCommitError = "CE"
+	AckCommitRej   = "CR"
+)
+
+// WriteFrame пишет сообщение в обрамлении MLLP
+func WriteFrame(w io.Writer, message []byte) error {
+	buf := make([]byte, 0, len(message)+3)
+	buf = append(buf, mllpStart)
+	buf = append(buf, message...)
+	buf = append(buf, mllpEnd, mllpCR)
+	_, err := w.Write(buf)
+	return err
+}
+
+// ReadFrame читает одно сообщение MLLP; байты до начала блока пропускаются
+func ReadFrame(r *bufio.Reader) ([]byte, error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if b == mllpStart {
+			break
+		}
+	}
+
+	var message []byte
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		if b == mllpEnd {
+			next, err := r.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			if next != mllpCR {
+				return nil, fmt.Errorf("mllp: expected CR after end of block, got 0x%02x", next)
+			}
+			return message, nil
+		}
+		if len(message) >= maxMessageSize {
+			return nil, fmt.Errorf("mllp: message is too large")
+		}
+		message = append(message, b)
+	}
+}
+
+// Header поля MSH, нужные для подтверждения
+type Header struct {
+	SendingApp        string
+	SendingFacility   string
+	ReceivingApp      string
+	ReceivingFacility string
+	MessageType       string
+	ControlID         string
+	Version           string
+}
+
+// ParseHeader разбирает сегмент MSH. Поля нумеруются как в стандарте:
+// MSH-1 - разделитель, поэтому индекс поля на единицу больше индекса в split
+func ParseHeader(message []byte) (Header, error) {
+	segments := Segments(message)
+	if len(segments) == 0 || !strings.HasPrefix(segments[0], "MSH") || len(segments[0]) < 8 {
+		return Header{}, fmt.Errorf("hl7: message does not start with MSH")
+	}
+	fields := strings.Split(segments[0], string(segments[0][3]))
+	field := func(n int) string {
+		if n-1 < len(fields) {
+			return fields[n-1]
+		}
+		return ""
+	}
+	return Header{
+		SendingApp:        field(3),
+		SendingFacility:   field(4),
+		ReceivingApp:      field(5),
+		ReceivingFacility: field(6),
+		MessageType:       field(9),
+		ControlID:         field(10),
+		Version:           field(12),
+	}, nil
+}
+
+// Segments делит сообщение на сегменты
+func Segments(message []byte) []string {
+	var segments []string
+	for _, s := range strings.Split(strings.ReplaceAll(string(message), "\n", "\r"), "\r") {
+		if s != "" {
+			segments = append(segments, s)
+		}
+	}
+	return segments
+}
+
+// Ack разобранное подтверждение
+type Ack struct {
+	Code      string // MSA-1
+	ControlID string // MSA-2: control ID подтверждаемого сообщения
+	Text      string // MSA-3 или ERR
+}
+
+// Accepted сообщает, что сообщение принято
+func (a Ack) Accepted() bool {
+	return a.Code == AckAccept || a.Code == AckCommitAcc
+}
+
+// ParseAck разбирает ACK: сегмент MSA и, если есть, ERR
+func ParseAck(message []byte) (Ack, error) {
+	var ack Ack
+	found := false
+	for _, s := range Segments(message) {
+		fields := strings.Split(s, "|")
+		switch fields[0] {
+		case "MSA":
+			found = true
+			if len(fields) > 1 {
+				ack.Code = fields[1]
+			}
+			if len(fields) > 2 {
+				ack.ControlID = fields[2]
+			}
+			if len(fields) > 3 {
+				ack.Text = fields[3]
+			}
+		case "ERR":
+			if ack.Text == "" && len(fields) > 1 {
+				ack.Text = strings.Trim(strings.Join(fields[1:], " "), " ")
+			}
+		}
+	}
+	if !found {
+		return ack, fmt.Errorf("hl7: ACK without MSA segment")
+	}
+	return ack, nil
+}
+
+// BuildAck собирает ACK на сообщение с заголовком h (отправитель и получатель меняются местами)
+func BuildAck(h Header, code string, text string, controlID string) []byte {
+	var b strings.Builder
+	version := h.Version
+	if version == "" {
+		version = "2.5.1"
+	}
+	trigger := ""
+	if parts := strings.Split(h.MessageType, "^"); len(parts) > 1 {
+		trigger = parts[1]
+	}
+	segment(&b, "MSH", `^~\&`, h.ReceivingApp, h.ReceivingFacility, h.SendingApp, h.SendingFacility,
+		time.Now().Format(timeLayout), "", "ACK^"+trigger+"^ACK", controlID, "P", version)
+	segment(&b, "MSA", code, h.ControlID, escape(text))
+	if code != AckAccept && code != AckCommitAcc && text != "" {
+		segment(&b, "ERR", "", "", "", "E", "", "", "", escape(text))
+	}
+	return []byte(b.String())
+}
@@ -0,0 +1,326 @@
+package httpbatch
+
+import (
+	"backend_gen/internal/adapter/encoding"
+	"backend_gen/internal/ports/websocket"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Заголовки запроса с пачкой сообщений
+const (
+	HeaderSensorToken    = "X-Auth-Sensor-Token"
+	HeaderSensorID       = "X-Sensor-Id"
+	HeaderIdempotencyKey = "Idempotency-Key"
+)
+
+// Encodings форматы сообщений, которые переносит транспорт: пакет - JSON массив
+var Encodings = []string{encoding.FormatJSON}
+
+// Config параметры пакетной отправки
+type Config struct {
+	SensorID string
+	// Interval период отправки накопленных сообщений
+	Interval time.Duration
+	// MaxBatch сообщений в одном запросе; остаток уходит следующим запросом
+	MaxBatch int
+	// MaxBuffer сообщений в очереди; при переполнении новые сообщения отбрасываются
+	MaxBuffer int
+	// Retries повторов запроса при сетевой ошибке, 429 и 5xx
+	Retries      int
+	RetryBackoff time.Duration
+	Timeout      time.Duration
+	// FlushTimeout общий срок отправки остатка при остановке; что не ушло
+	// за это время, отбрасывается
+	FlushTimeout time.Duration
+}
+
+// entry сообщение с номером в сессии
+type entry struct {
+	sequence uint64
+	message  []byte
+}
+
+// client накапливает JSON сообщения и отправляет их массивом POST запросом.
+// Каждый элемент массива - сообщение с полем sequence, номером в потоке
+// датчика, по которому сервер отбрасывает повторно полученные сообщения.
+// Ключ идемпотентности <sensor_id>-<первый номер>-<последний номер> не меняется
+// между повторами пачки. Номера начинаются с момента подключения в
+// миллисекундах, чтобы не повторяться после перезапуска генератора
+type client struct {
+	cfg  Config
+	http *http.Client
+
+	mu       sync.Mutex
+	url      string
+	token    string
+	buffer   []entry
+	sequence uint64
+	stopCh   chan struct{}
+	done     chan struct{}
+}
+
+// NewClient создает транспорт пакетной отправки
+func NewClient(cfg Config) websocket.Client {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+	if cfg.MaxBatch <= 0 {
+		cfg.MaxBatch = 500
+	}
+	if cfg.MaxBuffer <= 0 {
+		cfg.MaxBuffer = 10000
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = 500 * time.Millisecond
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.FlushTimeout <= 0 {
+		cfg.FlushTimeout = cfg.Timeout
+	}
+	return &client{
+		cfg:      cfg,
+		http:     &http.Client{Timeout: cfg.Timeout},
+		sequence: uint64(time.Now().UnixMilli()),
+	}
+}
+
+// Connect запоминает адрес и запускает периодическую отправку.
+// Соединение не устанавливается: ошибки видны при отправке пачек
+func (c *client) Connect(rawURL string, token string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stopCh != nil {
+		return fmt.Errorf("already connected")
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid endpoint url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported endpoint url scheme %q", u.Scheme)
+	}
+
+	c.url = rawURL
+	c.token = token
+	c.buffer = nil
+	c.stopCh = make(chan struct{})
+	c.done = make(chan struct{})
+	go c.run(c.stopCh, c.done)
+
+	slog.Info("HTTP batch transport started", "url", rawURL, "interval", c.cfg.Interval.String())
+	return nil
+}
+
+// Disconnect останавливает отправку и отправляет оставшиеся сообщения,
+// но не дольше FlushTimeout
+func (c *client) Disconnect() error {
+	c.mu.Lock()
+	stopCh, done := c.stopCh, c.done
+	c.stopCh = nil
+	c.mu.Unlock()
+
+	if stopCh == nil {
+		return nil
+	}
+	close(stopCh)
+	<-done
+	slog.Info("HTTP batch transport stopped")
+	return nil
+}
+
+func (c *client) IsConnected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stopCh != nil
+}
+
+// SendMessage добавляет JSON объект в очередь
+func (c *client) SendMessage(message []byte) error {
+	message = bytes.TrimSpace(message)
+	if len(message) < 2 || message[0] != '{' {
+		return fmt.Errorf("http batch transport requires json object messages")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stopCh == nil {
+		return fmt.Errorf("not connected")
+	}
+	if len(c.buffer) >= c.cfg.MaxBuffer {
+		return fmt.Errorf("batch buffer is full (%d messages)", len(c.buffer))
+	}
+	c.sequence++
+	c.buffer = append(c.buffer, entry{sequence: c.sequence, message: message})
+	return nil
+}
+
+// SendBinary не поддерживается: пачка отправляется JSON массивом
+func (c *client) SendBinary(message []byte) error {
+	return fmt.Errorf("http batch transport requires json encoding")
+}
+
+// run отправляет пачки по таймеру; при остановке отправляет остаток
+func (c *client) run(stopCh, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(c.cfg.Interval)
+	defer ticker.Stop()
+
+	// Остановка прерывает текущую пачку: она вернётся в очередь и уйдёт
+	// при финальной отправке с тем же ключом
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stopCh
+		cancel()
+	}()
+
+	for {
+		select {
+		case <-ticker.C:
+			// Между тиками отправляем не больше одной пачки, остальное - в следующий раз
+			c.flushBatch(ctx)
+		case <-stopCh:
+			c.flushFinal()
+			return
+		}
+	}
+}
+
+// flushFinal отправляет остаток очереди за общий срок FlushTimeout и
+// отбрасывает то, что не успело уйти
+func (c *client) flushFinal() {
+	ctx, cancel := context.WithTimeout(context.Background(), c.cfg.FlushTimeout)
+	defer cancel()
+
+	for c.flushBatch(ctx) {
+	}
+
+	c.mu.Lock()
+	rest := c.buffer
+	c.buffer = nil
+	c.mu.Unlock()
+	if len(rest) > 0 {
+		slog.Warn("Flush timeout exceeded, undelivered messages dropped",
+			"messages", len(rest), "first", rest[0].sequence, "last", rest[len(rest)-1].sequence)
+	}
+}
+
+// flushBatch отправляет одну пачку до MaxBatch сообщений. Пачка, которая не
+// ушла после всех повторов, отбрасывается, чтобы очередь не росла бесконечно;
+// прерванная через ctx - возвращается в начало очереди. Возвращает true,
+// если отправку имеет смысл продолжить
+func (c *client) flushBatch(ctx context.Context) bool {
+	c.mu.Lock()
+	n := min(len(c.buffer), c.cfg.MaxBatch)
+	batch := c.buffer[:n:n]
+	c.buffer = c.buffer[n:]
+	c.mu.Unlock()
+
+	if n == 0 {
+		return false
+	}
+	err := c.post(ctx, batch)
+	if err != nil && ctx.Err() != nil {
+		c.mu.Lock()
+		c.buffer = append(batch, c.buffer...)
+		c.mu.Unlock()
+		return false
+	}
+	if err != nil {
+		slog.Error("Failed to deliver message batch",
+			"first", batch[0].sequence, "last", batch[n-1].sequence, "error", err)
+	}
+	return true
+}
+
+// encodeBatch собирает JSON массив, добавляя в каждое сообщение его номер
+func encodeBatch(batch []entry) []byte {
+	var body bytes.Buffer
+	body.WriteByte('[')
+	for i, e := range batch {
+		if i > 0 {
+			body.WriteByte(',')
+		}
+		fmt.Fprintf(&body, `{"sequence":%d`, e.sequence)
+		if rest := bytes.TrimSpace(e.message[1:]); len(rest) > 0 && rest[0] != '}' {
+			body.WriteByte(',')
+		}
+		body.Write(e.message[1:])
+	}
+	body.WriteByte(']')
+	return body.Bytes()
+}
+
+// post отправляет пачку с повторами и экспоненциальной задержкой
+func (c *client) post(ctx context.Context, batch []entry) error {
+	body := encodeBatch(batch)
+	key := fmt.Sprintf("%s-%d-%d", c.cfg.SensorID, batch[0].sequence, batch[len(batch)-1].sequence)
+
+	backoff := c.cfg.RetryBackoff
+	var err error
+	for attempt := 0; attempt <= c.cfg.Retries; attempt++ {
+		if attempt > 0 {
+			slog.Warn("Retrying message batch", "key", key, "attempt", attempt, "error", err)
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return errors.Join(err, ctx.Err())
+			}
+			backoff *= 2
+		}
+
+		var retry bool
+		retry, err = c.send(ctx, key, body)
+		if err == nil {
+			slog.Debug("Message batch delivered", "key", key, "messages", len(batch))
+			return nil
+		}
+		if !retry {
+			return err
+		}
+	}
+	return err
+}
+
+// send выполняет один запрос; retry означает, что ошибку имеет смысл повторить
+func (c *client) send(ctx context.Context, key string, body []byte) (retry bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderSensorID, c.cfg.SensorID)
+	req.Header.Set(HeaderIdempotencyKey, key)
+	if c.token != "" {
+		req.Header.Set(HeaderSensorToken, c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("unexpected HTTP status %s", resp.Status)
+	}
+	return false, fmt.Errorf("batch rejected with HTTP status %s", resp.Status)
+}
@@ -0,0 +1,202 @@
+package httpbatch
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// request пачка, полученная тестовым сервером
+type request struct {
+	key   string
+	items []map[string]any
+}
+
+// batchServer принимает пачки; status задаёт ответ на n-й запрос (с нуля)
+type batchServer struct {
+	mu       sync.Mutex
+	requests []request
+	status   func(n int) int
+}
+
+func (s *batchServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	var items []map[string]any
+	if err := json.Unmarshal(body, &items); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if r.Header.Get(HeaderSensorID) != "s1" || r.Header.Get(HeaderSensorToken) != "token" {
+		http.Error(w, "bad headers", http.StatusUnauthorized)
+		return
+	}
+
+	s.mu.Lock()
+	n := len(s.requests)
+	s.requests = append(s.requests, request{key: r.Header.Get(HeaderIdempotencyKey), items: items})
+	s.mu.Unlock()
+
+	status := http.StatusOK
+	if s.status != nil {
+		status = s.status(n)
+	}
+	w.WriteHeader(status)
+}
+
+func (s *batchServer) Requests() []request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]request(nil), s.requests...)
+}
+
+func newTestClient(t *testing.T, url string, cfg Config) *client {
+	t.Helper()
+	cfg.SensorID = "s1"
+	c := NewClient(cfg).(*client)
+	if err := c.Connect(url, "token"); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func sendN(t *testing.T, c *client, n int) {
+	t.Helper()
+	for i := range n {
+		if err := c.SendMessage([]byte(fmt.Sprintf(`{"value":%d}`, i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// sequence номер элемента пачки
+func sequence(item map[string]any) uint64 {
+	return uint64(item["sequence"].(float64))
+}
+
+func TestBatching(t *testing.T) {
+	server := &batchServer{}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	// Интервал больше времени теста: всё уходит при остановке пачками по MaxBatch
+	c := newTestClient(t, ts.URL, Config{Interval: time.Hour, MaxBatch: 2})
+	sendN(t, c, 5)
+	if err := c.Disconnect(); err != nil {
+		t.Fatal(err)
+	}
+
+	requests := server.Requests()
+	if len(requests) != 3 {
+		t.Fatalf("requests = %d, want 3", len(requests))
+	}
+	first := sequence(requests[0].items[0])
+	value := 0
+	for i, r := range requests {
+		// Ключ пачки строится из номеров первого и последнего элемента
+		want := fmt.Sprintf("s1-%d-%d", sequence(r.items[0]), sequence(r.items[len(r.items)-1]))
+		if r.key != want {
+			t.Errorf("request %d key = %q, want %q", i, r.key, want)
+		}
+		for _, item := range r.items {
+			if got := sequence(item); got != first+uint64(value) {
+				t.Errorf("item %d sequence = %d, want %d", value, got, first+uint64(value))
+			}
+			if item["value"] != float64(value) {
+				t.Errorf("item %d = %v", value, item)
+			}
+			value++
+		}
+	}
+	if value != 5 {
+		t.Errorf("delivered %d messages, want 5", value)
+	}
+}
+
+func TestEncodeBatch(t *testing.T) {
+	got := string(encodeBatch([]entry{{7, []byte(`{}`)}, {8, []byte(`{"a":1}`)}}))
+	if want := `[{"sequence":7},{"sequence":8,"a":1}]`; got != want {
+		t.Errorf("batch = %s, want %s", got, want)
+	}
+}
+
+func TestRetriesKeepIdempotencyKey(t *testing.T) {
+	server := &batchServer{status: func(n int) int {
+		if n < 2 {
+			return http.StatusServiceUnavailable
+		}
+		return http.StatusOK
+	}}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	c := newTestClient(t, ts.URL, Config{Interval: time.Hour, Retries: 3, RetryBackoff: time.Millisecond})
+	sendN(t, c, 3)
+	if err := c.Disconnect(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Повторы несут ту же пачку с тем же ключом
+	requests := server.Requests()
+	if len(requests) != 3 {
+		t.Fatalf("requests = %d, want 3", len(requests))
+	}
+	for _, r := range requests[1:] {
+		if r.key != requests[0].key || len(r.items) != 3 || sequence(r.items[0]) != sequence(requests[0].items[0]) {
+			t.Errorf("retry %q %v differs from %q %v", r.key, r.items, requests[0].key, requests[0].items)
+		}
+	}
+}
+
+func TestRejectedBatchIsNotRetried(t *testing.T) {
+	server := &batchServer{status: func(int) int { return http.StatusUnprocessableEntity }}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	c := newTestClient(t, ts.URL, Config{Interval: time.Hour, Retries: 3, RetryBackoff: time.Millisecond})
+	sendN(t, c, 1)
+	if err := c.Disconnect(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(server.Requests()); n != 1 {
+		t.Errorf("requests = %d, want 1", n)
+	}
+}
+
+func TestFinalFlushDeadline(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer ts.Close()
+	defer close(release)
+
+	c := newTestClient(t, ts.URL, Config{
+		Interval:     time.Hour,
+		MaxBatch:     1,
+		Retries:      3,
+		RetryBackoff: time.Second,
+		Timeout:      10 * time.Second,
+		FlushTimeout: 100 * time.Millisecond,
+	})
+	sendN(t, c, 20)
+
+	// Бэкенд не отвечает: остановка ограничена FlushTimeout, остаток отброшен
+	start := time.Now()
+	if err := c.Disconnect(); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("disconnect took %v", elapsed)
+	}
+	if n := len(c.buffer); n != 0 {
+		t.Errorf("%d messages left in the buffer", n)
+	}
+}
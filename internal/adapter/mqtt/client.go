@@ -0,0 +1,207 @@
+package mqtt
+
+import (
+	"backend_gen/internal/ports/websocket"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+// Время ожидания подключения к брокеру и отправки статуса при отключении
+const (
+	connectTimeout    = 10 * time.Second
+	disconnectTimeout = 2 * time.Second
+)
+
+// Статусы, публикуемые в топик статуса датчика
+const (
+	statusOnline  = "online"
+	statusOffline = "offline"
+)
+
+// Config параметры публикации в MQTT
+type Config struct {
+	SensorID string
+	// ClientID идентификатор клиента MQTT ("" = ctg-generator-<sensor_id>)
+	ClientID string
+	// Topic топик данных; {sensor_id} заменяется идентификатором датчика
+	Topic string
+	// StatusTopic топик статуса online/offline ("" = не публиковать)
+	StatusTopic string
+	// QoS уровень гарантии доставки данных и статуса: 0, 1 или 2
+	QoS byte
+	// RetainStatus сохраняет статус на брокере для новых подписчиков
+	RetainStatus bool
+	KeepAlive    time.Duration
+}
+
+// client публикует сообщения генератора в MQTT брокер (протокол 3.1.1) через paho.
+// Реализует порт websocket.Client, поэтому подключается вместо WebSocket клиента.
+// Сессия не очищается при переподключении: неподтверждённые публикации QoS 1/2
+// хранятся в клиенте и отправляются повторно после восстановления соединения
+type client struct {
+	cfg         Config
+	topic       string
+	statusTopic string
+
+	mu   sync.Mutex
+	conn paho.Client
+}
+
+// NewClient создает MQTT транспорт
+func NewClient(cfg Config) websocket.Client {
+	if cfg.ClientID == "" {
+		cfg.ClientID = "ctg-generator-" + cfg.SensorID
+	}
+	if cfg.KeepAlive <= 0 {
+		cfg.KeepAlive = 30 * time.Second
+	}
+	return &client{
+		cfg:         cfg,
+		topic:       strings.ReplaceAll(cfg.Topic, "{sensor_id}", cfg.SensorID),
+		statusTopic: strings.ReplaceAll(cfg.StatusTopic, "{sensor_id}", cfg.SensorID),
+	}
+}
+
+// Connect подключается к брокеру по адресу вида tcp://host:port.
+// Токен датчика передаётся паролем, идентификатор датчика - именем пользователя
+func (c *client) Connect(rawURL string, token string) error {
+	c.mu.Lock()
+	connected := c.conn != nil
+	c.mu.Unlock()
+	if connected {
+		return fmt.Errorf("already connected")
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid broker url: %w", err)
+	}
+	if u.Scheme != "tcp" && u.Scheme != "mqtt" {
+		return fmt.Errorf("unsupported broker url scheme %q", u.Scheme)
+	}
+
+	opts := paho.NewClientOptions().
+		AddBroker("tcp://" + u.Host).
+		SetClientID(c.cfg.ClientID).
+		SetCleanSession(false).
+		SetAutoReconnect(true).
+		SetMaxReconnectInterval(30 * time.Second).
+		SetKeepAlive(c.cfg.KeepAlive).
+		SetConnectTimeout(connectTimeout).
+		SetOrderMatters(false).
+		SetOnConnectHandler(c.onConnect).
+		SetConnectionLostHandler(func(_ paho.Client, err error) {
+			slog.Error("MQTT connection lost, reconnecting", "error", err)
+		})
+	if c.cfg.SensorID != "" {
+		opts.SetUsername(c.cfg.SensorID)
+		opts.SetPassword(token)
+	}
+	if c.statusTopic != "" {
+		// Завещание: брокер опубликует offline при обрыве соединения
+		opts.SetBinaryWill(c.statusTopic, c.statusPayload(statusOffline), c.cfg.QoS, c.cfg.RetainStatus)
+	}
+
+	slog.Info("Connecting to MQTT broker", "addr", u.Host, "client_id", c.cfg.ClientID)
+	conn := paho.NewClient(opts)
+	t := conn.Connect()
+	if !t.WaitTimeout(connectTimeout) {
+		conn.Disconnect(0)
+		return fmt.Errorf("timed out connecting to MQTT broker")
+	}
+	if err := t.Error(); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.conn = conn
+	c.mu.Unlock()
+	slog.Info("MQTT connection established", "addr", u.Host, "topic", c.topic, "qos", c.cfg.QoS)
+	return nil
+}
+
+// onConnect публикует статус online после каждого (пере)подключения:
+// после обрыва брокер уже разослал завещание offline
+func (c *client) onConnect(conn paho.Client) {
+	if c.statusTopic == "" {
+		return
+	}
+	t := conn.Publish(c.statusTopic, c.cfg.QoS, c.cfg.RetainStatus, c.statusPayload(statusOnline))
+	go func() {
+		if t.Wait(); t.Error() != nil {
+			slog.Error("Failed to publish MQTT status", "error", t.Error())
+		}
+	}()
+}
+
+func (c *client) Disconnect() error {
+	c.mu.Lock()
+	conn := c.conn
+	c.conn = nil
+	c.mu.Unlock()
+
+	if conn == nil {
+		return nil
+	}
+	// Штатное отключение: статус offline публикуем сами, завещание брокер отбросит
+	if c.statusTopic != "" && conn.IsConnectionOpen() {
+		t := conn.Publish(c.statusTopic, c.cfg.QoS, c.cfg.RetainStatus, c.statusPayload(statusOffline))
+		if !t.WaitTimeout(disconnectTimeout) || t.Error() != nil {
+			slog.Error("Failed to publish MQTT status", "error", t.Error())
+		}
+	}
+	conn.Disconnect(uint(disconnectTimeout / time.Millisecond))
+	slog.Info("MQTT disconnected")
+	return nil
+}
+
+// IsConnected true и во время автоматического переподключения: публикации QoS 1/2
+// в этот период сохраняются и уходят брокеру после восстановления соединения
+func (c *client) IsConnected() bool {
+	c.mu.Lock()
+	conn := c.conn
+	c.mu.Unlock()
+	return conn != nil && conn.IsConnected()
+}
+
+// SendMessage публикует сообщение в топик данных. Подтверждение брокера не
+// ожидается: повторную отправку при потере соединения выполняет paho
+func (c *client) SendMessage(message []byte) error {
+	c.mu.Lock()
+	conn := c.conn
+	c.mu.Unlock()
+	if conn == nil {
+		return fmt.Errorf("not connected")
+	}
+
+	t := conn.Publish(c.topic, c.cfg.QoS, false, message)
+	select {
+	case <-t.Done():
+		if err := t.Error(); err != nil {
+			slog.Error("Failed to publish MQTT message", "topic", c.topic, "error", err)
+			return err
+		}
+	default:
+	}
+	return nil
+}
+
+// SendBinary публикует бинарное сообщение: для MQTT payload не отличается от текстового
+func (c *client) SendBinary(message []byte) error {
+	return c.SendMessage(message)
+}
+
+func (c *client) statusPayload(status string) []byte {
+	payload, _ := json.Marshal(struct {
+		SensorID string `json:"sensorID"`
+		Status   string `json:"status"`
+	}{c.cfg.SensorID, status})
+	return payload
+}
@@ -0,0 +1,246 @@
+package mqtt
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"backend_gen/cmd/mqtt_broker/broker"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+const waitTimeout = 5 * time.Second
+
+// subscribers счётчик для уникальных client id подписчиков
+var subscribers atomic.Int32
+
+// startBroker запускает тестовый брокер cmd/mqtt_broker на свободном порту
+func startBroker(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	b := broker.NewBroker()
+	go b.Serve(ln)
+	t.Cleanup(func() { b.Close() })
+	return ln.Addr().String()
+}
+
+// subscribe подписывает отдельного paho клиента на фильтр
+func subscribe(t *testing.T, addr, filter string) <-chan paho.Message {
+	t.Helper()
+	messages := make(chan paho.Message, 64)
+	opts := paho.NewClientOptions().AddBroker("tcp://" + addr).SetClientID(fmt.Sprintf("sub-%d", subscribers.Add(1)))
+	sub := paho.NewClient(opts)
+	if tok := sub.Connect(); !tok.WaitTimeout(waitTimeout) || tok.Error() != nil {
+		t.Fatalf("subscriber connect: %v", tok.Error())
+	}
+	t.Cleanup(func() { sub.Disconnect(0) })
+	tok := sub.Subscribe(filter, 1, func(_ paho.Client, m paho.Message) { messages <- m })
+	if !tok.WaitTimeout(waitTimeout) || tok.Error() != nil {
+		t.Fatalf("subscribe: %v", tok.Error())
+	}
+	return messages
+}
+
+// expect ждёт сообщение с заданным payload
+func expect(t *testing.T, messages <-chan paho.Message, topic, payload string) paho.Message {
+	t.Helper()
+	select {
+	case m := <-messages:
+		if m.Topic() != topic || string(m.Payload()) != payload {
+			t.Fatalf("got %s %q, want %s %q", m.Topic(), m.Payload(), topic, payload)
+		}
+		return m
+	case <-time.After(waitTimeout):
+		t.Fatalf("timed out waiting for %s %q", topic, payload)
+	}
+	return nil
+}
+
+// expectNone проверяет, что лишних сообщений нет
+func expectNone(t *testing.T, messages <-chan paho.Message) {
+	t.Helper()
+	select {
+	case m := <-messages:
+		t.Fatalf("unexpected message %s %q", m.Topic(), m.Payload())
+	case <-time.After(300 * time.Millisecond):
+	}
+}
+
+func connect(t *testing.T, addr string, cfg Config) *client {
+	t.Helper()
+	c := NewClient(cfg).(*client)
+	if err := c.Connect("tcp://"+addr, "token"); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { c.Disconnect() })
+	return c
+}
+
+func TestPublishQoS(t *testing.T) {
+	for _, qos := range []byte{0, 1, 2} {
+		t.Run(string('0'+qos), func(t *testing.T) {
+			addr := startBroker(t)
+			messages := subscribe(t, addr, "ctg/+/data")
+			c := connect(t, addr, Config{SensorID: "s1", Topic: "ctg/{sensor_id}/data", QoS: qos})
+
+			if !c.IsConnected() {
+				t.Fatal("client is not connected")
+			}
+			for _, payload := range []string{"first", "second"} {
+				if err := c.SendMessage([]byte(payload)); err != nil {
+					t.Fatalf("send: %v", err)
+				}
+				expect(t, messages, "ctg/s1/data", payload)
+			}
+			expectNone(t, messages)
+		})
+	}
+}
+
+func TestRetainedStatus(t *testing.T) {
+	addr := startBroker(t)
+	c := connect(t, addr, Config{SensorID: "s1", Topic: "d", StatusTopic: "ctg/{sensor_id}/status", QoS: 1, RetainStatus: true})
+
+	online := `{"sensorID":"s1","status":"online"}`
+	offline := `{"sensorID":"s1","status":"offline"}`
+	messages := subscribe(t, addr, "ctg/s1/status")
+	if m := expect(t, messages, "ctg/s1/status", online); !m.Retained() {
+		t.Error("status delivered on subscribe must be retained")
+	}
+
+	if err := c.Disconnect(); err != nil {
+		t.Fatalf("disconnect: %v", err)
+	}
+	if c.IsConnected() {
+		t.Error("client is connected after Disconnect")
+	}
+	expect(t, messages, "ctg/s1/status", offline)
+	// Штатное отключение: завещание не публикуется
+	expectNone(t, messages)
+
+	late := subscribe(t, addr, "ctg/+/status")
+	expect(t, late, "ctg/s1/status", offline)
+}
+
+// proxy TCP прокси между клиентом и брокером для имитации обрыва соединения
+type proxy struct {
+	ln     net.Listener
+	target string
+	// drop отбрасывает данные от клиента к брокеру
+	drop atomic.Bool
+
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func startProxy(t *testing.T, target string) *proxy {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	p := &proxy{ln: ln, target: target}
+	go p.serve()
+	t.Cleanup(func() {
+		ln.Close()
+		p.cut()
+	})
+	return p
+}
+
+func (p *proxy) serve() {
+	for {
+		in, err := p.ln.Accept()
+		if err != nil {
+			return
+		}
+		out, err := net.Dial("tcp", p.target)
+		if err != nil {
+			in.Close()
+			continue
+		}
+		p.mu.Lock()
+		p.conns = append(p.conns, in, out)
+		p.mu.Unlock()
+
+		go io.Copy(in, out)
+		go func() {
+			buf := make([]byte, 4096)
+			for {
+				n, err := in.Read(buf)
+				if err != nil {
+					out.Close()
+					return
+				}
+				if !p.drop.Load() {
+					out.Write(buf[:n])
+				}
+			}
+		}()
+	}
+}
+
+// cut разрывает все соединения и снова пропускает трафик
+func (p *proxy) cut() {
+	p.mu.Lock()
+	for _, c := range p.conns {
+		c.Close()
+	}
+	p.conns = nil
+	p.mu.Unlock()
+	p.drop.Store(false)
+}
+
+func (p *proxy) addr() string { return p.ln.Addr().String() }
+
+func TestRetransmitAfterReconnect(t *testing.T) {
+	for _, qos := range []byte{1, 2} {
+		t.Run(string('0'+qos), func(t *testing.T) {
+			addr := startBroker(t)
+			messages := subscribe(t, addr, "data")
+			p := startProxy(t, addr)
+			c := connect(t, p.addr(), Config{SensorID: "s1", Topic: "data", QoS: qos})
+
+			// Публикация уходит в обрыв и не доходит до брокера
+			p.drop.Store(true)
+			if err := c.SendMessage([]byte("lost")); err != nil {
+				t.Fatalf("send: %v", err)
+			}
+			time.Sleep(200 * time.Millisecond)
+			expectNone(t, messages)
+
+			p.cut()
+			// paho переподключается и повторяет неподтверждённую публикацию
+			expect(t, messages, "data", "lost")
+			if !c.IsConnected() {
+				t.Error("client must stay connected while reconnecting")
+			}
+			if err := c.SendMessage([]byte("next")); err != nil {
+				t.Fatalf("send: %v", err)
+			}
+			expect(t, messages, "data", "next")
+			expectNone(t, messages)
+		})
+	}
+}
+
+func TestWillOnConnectionLoss(t *testing.T) {
+	addr := startBroker(t)
+	messages := subscribe(t, addr, "status")
+	p := startProxy(t, addr)
+	connect(t, p.addr(), Config{SensorID: "s1", Topic: "data", StatusTopic: "status", QoS: 1})
+
+	online := `{"sensorID":"s1","status":"online"}`
+	expect(t, messages, "status", online)
+	p.cut()
+	expect(t, messages, "status", `{"sensorID":"s1","status":"offline"}`)
+	expect(t, messages, "status", online)
+}
@@ -78,6 +78,20 @@ func (c *client) SendMessage(message []byte) error {
 	return nil
 }
 
+func (c *client) SendBinary(message []byte) error {
+	if c.conn == nil {
+		return fmt.Errorf("not connected")
+	}
+
+	slog.Debug("Sending binary WebSocket message", "bytes", len(message))
+	err := c.conn.WriteMessage(gorillaWS.BinaryMessage, message)
+	if err != nil {
+		slog.Error("Failed to send binary WebSocket message", "error", err)
+		return err
+	}
+	return nil
+}
+
 func NewClient() websocket.Client {
 	return &client{}
 }
@@ -0,0 +1,375 @@
+package websocket
+
+import (
+	"backend_gen/internal/ports/websocket"
+	"errors"
+	"log/slog"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Размер очереди отложенной доставки: при переполнении сообщения теряются
+const faultQueueSize = 1024
+
+var errNotConnected = errors.New("not connected")
+
+// frame сообщение вместе с типом WebSocket фрейма
+type frame struct {
+	message []byte
+	binary  bool
+}
+
+// delivery фрейм, ожидающий отправки в момент at
+type delivery struct {
+	at time.Time
+	frame
+}
+
+// innerClient текущее соединение сессии
+type innerClient struct {
+	websocket.Client
+}
+
+// faultClient оборачивает websocket.Client и имитирует плохую сеть:
+// задержки и джиттер, потери, дубли, перестановки и принудительные разрывы.
+// Каждое подключение выполняется новым клиентом newInner вне блокировок,
+// поэтому медленный бэкенд не останавливает отправку и API
+type faultClient struct {
+	newInner func() websocket.Client
+	defaults websocket.FaultConfig
+
+	mu  sync.Mutex
+	cfg websocket.FaultConfig
+	rng *rand.Rand
+
+	// Состояние сессии (между Connect и Disconnect)
+	session     bool
+	generation  int
+	url         string
+	token       string
+	connectedAt time.Time
+
+	lastDeliverAt time.Time
+	held          *frame // сообщение, задержанное для перестановки
+	queue         chan delivery
+	// pending фреймы сессии в очереди доставки, ещё не отправленные. Пока
+	// очередь не пуста, сообщения идут через неё даже с выключенными сбоями,
+	// чтобы не обгонять задержанные
+	pending *atomic.Int64
+	done    chan struct{}
+
+	// inner текущее соединение; nil во время разрыва. Меняется под mu,
+	// а читается при отправке без mu
+	inner atomic.Pointer[innerClient]
+	// sendMu упорядочивает запись в inner: gorilla/websocket не допускает
+	// конкурентную запись. Сетевая запись никогда не выполняется под mu
+	sendMu sync.Mutex
+}
+
+// NewFaultClient создает клиент с инъекцией сбоев над клиентами newInner.
+// defaults применяются к каждой новой сессии
+func NewFaultClient(newInner func() websocket.Client, defaults websocket.FaultConfig) websocket.Client {
+	return &faultClient{
+		newInner: newInner,
+		defaults: defaults,
+		cfg:      defaults,
+		rng:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+func (c *faultClient) Connect(url string, token string) error {
+	inner := c.newInner()
+	if err := inner.Connect(url, token); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.session {
+		if old := c.endSession(); old != nil {
+			go old.Disconnect()
+		}
+	}
+	c.setInner(inner)
+
+	c.session = true
+	c.generation++
+	c.url = url
+	c.token = token
+	c.connectedAt = time.Now()
+	c.lastDeliverAt = time.Time{}
+	c.held = nil
+	c.queue = make(chan delivery, faultQueueSize)
+	c.pending = new(atomic.Int64)
+	c.done = make(chan struct{})
+	go c.deliver(c.queue, c.pending, c.done)
+
+	if c.cfg.Enabled {
+		slog.Info("Network fault injection active", "faults", c.cfg)
+	}
+	return nil
+}
+
+func (c *faultClient) Disconnect() error {
+	c.mu.Lock()
+	inner := c.endSession()
+	// Параметры, изменённые через API, действуют до конца сессии
+	c.cfg = c.defaults
+	c.mu.Unlock()
+
+	if inner == nil {
+		return nil
+	}
+	return inner.Disconnect()
+}
+
+// endSession завершает сессию и отсоединяет клиент, который вызывающий должен
+// закрыть вне блокировок. Вызывается под mu
+func (c *faultClient) endSession() websocket.Client {
+	if c.session {
+		close(c.done)
+	}
+	c.session = false
+	c.generation++
+	c.held = nil
+	return c.setInner(nil)
+}
+
+// setInner заменяет клиент и возвращает прежний. Вызывается под mu; не ждёт
+// идущую запись, поэтому медленный бэкенд не задерживает разрыв и API
+func (c *faultClient) setInner(inner websocket.Client) websocket.Client {
+	var next *innerClient
+	if inner != nil {
+		next = &innerClient{inner}
+	}
+	if old := c.inner.Swap(next); old != nil {
+		return old.Client
+	}
+	return nil
+}
+
+// IsConnected сообщает об активной сессии: во время имитируемого разрыва
+// сессия продолжается, а сообщения теряются
+func (c *faultClient) IsConnected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.session
+}
+
+func (c *faultClient) SendMessage(message []byte) error {
+	return c.send(frame{message: message})
+}
+
+func (c *faultClient) SendBinary(message []byte) error {
+	return c.send(frame{message: message, binary: true})
+}
+
+// send отправляет фрейм сразу или через имитацию плохой сети. Прямая
+// отправка выполняется вне mu
+func (c *faultClient) send(f frame) error {
+	c.mu.Lock()
+	if !c.session {
+		c.mu.Unlock()
+		return errNotConnected
+	}
+	if !c.cfg.Enabled {
+		if c.pending.Load() > 0 {
+			// Сбои выключены, но задержанные фреймы ещё не доставлены: новый
+			// уходит за ними без дополнительной задержки
+			c.enqueue(f, 0)
+			c.mu.Unlock()
+			return nil
+		}
+		c.mu.Unlock()
+		return c.sendInner(f)
+	}
+	defer c.mu.Unlock()
+
+	connected := c.inner.Load() != nil
+	if connected && c.shouldDisconnect() {
+		c.forceDisconnect()
+		connected = false
+	}
+	if !connected {
+		slog.Debug("Message lost: simulated network outage")
+		return nil
+	}
+
+	if c.rng.Float64() < c.cfg.DropRate {
+		slog.Debug("Message dropped by fault injection")
+		return nil
+	}
+
+	batch := []frame{f}
+	if c.rng.Float64() < c.cfg.DuplicateRate {
+		batch = append(batch, f)
+	}
+	if c.held != nil {
+		// Задержанное сообщение уходит после текущего
+		batch = append(batch, *c.held)
+		c.held = nil
+	} else if c.rng.Float64() < c.cfg.ReorderRate {
+		c.held = &f
+		return nil
+	}
+
+	for _, m := range batch {
+		c.enqueue(m, c.delay())
+	}
+	return nil
+}
+
+func (c *faultClient) Faults() websocket.FaultConfig {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cfg
+}
+
+func (c *faultClient) SetFaults(cfg websocket.FaultConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Задержанное для перестановки сообщение уходит за очередью; прямая
+	// отправка начнётся, когда очередь опустеет
+	if !cfg.Enabled && c.held != nil && c.session {
+		c.enqueue(*c.held, 0)
+	}
+	c.held = nil
+	c.cfg = cfg
+	// Расписание разрывов отсчитывается от момента изменения параметров
+	c.connectedAt = time.Now()
+	slog.Info("Network fault injection updated", "faults", cfg)
+}
+
+// delay задержка доставки по параметрам сбоев: латентность и джиттер
+func (c *faultClient) delay() time.Duration {
+	delay := c.cfg.Latency
+	if c.cfg.Jitter > 0 {
+		delay += time.Duration(c.rng.Int63n(int64(c.cfg.Jitter)))
+	}
+	return delay
+}
+
+// enqueue ставит сообщение в очередь доставки через delay. Момент доставки
+// не убывает, поэтому джиттер сам по себе не меняет порядок. Вызывается под mu
+func (c *faultClient) enqueue(f frame, delay time.Duration) {
+	at := time.Now().Add(delay)
+	if at.Before(c.lastDeliverAt) {
+		at = c.lastDeliverAt
+	}
+	c.lastDeliverAt = at
+
+	select {
+	case c.queue <- delivery{at: at, frame: f}:
+		c.pending.Add(1)
+	default:
+		slog.Warn("Fault injection queue is full, message dropped")
+	}
+}
+
+// deliver отправляет сообщения из очереди в назначенное время
+func (c *faultClient) deliver(queue chan delivery, pending *atomic.Int64, done chan struct{}) {
+	for {
+		select {
+		case <-done:
+			return
+		case d := <-queue:
+			if wait := time.Until(d.at); wait > 0 {
+				select {
+				case <-done:
+					return
+				case <-time.After(wait):
+				}
+			}
+
+			err := c.sendInner(d.frame)
+			pending.Add(-1)
+			switch {
+			case errors.Is(err, errNotConnected):
+				slog.Debug("Delayed message lost: simulated network outage")
+			case err != nil:
+				slog.Error("Failed to deliver delayed message", "error", err)
+			}
+		}
+	}
+}
+
+// sendInner отправляет фрейм через текущее соединение. Выполняется вне mu
+func (c *faultClient) sendInner(f frame) error {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	inner := c.inner.Load()
+	if inner == nil {
+		return errNotConnected
+	}
+	if f.binary {
+		return inner.SendBinary(f.message)
+	}
+	return inner.SendMessage(f.message)
+}
+
+// shouldDisconnect решает, пора ли разорвать соединение
+func (c *faultClient) shouldDisconnect() bool {
+	if c.cfg.DisconnectEvery > 0 && time.Since(c.connectedAt) >= c.cfg.DisconnectEvery {
+		return true
+	}
+	return c.rng.Float64() < c.cfg.DisconnectRate
+}
+
+// forceDisconnect обрывает соединение без close-фрейма и планирует переподключение.
+// Вызывается под mu; соединение закрывается в отдельной горутине
+func (c *faultClient) forceDisconnect() {
+	if inner := c.setInner(nil); inner != nil {
+		go func() {
+			if err := inner.Disconnect(); err != nil {
+				slog.Error("Error during forced disconnect", "error", err)
+			}
+		}()
+	}
+	c.connectedAt = time.Now()
+
+	slog.Warn("Simulated network disconnect", "reconnect_after", c.cfg.ReconnectAfter)
+	if c.cfg.ReconnectAfter > 0 {
+		generation := c.generation
+		time.AfterFunc(c.cfg.ReconnectAfter, func() { c.reconnect(generation) })
+	}
+}
+
+// reconnect восстанавливает соединение, если сессия всё ещё та же. Подключение
+// выполняется без блокировок; если за это время сессия сменилась, новое
+// соединение закрывается
+func (c *faultClient) reconnect(generation int) {
+	c.mu.Lock()
+	if !c.session || c.generation != generation {
+		c.mu.Unlock()
+		return
+	}
+	url, token := c.url, c.token
+	c.mu.Unlock()
+
+	inner := c.newInner()
+	err := inner.Connect(url, token)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Сессия сменилась или соединение уже восстановлено другим переподключением
+	if !c.session || c.generation != generation || c.inner.Load() != nil {
+		if err == nil {
+			go inner.Disconnect()
+		}
+		return
+	}
+	if err != nil {
+		slog.Error("Reconnect after simulated disconnect failed", "error", err)
+		if c.cfg.ReconnectAfter > 0 {
+			time.AfterFunc(c.cfg.ReconnectAfter, func() { c.reconnect(generation) })
+		}
+		return
+	}
+	c.setInner(inner)
+	c.connectedAt = time.Now()
+	slog.Info("Reconnected after simulated disconnect")
+}
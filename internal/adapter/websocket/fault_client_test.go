@@ -0,0 +1,259 @@
+package websocket
+
+import (
+	"backend_gen/internal/ports/websocket"
+	"math/rand"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingBackend общий журнал сообщений, полученных всеми соединениями
+type recordingBackend struct {
+	mu       sync.Mutex
+	received []string
+	connects int
+	// block, если задан, задерживает каждую запись до закрытия канала
+	block chan struct{}
+}
+
+func (b *recordingBackend) newClient() websocket.Client {
+	return &recordingClient{backend: b}
+}
+
+func (b *recordingBackend) Received() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return slices.Clone(b.received)
+}
+
+func (b *recordingBackend) Connects() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.connects
+}
+
+// recordingClient соединение с recordingBackend
+type recordingClient struct {
+	backend   *recordingBackend
+	connected bool
+}
+
+func (c *recordingClient) Connect(string, string) error {
+	c.backend.mu.Lock()
+	defer c.backend.mu.Unlock()
+	c.backend.connects++
+	c.connected = true
+	return nil
+}
+
+func (c *recordingClient) Disconnect() error         { return nil }
+func (c *recordingClient) IsConnected() bool         { return c.connected }
+func (c *recordingClient) SendBinary(m []byte) error { return c.SendMessage(m) }
+
+func (c *recordingClient) SendMessage(message []byte) error {
+	if c.backend.block != nil {
+		<-c.backend.block
+	}
+	c.backend.mu.Lock()
+	defer c.backend.mu.Unlock()
+	c.backend.received = append(c.backend.received, string(message))
+	return nil
+}
+
+func newTestFaultClient(t *testing.T, backend *recordingBackend, cfg websocket.FaultConfig) *faultClient {
+	t.Helper()
+	c := NewFaultClient(backend.newClient, cfg).(*faultClient)
+	c.rng = rand.New(rand.NewSource(1))
+	if err := c.Connect("ws://backend", "token"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = c.Disconnect() })
+	return c
+}
+
+func send(t *testing.T, c *faultClient, messages ...string) {
+	t.Helper()
+	for _, m := range messages {
+		if err := c.SendMessage([]byte(m)); err != nil {
+			t.Fatalf("send %q: %v", m, err)
+		}
+	}
+}
+
+// waitReceived ждёт, пока бэкенд получит n сообщений
+func waitReceived(t *testing.T, b *recordingBackend, n int) []string {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if got := b.Received(); len(got) >= n {
+			return got
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("received %q, want %d messages", b.Received(), n)
+	return nil
+}
+
+func TestFaultClientDirectSend(t *testing.T) {
+	backend := &recordingBackend{}
+	c := newTestFaultClient(t, backend, websocket.FaultConfig{})
+
+	// Без сбоев сообщения уходят сразу и по порядку
+	send(t, c, "1", "2", "3")
+	if got := backend.Received(); !slices.Equal(got, []string{"1", "2", "3"}) {
+		t.Errorf("received %q", got)
+	}
+}
+
+func TestFaultClientSlowBackendDoesNotBlockStatus(t *testing.T) {
+	backend := &recordingBackend{block: make(chan struct{})}
+	c := newTestFaultClient(t, backend, websocket.FaultConfig{})
+
+	sent := make(chan struct{})
+	go func() {
+		send(t, c, "1")
+		close(sent)
+	}()
+
+	// Запись висит на медленном бэкенде, но состояние и параметры доступны
+	status := make(chan bool)
+	go func() {
+		connected := c.IsConnected()
+		c.SetFaults(c.Faults())
+		status <- connected
+	}()
+	select {
+	case connected := <-status:
+		if !connected {
+			t.Error("session reported as disconnected")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("IsConnected blocked by a pending write")
+	}
+
+	close(backend.block)
+	<-sent
+}
+
+func TestFaultClientLatency(t *testing.T) {
+	backend := &recordingBackend{}
+	c := newTestFaultClient(t, backend, websocket.FaultConfig{Enabled: true, Latency: 100 * time.Millisecond})
+
+	start := time.Now()
+	send(t, c, "1", "2")
+	if got := backend.Received(); len(got) != 0 {
+		t.Fatalf("delivered before latency: %q", got)
+	}
+	got := waitReceived(t, backend, 2)
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("delivered after %v, want at least 100ms", elapsed)
+	}
+	if !slices.Equal(got, []string{"1", "2"}) {
+		t.Errorf("received %q", got)
+	}
+}
+
+func TestFaultClientDisableKeepsOrder(t *testing.T) {
+	backend := &recordingBackend{}
+	c := newTestFaultClient(t, backend, websocket.FaultConfig{Enabled: true, Latency: 100 * time.Millisecond})
+
+	send(t, c, "1", "2")
+	// Сбои выключены, пока фреймы в очереди: новые не обгоняют задержанные
+	c.SetFaults(websocket.FaultConfig{})
+	send(t, c, "3")
+	if got := waitReceived(t, backend, 3); !slices.Equal(got, []string{"1", "2", "3"}) {
+		t.Errorf("received %q", got)
+	}
+
+	// Когда очередь опустела, отправка снова прямая
+	send(t, c, "4")
+	if got := backend.Received(); !slices.Equal(got, []string{"1", "2", "3", "4"}) {
+		t.Errorf("received %q after the queue drained", got)
+	}
+}
+
+func TestFaultClientDropDuplicateReorder(t *testing.T) {
+	cases := []struct {
+		name string
+		cfg  websocket.FaultConfig
+		want []string
+	}{
+		{"drop", websocket.FaultConfig{DropRate: 1}, nil},
+		{"duplicate", websocket.FaultConfig{DuplicateRate: 1}, []string{"1", "1", "2", "2", "3", "3", "4", "4"}},
+		// Каждое нечётное сообщение задерживается и уходит после следующего
+		{"reorder", websocket.FaultConfig{ReorderRate: 1}, []string{"2", "1", "4", "3"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			backend := &recordingBackend{}
+			tc.cfg.Enabled = true
+			c := newTestFaultClient(t, backend, tc.cfg)
+
+			send(t, c, "1", "2", "3", "4")
+			if len(tc.want) > 0 {
+				waitReceived(t, backend, len(tc.want))
+			}
+			// Лишние сообщения успели бы прийти за это время
+			time.Sleep(50 * time.Millisecond)
+			got := backend.Received()
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("received %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFaultClientDisconnectAndReconnect(t *testing.T) {
+	backend := &recordingBackend{}
+	c := newTestFaultClient(t, backend, websocket.FaultConfig{
+		Enabled:         true,
+		DisconnectEvery: 50 * time.Millisecond,
+		ReconnectAfter:  50 * time.Millisecond,
+	})
+
+	send(t, c, "1")
+	time.Sleep(60 * time.Millisecond)
+	// Расписание сработало: соединение оборвано, сообщения во время разрыва теряются
+	send(t, c, "lost")
+	if c.inner.Load() != nil {
+		t.Fatal("connection not dropped by schedule")
+	}
+	if !c.IsConnected() {
+		t.Error("session must survive a simulated disconnect")
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for c.inner.Load() == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("no reconnect after ReconnectAfter")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := backend.Connects(); n != 2 {
+		t.Errorf("connects = %d, want 2", n)
+	}
+	send(t, c, "2")
+	if got := waitReceived(t, backend, 2); !slices.Equal(got, []string{"1", "2"}) {
+		t.Errorf("received %q", got)
+	}
+}
+
+func TestFaultClientDisconnectResetsFaults(t *testing.T) {
+	backend := &recordingBackend{}
+	defaults := websocket.FaultConfig{Latency: time.Second}
+	c := newTestFaultClient(t, backend, defaults)
+
+	c.SetFaults(websocket.FaultConfig{Enabled: true, DropRate: 1})
+	if err := c.Disconnect(); err != nil {
+		t.Fatal(err)
+	}
+	// Параметры сессии не переходят в следующую
+	if got := c.Faults(); got != defaults {
+		t.Errorf("faults after disconnect = %+v, want defaults", got)
+	}
+	if err := c.SendMessage([]byte("x")); err == nil {
+		t.Error("send without a session must fail")
+	}
+}
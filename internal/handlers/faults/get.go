@@ -0,0 +1,14 @@
+package faults
+
+import (
+	"backend_gen/internal/usecase"
+	"backend_gen/pkg/http/writer"
+	"net/http"
+)
+
+func GetFaults(uc usecase.FaultsUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writer.WriteStatusOK(w)
+		writer.WriteJson(w, uc.GetFaults())
+	}
+}
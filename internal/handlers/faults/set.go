@@ -0,0 +1,30 @@
+package faults
+
+import (
+	"backend_gen/internal/usecase"
+	httpErr "backend_gen/pkg/http/error"
+	"backend_gen/pkg/http/writer"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// SetFaults частично обновляет параметры сбоев: поля, отсутствующие в теле
+// запроса, сохраняют текущие значения
+func SetFaults(uc usecase.FaultsUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		faults := uc.GetFaults()
+		if err := json.NewDecoder(r.Body).Decode(faults); err != nil {
+			httpErr.BadRequest(w, fmt.Errorf("invalid request body: %w", err))
+			return
+		}
+
+		if err := uc.SetFaults(faults); err != nil {
+			httpErr.BadRequest(w, err)
+			return
+		}
+
+		writer.WriteStatusOK(w)
+		writer.WriteJson(w, uc.GetFaults())
+	}
+}
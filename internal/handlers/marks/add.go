@@ -0,0 +1,39 @@
+package marks
+
+import (
+	"backend_gen/internal/models/dto"
+	"backend_gen/internal/ports/websocket"
+	"backend_gen/internal/usecase"
+	httpErr "backend_gen/pkg/http/error"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+func AddMark(uc usecase.WebSocketUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req dto.MarkRequest
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				httpErr.BadRequest(w, fmt.Errorf("invalid request body: %w", err))
+				return
+			}
+		}
+
+		switch req.Type {
+		case "":
+			req.Type = websocket.MarkEvent
+		case websocket.MarkEvent, websocket.MarkFetalMovement:
+		default:
+			httpErr.BadRequest(w, fmt.Errorf("unknown mark type %q", req.Type))
+			return
+		}
+
+		if err := uc.AddMark(req.Type, req.Note); err != nil {
+			httpErr.BadRequest(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
@@ -0,0 +1,22 @@
+package scenarios
+
+import (
+	"backend_gen/internal/usecase"
+	httpErr "backend_gen/pkg/http/error"
+	"backend_gen/pkg/http/writer"
+	"fmt"
+	"net/http"
+)
+
+func ListScenarios(uc usecase.ScenariosUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		scenarios, err := uc.ListScenarios()
+		if err != nil {
+			httpErr.InternalError(w, fmt.Errorf("failed to list scenarios: %w", err))
+			return
+		}
+
+		writer.WriteStatusOK(w)
+		writer.WriteJson(w, scenarios)
+	}
+}
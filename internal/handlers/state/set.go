@@ -0,0 +1,28 @@
+package state
+
+import (
+	"backend_gen/internal/models/dto"
+	"backend_gen/internal/usecase"
+	httpErr "backend_gen/pkg/http/error"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// SetFetalState меняет состояние плода (healthy | hypoxia) во время сессии
+func SetFetalState(uc usecase.WebSocketUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req dto.FetalStateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			httpErr.BadRequest(w, fmt.Errorf("invalid request body: %w", err))
+			return
+		}
+
+		if err := uc.SetFetalState(req.State); err != nil {
+			httpErr.BadRequest(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
@@ -0,0 +1,70 @@
+package stream
+
+import (
+	"backend_gen/internal/usecase"
+	httpErr "backend_gen/pkg/http/error"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// SSE транслирует сообщения текущей сессии как Server-Sent Events
+// (событие message, данные - MessageData в JSON)
+func SSE(uc usecase.StreamUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rc := http.NewResponseController(w)
+		if err := extendWriteDeadline(rc); err != nil {
+			httpErr.InternalError(w, fmt.Errorf("streaming is not supported: %w", err))
+			return
+		}
+
+		messages, unsubscribe := uc.Subscribe()
+		defer unsubscribe()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		_, _ = fmt.Fprint(w, "retry: 2000\n\n")
+		if err := rc.Flush(); err != nil {
+			return
+		}
+
+		heartbeat := time.NewTicker(heartbeatInterval)
+		defer heartbeat.Stop()
+
+		var id int
+		for {
+			var err error
+			select {
+			case <-r.Context().Done():
+				return
+			case <-heartbeat.C:
+				if err = extendWriteDeadline(rc); err == nil {
+					_, err = fmt.Fprint(w, ": ping\n\n")
+				}
+			case message := <-messages:
+				var data []byte
+				data, err = json.Marshal(message)
+				if err != nil {
+					slog.Error("Failed to marshal stream message", "error", err)
+					continue
+				}
+				id++
+				if err = extendWriteDeadline(rc); err == nil {
+					_, err = fmt.Fprintf(w, "id: %d\nevent: message\ndata: %s\n\n", id, data)
+				}
+			}
+			if err == nil {
+				err = rc.Flush()
+			}
+			if err != nil {
+				slog.Info("SSE stream closed", "error", err)
+				return
+			}
+		}
+	}
+}
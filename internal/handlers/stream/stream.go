@@ -0,0 +1,18 @@
+package stream
+
+import (
+	"net/http"
+	"time"
+)
+
+// writeTimeout ограничивает каждую запись в поток. Общий WriteTimeout сервера
+// (10 с) для потоков заменяется дедлайном на отдельную запись
+const writeTimeout = 5 * time.Second
+
+// heartbeatInterval период служебных сообщений, чтобы прокси не закрывали соединение
+const heartbeatInterval = 15 * time.Second
+
+// extendWriteDeadline продлевает дедлайн записи ответа на writeTimeout
+func extendWriteDeadline(rc *http.ResponseController) error {
+	return rc.SetWriteDeadline(time.Now().Add(writeTimeout))
+}
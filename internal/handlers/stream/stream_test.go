@@ -0,0 +1,161 @@
+package stream
+
+import (
+	"backend_gen/internal/adapter/broadcast"
+	"backend_gen/internal/adapter/encoding"
+	"backend_gen/internal/ports/websocket"
+	"bufio"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	gorilla "github.com/gorilla/websocket"
+)
+
+// testUseCase раздаёт сообщения через настоящий hub и сообщает о подписке,
+// чтобы тест публиковал только после неё
+type testUseCase struct {
+	websocket.Broadcaster
+	subscribed chan struct{}
+}
+
+func newTestUseCase() *testUseCase {
+	return &testUseCase{Broadcaster: broadcast.NewHub(), subscribed: make(chan struct{}, 1)}
+}
+
+func (uc *testUseCase) Subscribe() (<-chan websocket.MessageData, func()) {
+	messages, unsubscribe := uc.Broadcaster.Subscribe()
+	uc.subscribed <- struct{}{}
+	return messages, unsubscribe
+}
+
+func (uc *testUseCase) Encoder(name string) (websocket.Encoder, error) {
+	return encoding.New(name)
+}
+
+func (uc *testUseCase) waitSubscribed(t *testing.T) {
+	t.Helper()
+	select {
+	case <-uc.subscribed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("handler did not subscribe")
+	}
+}
+
+// lossMessage сообщение с потерей сигнала FHR
+func lossMessage(sec float64) websocket.MessageData {
+	return websocket.MessageData{SensorID: "s1", SecFromStart: sec, Data: websocket.SensorData{BPMChild: math.NaN(), Uterus: 12, Spasms: 3}}
+}
+
+func TestSSE(t *testing.T) {
+	uc := newTestUseCase()
+	server := httptest.NewServer(SSE(uc))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("content type = %q", ct)
+	}
+	uc.waitSubscribed(t)
+	uc.Publish(lossMessage(1.5))
+	uc.Publish(lossMessage(2))
+
+	reader := bufio.NewReader(resp.Body)
+	var events []string
+	var event strings.Builder
+	for len(events) < 2 {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read: %v (events %q)", err, events)
+		}
+		if line == "\n" {
+			if strings.HasPrefix(event.String(), "id:") {
+				events = append(events, event.String())
+			}
+			event.Reset()
+			continue
+		}
+		event.WriteString(line)
+	}
+
+	want := "id: 1\nevent: message\ndata: "
+	if !strings.HasPrefix(events[0], want) || !strings.HasPrefix(events[1], "id: 2\n") {
+		t.Fatalf("events = %q", events)
+	}
+	var m struct {
+		SecFromStart float64             `json:"secFromStart"`
+		Data         map[string]*float64 `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(strings.TrimSpace(events[0]), want)), &m); err != nil {
+		t.Fatalf("decode %q: %v", events[0], err)
+	}
+	// Потеря сигнала передаётся как null
+	if m.SecFromStart != 1.5 || m.Data["bpmChild"] != nil || *m.Data["uterus"] != 12 {
+		t.Errorf("message = %+v", m)
+	}
+}
+
+func TestWebSocket(t *testing.T) {
+	uc := newTestUseCase()
+	server := httptest.NewServer(WebSocket(uc))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	for _, c := range []struct {
+		encoding string
+		frame    int
+	}{
+		{"", gorilla.TextMessage},
+		{encoding.FormatMsgpack, gorilla.BinaryMessage},
+	} {
+		conn, _, err := gorilla.DefaultDialer.Dial(url+"?encoding="+c.encoding, nil)
+		if err != nil {
+			t.Fatalf("%q: dial: %v", c.encoding, err)
+		}
+		uc.waitSubscribed(t)
+		uc.Publish(lossMessage(3))
+
+		_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		frame, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("%q: read: %v", c.encoding, err)
+		}
+		if frame != c.frame {
+			t.Errorf("%q: frame type %d, want %d", c.encoding, frame, c.frame)
+		}
+		name := c.encoding
+		if name == "" {
+			name = encoding.FormatJSON
+		}
+		codec, _ := encoding.New(name)
+		m, err := codec.Decode(data)
+		if err != nil {
+			t.Fatalf("%q: decode: %v", c.encoding, err)
+		}
+		if m.SecFromStart != 3 || !math.IsNaN(m.Data.BPMChild) || m.Data.Uterus != 12 {
+			t.Errorf("%q: message = %+v", c.encoding, m)
+		}
+		conn.Close()
+	}
+}
+
+func TestWebSocketUnknownEncoding(t *testing.T) {
+	server := httptest.NewServer(WebSocket(newTestUseCase()))
+	defer server.Close()
+
+	_, resp, err := gorilla.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"?encoding=xml", nil)
+	if err == nil {
+		t.Fatal("dial must fail")
+	}
+	if resp == nil || resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("response = %v, want 400", resp)
+	}
+}
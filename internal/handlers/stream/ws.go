@@ -0,0 +1,85 @@
+package stream
+
+import (
+	"backend_gen/internal/usecase"
+	httpErr "backend_gen/pkg/http/error"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+var upgrader = websocket.Upgrader{
+	// Дашборды открываются с других origin, поток только для чтения
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// WebSocket транслирует сообщения текущей сессии в WebSocket. Query-параметр
+// encoding выбирает формат (json по умолчанию, бинарные форматы - бинарными фреймами)
+func WebSocket(uc usecase.StreamUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("encoding")
+		if name == "" {
+			name = "json"
+		}
+		encoder, err := uc.Encoder(name)
+		if err != nil {
+			httpErr.BadRequest(w, err)
+			return
+		}
+
+		// Upgrade снимает дедлайны HTTP сервера с соединения
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			slog.Warn("Stream WebSocket upgrade failed", "error", err)
+			return
+		}
+		defer conn.Close()
+
+		messages, unsubscribe := uc.Subscribe()
+		defer unsubscribe()
+
+		// Входящие сообщения не ожидаются: читаем только для обработки close и ping
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		heartbeat := time.NewTicker(heartbeatInterval)
+		defer heartbeat.Stop()
+
+		frameType := websocket.TextMessage
+		if encoder.Binary() {
+			frameType = websocket.BinaryMessage
+		}
+		for {
+			select {
+			case <-closed:
+				return
+			case <-heartbeat.C:
+				deadline := time.Now().Add(writeTimeout)
+				if err := conn.WriteControl(websocket.PingMessage, nil, deadline); err != nil {
+					slog.Info("Stream WebSocket closed", "error", err)
+					return
+				}
+			case message := <-messages:
+				data, err := encoder.Encode(message)
+				if err != nil {
+					slog.Error("Failed to encode stream message", "encoding", encoder.Name(), "error", err)
+					continue
+				}
+				_ = conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+				if err := conn.WriteMessage(frameType, data); err != nil {
+					slog.Info("Stream WebSocket closed", "error", err)
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package websocket
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"backend_gen/internal/models/dto"
+)
+
+// faultParams query-параметры сбоев сети для сессии и соответствующие поля
+var faultParams = []struct {
+	name  string
+	field func(*dto.Faults) *float64
+	ms    func(*dto.Faults) *int
+}{
+	{name: "latency_ms", ms: func(f *dto.Faults) *int { return &f.LatencyMs }},
+	{name: "jitter_ms", ms: func(f *dto.Faults) *int { return &f.JitterMs }},
+	{name: "drop_rate", field: func(f *dto.Faults) *float64 { return &f.DropRate }},
+	{name: "duplicate_rate", field: func(f *dto.Faults) *float64 { return &f.DuplicateRate }},
+	{name: "reorder_rate", field: func(f *dto.Faults) *float64 { return &f.ReorderRate }},
+	{name: "disconnect_every_sec", field: func(f *dto.Faults) *float64 { return &f.DisconnectEverySec }},
+	{name: "disconnect_rate", field: func(f *dto.Faults) *float64 { return &f.DisconnectRate }},
+	{name: "reconnect_after_ms", ms: func(f *dto.Faults) *int { return &f.ReconnectAfterMs }},
+}
+
+// parseFaults читает параметры сбоев сессии поверх текущих. Любой параметр
+// сбоя включает имитацию, если faults не задан явно. Возвращает false, если
+// запрос не содержит параметров сбоев
+func parseFaults(r *http.Request, current *dto.Faults) (*dto.Faults, bool, error) {
+	faults := *current
+	query := r.URL.Query()
+	found := false
+
+	for _, p := range faultParams {
+		v := query.Get(p.name)
+		if v == "" {
+			continue
+		}
+		found = true
+		if p.ms != nil {
+			ms, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, false, fmt.Errorf("invalid %s: %w", p.name, err)
+			}
+			*p.ms(&faults) = ms
+			continue
+		}
+		value, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid %s: %w", p.name, err)
+		}
+		*p.field(&faults) = value
+	}
+	if found {
+		faults.Enabled = true
+	}
+	if v := query.Get("faults"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid faults: %w", err)
+		}
+		faults.Enabled = enabled
+		found = true
+	}
+
+	return &faults, found, nil
+}
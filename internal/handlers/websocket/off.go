@@ -7,11 +7,24 @@ import (
 	"net/http"
 )
 
+// OffSocket останавливает генерацию и отключает бэкенд, если он подключён
 func OffSocket(uc usecase.WebSocketUseCase) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		status := uc.Status()
+
 		// Останавливаем отправку сообщений
 		uc.StopSendingMessages()
 
+		if !status.Connected {
+			if !status.Running {
+				httpErr.InternalError(w, fmt.Errorf("failed to disconnect: not connected"))
+				return
+			}
+			// Сессия без бэкенда: достаточно остановить генерацию
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
 		err := uc.Disconnect()
 		if err != nil {
 			httpErr.InternalError(w, fmt.Errorf("failed to disconnect: %w", err))
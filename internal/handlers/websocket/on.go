@@ -1,28 +1,115 @@
 package websocket
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 
+	"backend_gen/internal/models/dto"
+	"backend_gen/internal/ports/generator"
 	"backend_gen/internal/usecase"
 	httpErr "backend_gen/pkg/http/error"
+
+	"github.com/go-chi/chi/v5"
 )
 
+// OnSocket начинает сессию. Сценарий задаётся параметром пути {name}
+// (/scenarios/{name}/on) или query-параметром scenario; без него
+// используется генератор из конфигурации. Query-параметр encoding
+// выбирает формат сообщений (json, msgpack, protobuf, cbor) из тех, что
+// переносит транспорт; неподдерживаемый формат - 400. С backend=false
+// бэкенд не подключается: данные идут только в локальные потоки /api/stream.
+// Параметры faults, latency_ms, jitter_ms, drop_rate, duplicate_rate,
+// reorder_rate, disconnect_every_sec, disconnect_rate и reconnect_after_ms
+// задают сбои сети на эту сессию; после /api/off действуют значения из
+// конфигурации. endpoint - адрес бэкенда для выбранного транспорта
 func OnSocket(
 	uc usecase.WebSocketUseCase,
-	sensorID string,
+	faultsUC usecase.FaultsUseCase,
+	endpoint string,
 	sensorToken string,
-	wsAddr string,
-	wsPort string,
+	defaultProfile dto.Profile,
 ) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		url := fmt.Sprintf("ws://%s:%s/ws/sensor?sensor_id=%s", wsAddr, wsPort, sensorID)
-		err := uc.Connect(url, sensorToken)
+		profile, err := parseProfile(r, defaultProfile)
+		if err != nil {
+			httpErr.BadRequest(w, err)
+			return
+		}
+		backend := true
+		if v := r.URL.Query().Get("backend"); v != "" {
+			if backend, err = strconv.ParseBool(v); err != nil {
+				httpErr.BadRequest(w, fmt.Errorf("invalid backend: %w", err))
+				return
+			}
+		}
+
+		current := &dto.Faults{}
+		if faultsUC != nil {
+			current = faultsUC.GetFaults()
+		}
+		faults, sessionFaults, err := parseFaults(r, current)
+		if err != nil {
+			httpErr.BadRequest(w, err)
+			return
+		}
+		if sessionFaults && faultsUC == nil {
+			httpErr.BadRequest(w, fmt.Errorf("fault injection is not available for this transport"))
+			return
+		}
+		if sessionFaults && !backend {
+			httpErr.BadRequest(w, fmt.Errorf("network faults require a backend connection"))
+			return
+		}
+
+		scenario := chi.URLParam(r, "name")
+		if scenario == "" {
+			scenario = r.URL.Query().Get("scenario")
+		}
+		err = uc.UseScenario(scenario)
+		if errors.Is(err, generator.ErrScenarioNotFound) {
+			httpErr.NotFound(w, err)
+			return
+		}
+		if err != nil {
+			httpErr.BadRequest(w, err)
+			return
+		}
+
+		err = uc.UseEncoding(r.URL.Query().Get("encoding"))
 		if err != nil {
-			httpErr.InternalError(w, fmt.Errorf("failed to connect: %w", err))
+			httpErr.BadRequest(w, err)
 			return
 		}
 
+		// Профиль проверяется до подключения, чтобы не открывать сессию на бэкенде зря
+		err = uc.SetProfile(profile)
+		if err != nil {
+			httpErr.BadRequest(w, err)
+			return
+		}
+
+		if sessionFaults {
+			err = faultsUC.SetFaults(faults)
+			if err != nil {
+				httpErr.BadRequest(w, err)
+				return
+			}
+		}
+
+		if backend {
+			err = uc.Connect(endpoint, sensorToken)
+			if err != nil {
+				if sessionFaults {
+					// Сессия не началась: возвращаем прежние параметры сбоев
+					_ = faultsUC.SetFaults(current)
+				}
+				httpErr.InternalError(w, fmt.Errorf("failed to connect: %w", err))
+				return
+			}
+		}
+
 		// Запускаем отправку сообщений каждую секунду
 		err = uc.StartSendingMessages()
 		if err != nil {
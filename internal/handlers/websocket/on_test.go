@@ -0,0 +1,172 @@
+package websocket
+
+import (
+	"backend_gen/internal/adapter/broadcast"
+	"backend_gen/internal/models/dto"
+	"backend_gen/internal/ports/generator"
+	"backend_gen/internal/ports/websocket"
+	wsUC "backend_gen/internal/usecase/websocket"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// countingClient считает подключения к бэкенду
+type countingClient struct {
+	connects  int
+	connected atomic.Bool
+}
+
+func (c *countingClient) Connect(string, string) error {
+	c.connects++
+	c.connected.Store(true)
+	return nil
+}
+func (c *countingClient) Disconnect() error        { c.connected.Store(false); return nil }
+func (c *countingClient) IsConnected() bool        { return c.connected.Load() }
+func (c *countingClient) SendMessage([]byte) error { return nil }
+func (c *countingClient) SendBinary([]byte) error  { return nil }
+
+type constGenerator struct{}
+
+func (constGenerator) GenerateNext(float64) websocket.SensorData {
+	return websocket.SensorData{BPMChild: 140}
+}
+func (constGenerator) Reset()                                       {}
+func (constGenerator) SetParameters(generator.GenerationParameters) {}
+
+type jsonEncoder struct{}
+
+func (jsonEncoder) Name() string                                 { return "json" }
+func (jsonEncoder) Binary() bool                                 { return false }
+func (jsonEncoder) Encode(websocket.MessageData) ([]byte, error) { return []byte("{}"), nil }
+
+func TestOnSocketProfile(t *testing.T) {
+	cases := []struct {
+		name     string
+		defaults dto.Profile
+		query    string
+		status   int
+		connects int
+	}{
+		// Конфигурация без блока profile: срок 0 считается незаданным
+		{"config without profile", dto.Profile{}, "", http.StatusOK, 1},
+		{"explicit weeks", dto.Profile{}, "?gestational_weeks=32", http.StatusOK, 1},
+		// Неверный профиль отклоняется до подключения к бэкенду
+		{"weeks out of range", dto.Profile{GestationalWeeks: 40}, "?gestational_weeks=50", http.StatusBadRequest, 0},
+		{"maternal age out of range", dto.Profile{}, "?maternal_age=80", http.StatusBadRequest, 0},
+		{"local session", dto.Profile{}, "?backend=false", http.StatusOK, 0},
+		{"invalid backend flag", dto.Profile{}, "?backend=maybe", http.StatusBadRequest, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			client := &countingClient{}
+			uc := wsUC.NewWebSocketUseCase(client, broadcast.NewHub(), nil, constGenerator{}, nil,
+				map[string]websocket.Encoder{"json": jsonEncoder{}}, "json", nil, false)
+			defer uc.StopSendingMessages()
+
+			rec := httptest.NewRecorder()
+			OnSocket(uc, nil, "ws://backend", "token", c.defaults)(rec, httptest.NewRequest(http.MethodGet, "/api/on"+c.query, nil))
+			if rec.Code != c.status {
+				t.Errorf("status = %d, want %d: %s", rec.Code, c.status, rec.Body)
+			}
+			if client.connects != c.connects {
+				t.Errorf("connects = %d, want %d", client.connects, c.connects)
+			}
+		})
+	}
+}
+
+func TestOffSocketLocalSession(t *testing.T) {
+	uc := wsUC.NewWebSocketUseCase(&countingClient{}, broadcast.NewHub(), nil, constGenerator{}, nil,
+		map[string]websocket.Encoder{"json": jsonEncoder{}}, "json", nil, false)
+	on := OnSocket(uc, nil, "ws://backend", "token", dto.Profile{})
+	off := OffSocket(uc)
+
+	rec := httptest.NewRecorder()
+	on(rec, httptest.NewRequest(http.MethodGet, "/api/on?backend=false", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("on: %d %s", rec.Code, rec.Body)
+	}
+	rec = httptest.NewRecorder()
+	off(rec, httptest.NewRequest(http.MethodGet, "/api/off", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("off: %d %s", rec.Code, rec.Body)
+	}
+	// Повторная остановка: сессии уже нет
+	rec = httptest.NewRecorder()
+	off(rec, httptest.NewRequest(http.MethodGet, "/api/off", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("second off: %d, want 500", rec.Code)
+	}
+}
+
+// recordingFaults хранит параметры сбоев в памяти
+type recordingFaults struct {
+	current dto.Faults
+}
+
+func (f *recordingFaults) GetFaults() *dto.Faults {
+	faults := f.current
+	return &faults
+}
+
+func (f *recordingFaults) SetFaults(faults *dto.Faults) error {
+	if faults.DropRate < 0 || faults.DropRate > 1 {
+		return fmt.Errorf("dropRate must be in range 0..1")
+	}
+	f.current = *faults
+	return nil
+}
+
+func TestOnSocketSessionFaults(t *testing.T) {
+	cases := []struct {
+		name     string
+		query    string
+		status   int
+		connects int
+		want     dto.Faults
+	}{
+		{"defaults", "", http.StatusOK, 1, dto.Faults{LatencyMs: 100}},
+		// Любой параметр сбоя включает имитацию
+		{"drop rate", "?drop_rate=0.2&reconnect_after_ms=500", http.StatusOK, 1,
+			dto.Faults{Enabled: true, LatencyMs: 100, DropRate: 0.2, ReconnectAfterMs: 500}},
+		{"explicitly off", "?faults=false&jitter_ms=30", http.StatusOK, 1, dto.Faults{LatencyMs: 100, JitterMs: 30}},
+		{"invalid value", "?drop_rate=high", http.StatusBadRequest, 0, dto.Faults{LatencyMs: 100}},
+		{"rejected by validation", "?drop_rate=2", http.StatusBadRequest, 0, dto.Faults{LatencyMs: 100}},
+		{"without backend", "?backend=false&faults=true", http.StatusBadRequest, 0, dto.Faults{LatencyMs: 100}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			client := &countingClient{}
+			faults := &recordingFaults{current: dto.Faults{LatencyMs: 100}}
+			uc := wsUC.NewWebSocketUseCase(client, broadcast.NewHub(), nil, constGenerator{}, nil,
+				map[string]websocket.Encoder{"json": jsonEncoder{}}, "json", nil, false)
+			defer uc.StopSendingMessages()
+
+			rec := httptest.NewRecorder()
+			OnSocket(uc, faults, "ws://backend", "token", dto.Profile{})(rec, httptest.NewRequest(http.MethodGet, "/api/on"+c.query, nil))
+			if rec.Code != c.status {
+				t.Errorf("status = %d, want %d: %s", rec.Code, c.status, rec.Body)
+			}
+			if client.connects != c.connects {
+				t.Errorf("connects = %d, want %d", client.connects, c.connects)
+			}
+			if faults.current != c.want {
+				t.Errorf("faults = %+v, want %+v", faults.current, c.want)
+			}
+		})
+	}
+}
+
+func TestOnSocketFaultsUnavailable(t *testing.T) {
+	uc := wsUC.NewWebSocketUseCase(&countingClient{}, broadcast.NewHub(), nil, constGenerator{}, nil,
+		map[string]websocket.Encoder{"json": jsonEncoder{}}, "json", nil, false)
+	rec := httptest.NewRecorder()
+	OnSocket(uc, nil, "ws://backend", "token", dto.Profile{})(rec, httptest.NewRequest(http.MethodGet, "/api/on?latency_ms=200", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", rec.Code)
+	}
+}
@@ -0,0 +1,39 @@
+package websocket
+
+import (
+	"backend_gen/internal/models/dto"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// parseProfile читает профиль пациентки из query-параметров
+// gestational_weeks, maternal_age и parity; отсутствующие берутся из defaults
+func parseProfile(r *http.Request, defaults dto.Profile) (dto.Profile, error) {
+	profile := defaults
+	query := r.URL.Query()
+
+	if v := query.Get("gestational_weeks"); v != "" {
+		weeks, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return profile, fmt.Errorf("invalid gestational_weeks: %w", err)
+		}
+		profile.GestationalWeeks = weeks
+	}
+	if v := query.Get("maternal_age"); v != "" {
+		age, err := strconv.Atoi(v)
+		if err != nil {
+			return profile, fmt.Errorf("invalid maternal_age: %w", err)
+		}
+		profile.MaternalAge = age
+	}
+	if v := query.Get("parity"); v != "" {
+		parity, err := strconv.Atoi(v)
+		if err != nil {
+			return profile, fmt.Errorf("invalid parity: %w", err)
+		}
+		profile.Parity = parity
+	}
+
+	return profile, nil
+}
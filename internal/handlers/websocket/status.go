@@ -0,0 +1,14 @@
+package websocket
+
+import (
+	"backend_gen/internal/usecase"
+	"backend_gen/pkg/http/writer"
+	"net/http"
+)
+
+func Status(uc usecase.WebSocketUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writer.WriteStatusOK(w)
+		writer.WriteJson(w, uc.Status())
+	}
+}
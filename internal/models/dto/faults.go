@@ -0,0 +1,17 @@
+package dto
+
+// Faults параметры инъекции сетевых сбоев для control API
+type Faults struct {
+	Enabled bool `json:"enabled"`
+
+	LatencyMs int `json:"latencyMs"`
+	JitterMs  int `json:"jitterMs"`
+
+	DropRate      float64 `json:"dropRate"`
+	DuplicateRate float64 `json:"duplicateRate"`
+	ReorderRate   float64 `json:"reorderRate"`
+
+	DisconnectEverySec float64 `json:"disconnectEverySec"`
+	DisconnectRate     float64 `json:"disconnectRate"`
+	ReconnectAfterMs   int     `json:"reconnectAfterMs"`
+}
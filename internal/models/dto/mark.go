@@ -0,0 +1,8 @@
+package dto
+
+// MarkRequest отметка события, вносимая через control API
+type MarkRequest struct {
+	// Type тип отметки: event (по умолчанию) или fetal_movement
+	Type string `json:"type"`
+	Note string `json:"note"`
+}
@@ -0,0 +1,18 @@
+package dto
+
+// Scenario описание сценария генерации из каталога
+type Scenario struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Expected    []string        `json:"expected,omitempty"`
+	Preset      bool            `json:"preset"`
+	DurationSec float64         `json:"durationSec"`
+	Loop        bool            `json:"loop"`
+	Phases      []ScenarioPhase `json:"phases"`
+}
+
+// ScenarioPhase фаза сценария
+type ScenarioPhase struct {
+	Name        string  `json:"name"`
+	DurationSec float64 `json:"durationSec"`
+}
@@ -0,0 +1,34 @@
+package dto
+
+// Profile клинический профиль пациентки для сессии генерации
+type Profile struct {
+	GestationalWeeks float64 `json:"gestationalWeeks"`
+	MaternalAge      int     `json:"maternalAge,omitempty"`
+	Parity           int     `json:"parity"`
+}
+
+// ProfileNorms нормы CTG, применённые генератором для профиля
+type ProfileNorms struct {
+	BaselineBPM           float64 `json:"baselineBpm"`
+	Variability           float64 `json:"variability"`
+	AccelerationAmplitude float64 `json:"accelerationAmplitude"`
+	AccelerationDuration  float64 `json:"accelerationDuration"`
+	MaternalBPM           float64 `json:"maternalBpm"`
+}
+
+// SessionStatus состояние текущей сессии генерации
+type SessionStatus struct {
+	Connected    bool          `json:"connected"`
+	Running      bool          `json:"running"`
+	SecFromStart float64       `json:"secFromStart,omitempty"`
+	Profile      Profile       `json:"profile"`
+	Norms        *ProfileNorms `json:"norms,omitempty"` // nil, если генератор не учитывает профиль
+	Scenario     string        `json:"scenario,omitempty"`
+	FetalState   string        `json:"fetalState,omitempty"` // healthy | hypoxia
+	Encoding     string        `json:"encoding"`
+}
+
+// FetalStateRequest запрос смены состояния плода во время сессии
+type FetalStateRequest struct {
+	State string `json:"state"` // healthy | hypoxia
+}
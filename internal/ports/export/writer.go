@@ -0,0 +1,22 @@
+package export
+
+import (
+	"backend_gen/internal/ports/websocket"
+	"time"
+)
+
+// Writer записывает сообщения сессии в файл или внешнюю систему
+// (офлайн генерация, экспорт живой сессии)
+type Writer interface {
+	// Write записывает очередное сообщение
+	Write(message websocket.MessageData) error
+
+	// Close дописывает накопленные данные и закрывает вывод
+	Close() error
+}
+
+// Recorder открывает запись живой сессии
+type Recorder interface {
+	// Open создает запись сессии, начавшейся в start
+	Open(start time.Time) (Writer, error)
+}
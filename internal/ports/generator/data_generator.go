@@ -1,6 +1,10 @@
 package generator
 
-import "backend_gen/internal/ports/websocket"
+import (
+	"backend_gen/internal/ports/websocket"
+	"errors"
+	"time"
+)
 
 // DataGenerator интерфейс для генерации медицинских данных
 type DataGenerator interface {
@@ -14,6 +18,88 @@ type DataGenerator interface {
 	SetParameters(params GenerationParameters)
 }
 
+// AnnotationSource опциональный интерфейс генератора, который сообщает
+// эталонную разметку внесённых событий (схватки, децелерации, стадии гипоксии)
+type AnnotationSource interface {
+	// DrainAnnotations возвращает события, начавшиеся с прошлого вызова, и очищает буфер
+	DrainAnnotations() []websocket.Annotation
+}
+
+// MarkSource опциональный интерфейс генератора, который отмечает шевеления плода
+type MarkSource interface {
+	// DrainMarks возвращает отметки, появившиеся с прошлого вызова, и очищает буфер
+	DrainMarks() []websocket.Mark
+}
+
+// Profile клинический профиль пациентки, от которого зависят нормы CTG
+type Profile struct {
+	GestationalWeeks float64 // срок беременности в неделях
+	MaternalAge      int     // возраст матери (0 = не указан)
+	Parity           int     // число предыдущих родов
+}
+
+// ProfileNorms нормы CTG, рассчитанные генератором для профиля
+type ProfileNorms struct {
+	BaselineBPM           float64 // базальный ритм здорового плода
+	Variability           float64 // амплитуда вариабельности, ±BPM
+	AccelerationAmplitude float64 // критерий акцелерации: минимальный подъём, BPM
+	AccelerationDuration  float64 // критерий акцелерации: минимальная длительность, сек
+	ContractionInterval   float64 // множитель среднего интервала между схватками
+	MaternalBPM           float64 // базальный пульс матери в покое
+}
+
+// ProfileAware опциональный интерфейс генератора, учитывающего профиль пациентки
+type ProfileAware interface {
+	// SetProfile применяет профиль (сохраняется при Reset) и возвращает нормы.
+	// ok = false, если профиль не применён (обёртка над генератором без профиля)
+	SetProfile(profile Profile) (norms ProfileNorms, ok bool)
+}
+
+// Состояния плода
+const (
+	FetalStateHealthy = "healthy"
+	FetalStateHypoxia = "hypoxia"
+)
+
+// FetalStateControl опциональный интерфейс генератора, позволяющий сменить
+// состояние плода во время сессии без перезапуска
+type FetalStateControl interface {
+	// SetFetalState плавно переводит плод в состояние state
+	SetFetalState(state string) error
+
+	// FetalState возвращает текущее состояние плода ("" = неизвестно)
+	FetalState() string
+}
+
+// ErrScenarioNotFound сценарий с таким именем отсутствует в каталоге
+var ErrScenarioNotFound = errors.New("scenario not found")
+
+// ScenarioInfo описание сценария из каталога
+type ScenarioInfo struct {
+	Name        string
+	Description string
+	Expected    []string      // ожидаемые признаки КТГ
+	Preset      bool          // встроенный пресет
+	Duration    time.Duration // длительность одного прохода
+	Loop        bool
+	Phases      []PhaseInfo
+}
+
+// PhaseInfo описание фазы сценария
+type PhaseInfo struct {
+	Name     string
+	Duration time.Duration
+}
+
+// ScenarioCatalog каталог именованных сценариев генерации
+type ScenarioCatalog interface {
+	// List возвращает описания всех сценариев каталога
+	List() ([]ScenarioInfo, error)
+
+	// Open создает генератор, воспроизводящий сценарий name
+	Open(name string) (DataGenerator, error)
+}
+
 // GenerationParameters параметры для генерации данных
 type GenerationParameters struct {
 	// BPM параметры
@@ -0,0 +1,11 @@
+package websocket
+
+// Broadcaster раздаёт сообщения текущей сессии локальным подписчикам
+// (SSE и WebSocket потоки самого генератора)
+type Broadcaster interface {
+	// Publish отправляет сообщение всем подписчикам без блокировки
+	Publish(message MessageData)
+
+	// Subscribe возвращает канал сообщений и функцию отписки
+	Subscribe() (<-chan MessageData, func())
+}
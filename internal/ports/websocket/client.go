@@ -5,4 +5,6 @@ type Client interface {
 	Disconnect() error
 	IsConnected() bool
 	SendMessage(message []byte) error
+	// SendBinary отправляет сообщение бинарным фреймом (MessagePack, Protobuf, CBOR)
+	SendBinary(message []byte) error
 }
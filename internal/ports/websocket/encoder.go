@@ -0,0 +1,21 @@
+package websocket
+
+import "time"
+
+// Encoder кодирует сообщения генератора в формат сессии
+type Encoder interface {
+	// Name имя формата: json, msgpack, protobuf, cbor
+	Name() string
+
+	// Encode кодирует сообщение
+	Encode(message MessageData) ([]byte, error)
+
+	// Binary сообщает, что сообщения отправляются бинарными фреймами
+	Binary() bool
+}
+
+// SessionEncoder опциональный интерфейс кодировщика, которому нужно время начала
+// сессии (HL7: время наблюдения = начало сессии + secFromStart)
+type SessionEncoder interface {
+	StartSession(start time.Time)
+}
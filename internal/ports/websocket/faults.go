@@ -0,0 +1,26 @@
+package websocket
+
+import "time"
+
+// FaultConfig параметры инъекции сетевых сбоев между генератором и бэкендом.
+// Вероятности задаются в диапазоне 0..1 на одно сообщение
+type FaultConfig struct {
+	Enabled bool
+
+	Latency time.Duration // фиксированная задержка доставки
+	Jitter  time.Duration // случайная добавка к задержке 0..Jitter
+
+	DropRate      float64 // доля потерянных сообщений
+	DuplicateRate float64 // доля продублированных сообщений
+	ReorderRate   float64 // доля сообщений, отправленных после следующего
+
+	DisconnectEvery time.Duration // принудительный разрыв по расписанию (0 = выключено)
+	DisconnectRate  float64       // вероятность разрыва на сообщение
+	ReconnectAfter  time.Duration // пауза перед переподключением (0 = не переподключаться)
+}
+
+// FaultInjector клиент, позволяющий менять параметры сбоев во время сессии
+type FaultInjector interface {
+	Faults() FaultConfig
+	SetFaults(cfg FaultConfig)
+}
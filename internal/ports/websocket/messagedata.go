@@ -1,13 +1,153 @@
 package websocket
 
+import (
+	"encoding/json"
+	"math"
+)
+
 type MessageData struct {
 	SensorID     string     `json:"sensorID"`
 	SecFromStart float64    `json:"secFromStart"`
 	Data         SensorData `json:"data"`
+	// Annotations эталонная разметка событий, начавшихся в этом сообщении.
+	// Заполняется только при включённом generator.annotations
+	Annotations []Annotation `json:"annotations,omitempty"`
+	// Marks отметки шевелений плода и нажатий кнопки события
+	Marks []Mark `json:"marks,omitempty"`
 }
 
 type SensorData struct {
 	BPMChild float64 `json:"bpmChild"`
 	Uterus   float64 `json:"uterus"`
 	Spasms   float64 `json:"spasms"`
+	// BPMMother пульс матери; передаётся только при включённом generator.maternal_hr
+	BPMMother *float64 `json:"bpmMother,omitempty"`
+	// BPMChild2 пульс второго плода; передаётся только в режиме двойни
+	BPMChild2 *float64 `json:"bpmChild2,omitempty"`
+}
+
+// MarshalJSON кодирует потерю сигнала (NaN) как null: encoding/json не умеет NaN
+func (d SensorData) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		BPMChild  *float64  `json:"bpmChild"`
+		Uterus    *float64  `json:"uterus"`
+		Spasms    *float64  `json:"spasms"`
+		BPMMother *optional `json:"bpmMother,omitempty"`
+		BPMChild2 *optional `json:"bpmChild2,omitempty"`
+	}{
+		BPMChild:  nullable(d.BPMChild),
+		Uterus:    nullable(d.Uterus),
+		Spasms:    nullable(d.Spasms),
+		BPMMother: (*optional)(d.BPMMother),
+		BPMChild2: (*optional)(d.BPMChild2),
+	})
+}
+
+// nullable возвращает nil для NaN
+func nullable(v float64) *float64 {
+	if math.IsNaN(v) {
+		return nil
+	}
+	return &v
+}
+
+// optional значение необязательного канала: отсутствующий канал не передаётся,
+// а потеря сигнала на нём (NaN) передаётся как null
+type optional float64
+
+func (v optional) MarshalJSON() ([]byte, error) {
+	return json.Marshal(nullable(float64(v)))
+}
+
+// UnmarshalJSON обратное MarshalJSON: null - потеря сигнала (NaN), отсутствующий
+// необязательный канал - nil
+func (d *SensorData) UnmarshalJSON(data []byte) error {
+	var channels map[string]*float64
+	if err := json.Unmarshal(data, &channels); err != nil {
+		return err
+	}
+	*d = SensorDataFromChannels(channels)
+	return nil
+}
+
+// Channels возвращает каналы по именам JSON; потеря сигнала - nil,
+// отсутствующий необязательный канал в карту не попадает
+func (d SensorData) Channels() map[string]*float64 {
+	channels := map[string]*float64{
+		"bpmChild": nullable(d.BPMChild),
+		"uterus":   nullable(d.Uterus),
+		"spasms":   nullable(d.Spasms),
+	}
+	if d.BPMMother != nil {
+		channels["bpmMother"] = nullable(*d.BPMMother)
+	}
+	if d.BPMChild2 != nil {
+		channels["bpmChild2"] = nullable(*d.BPMChild2)
+	}
+	return channels
+}
+
+// SensorDataFromChannels обратное Channels: nil и отсутствие обязательного
+// канала - NaN
+func SensorDataFromChannels(channels map[string]*float64) SensorData {
+	value := func(name string) float64 {
+		if v := channels[name]; v != nil {
+			return *v
+		}
+		return math.NaN()
+	}
+	optionalValue := func(name string) *float64 {
+		if _, ok := channels[name]; !ok {
+			return nil
+		}
+		v := value(name)
+		return &v
+	}
+	return SensorData{
+		BPMChild:  value("bpmChild"),
+		Uterus:    value("uterus"),
+		Spasms:    value("spasms"),
+		BPMMother: optionalValue("bpmMother"),
+		BPMChild2: optionalValue("bpmChild2"),
+	}
+}
+
+// Типы событий эталонной разметки
+const (
+	AnnotationContraction  = "contraction"
+	AnnotationDeceleration = "deceleration"
+	AnnotationHypoxia      = "hypoxia"
+	AnnotationArtifact     = "artifact"
+	AnnotationAcceleration = "acceleration"
+	AnnotationPhase        = "phase"
+	AnnotationStateChange  = "state_change"
+	AnnotationSegment      = "segment"
+)
+
+// Типы и источники отметок канала событий
+const (
+	MarkFetalMovement = "fetal_movement"
+	MarkEvent         = "event"
+
+	MarkSourceAuto   = "auto"   // автоматически детектировано монитором
+	MarkSourceManual = "manual" // нажатие кнопки (через control API)
+)
+
+// Mark отметка в канале событий монитора
+type Mark struct {
+	Type         string  `json:"type"`
+	Source       string  `json:"source"`
+	SecFromStart float64 `json:"secFromStart"`
+	Note         string  `json:"note,omitempty"`
+}
+
+// Annotation событие, внесённое генератором (ground truth для оценки детекторов).
+// Start и End задаются в секундах от старта генерации (как secFromStart)
+type Annotation struct {
+	Type  string   `json:"type"`
+	Kind  string   `json:"kind,omitempty"` // уточнение: early/late для децелераций, стадия гипоксии, вид артефакта, имя фазы сценария, новое состояние плода, источник сегмента
+	Start float64  `json:"start"`
+	End   *float64 `json:"end,omitempty"` // nil, если событие длится до конца сессии
+	// Channel канал, к которому относится событие, если оно не общее (bpmChild2)
+	Channel string `json:"channel,omitempty"`
 }
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"backend_gen/config"
+	"backend_gen/internal/adapter/edf"
+	encodingAdapter "backend_gen/internal/adapter/encoding"
+	exportAdapter "backend_gen/internal/adapter/export"
+	"backend_gen/internal/adapter/fhir"
+	"backend_gen/internal/ports/export"
+	"backend_gen/internal/usecase/offline"
+)
+
+// Форматы записи сессий и расширения файлов
+var exportExtensions = map[string]string{
+	"json": "jsonl",
+	"fhir": "ndjson",
+	"edf":  "edf",
+}
+
+// NewExportWriter создает запись сессии, начавшейся в start, в формате format.
+// Используется записью живых сессий и офлайн CLI
+func NewExportWriter(cfg *config.Config, format string, out io.WriteCloser, start time.Time) (export.Writer, error) {
+	switch format {
+	case "json":
+		codec, err := encodingAdapter.New(encodingAdapter.FormatJSON)
+		if err != nil {
+			return nil, err
+		}
+		return exportAdapter.NewJSONLWriter(out, codec)
+	case "fhir":
+		return fhir.NewNDJSONWriter(out, fhir.Config{
+			SensorID:  cfg.Server.SensorID,
+			PatientID: cfg.FHIR.PatientID,
+			Window:    time.Duration(cfg.FHIR.WindowSec * float64(time.Second)),
+			Period:    offline.Step,
+		}, start), nil
+	case "edf":
+		return edf.NewWriter(out, edf.Config{
+			SensorID:  cfg.Server.SensorID,
+			PatientID: cfg.Export.PatientID,
+			Period:    offline.Step,
+		}, start), nil
+	}
+	return nil, fmt.Errorf("unknown export format %q", format)
+}
+
+// newRecorder создает запись живых сессий в каталог export.dir (nil - запись выключена)
+func newRecorder(cfg *config.Config) (export.Recorder, error) {
+	if cfg.Export.Dir == "" {
+		return nil, nil
+	}
+	if cfg.Export.Format == "" {
+		cfg.Export.Format = "edf"
+	}
+	ext, ok := exportExtensions[cfg.Export.Format]
+	if !ok {
+		return nil, fmt.Errorf("unknown export format %q", cfg.Export.Format)
+	}
+	return exportAdapter.NewFileRecorder(cfg.Export.Dir, "ctg_"+cfg.Server.SensorID, ext,
+		func(out io.WriteCloser, start time.Time) (export.Writer, error) {
+			return NewExportWriter(cfg, cfg.Export.Format, out, start)
+		})
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+
+	"backend_gen/config"
+	"backend_gen/internal/adapter/dataset"
+	generatorAdapter "backend_gen/internal/adapter/generator"
+	"backend_gen/internal/ports/generator"
+)
+
+// NewGenerators собирает генератор по умолчанию и каталог сценариев из конфигурации.
+// Используется сервером и офлайн CLI, чтобы данные получались одинаковыми
+func NewGenerators(cfg *config.Config) (generator.DataGenerator, generator.ScenarioCatalog, error) {
+	wrap := artifactsWrapper(cfg)
+	ctgOptions := generatorAdapter.CTGOptions{
+		MaternalHR: cfg.Generator.MaternalHR,
+		Twins:      cfg.Generator.Twins,
+		Events:     cfg.Generator.Events,
+	}
+	scenarios := generatorAdapter.NewScenarioCatalog(cfg.Scenarios.Dir, ctgOptions, wrap)
+	if cfg.Generator.Preset != "" {
+		if cfg.Generator.Source != "" && cfg.Generator.Source != "synthetic" {
+			return nil, nil, fmt.Errorf("generator preset requires synthetic source, got %q", cfg.Generator.Source)
+		}
+		// Генератор каталога уже обёрнут слоем артефактов
+		gen, err := scenarios.Open(cfg.Generator.Preset)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open generator preset: %w", err)
+		}
+		return gen, scenarios, nil
+	}
+
+	var gen generator.DataGenerator
+	switch cfg.Generator.Source {
+	case "", "synthetic":
+		if cfg.Generator.Annotations && !cfg.Generator.Events {
+			slog.Warn("Generator events are disabled: contractions and decelerations are neither generated nor annotated",
+				"hint", "set generator.events to true")
+		}
+		gen = generatorAdapter.NewCTGGenerator(cfg.Generator.HypoxiaMode, ctgOptions)
+	case "replay":
+		rec, err := loadReplayRecording(cfg)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load replay recording: %w", err)
+		}
+		if cfg.Augment.Enabled {
+			gen, err = generatorAdapter.NewAugmentGenerator(rec, augmentOptions(cfg, rec.Class), cfg.Augment.Seed, cfg.Generator.Twins)
+		} else {
+			gen, err = generatorAdapter.NewReplayGenerator(rec, cfg.Generator.Twins)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create replay generator: %w", err)
+		}
+	case "hybrid":
+		hybrid, err := generatorAdapter.NewHybridGenerator(generatorAdapter.HybridOptions{
+			DatasetDir:    cfg.Replay.DatasetDir,
+			Class:         cfg.Hybrid.Class,
+			SegmentSec:    cfg.Hybrid.SegmentSec,
+			TransitionSec: cfg.Hybrid.TransitionSec,
+			MaxMissing:    cfg.Hybrid.MaxMissing,
+			Seed:          cfg.Hybrid.Seed,
+			LossAsNaN:     cfg.Artifacts.LossValue == "nan",
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create hybrid generator: %w", err)
+		}
+		gen = hybrid
+	default:
+		return nil, nil, fmt.Errorf("unknown generator source %q", cfg.Generator.Source)
+	}
+	return wrap(gen), scenarios, nil
+}
+
+// loadReplayRecording загружает запись датасета, склеивает записи пациента
+// или импортирует файл replay.file
+func loadReplayRecording(cfg *config.Config) (*dataset.Recording, error) {
+	if cfg.Replay.Stitch && cfg.Replay.File == "" {
+		return dataset.LoadPatient(cfg.Replay.DatasetDir, cfg.Replay.Class, cfg.Replay.Patient, dataset.StitchOptions{
+			Gap:    cfg.Replay.StitchGap,
+			GapSec: cfg.Replay.StitchGapSec,
+		})
+	}
+	if cfg.Replay.File == "" {
+		return dataset.LoadRecording(
+			cfg.Replay.DatasetDir,
+			cfg.Replay.Class,
+			cfg.Replay.Patient,
+			cfg.Replay.Recording,
+		)
+	}
+	rec, err := dataset.Import(cfg.Replay.File, cfg.Replay.Format)
+	if err != nil {
+		return nil, err
+	}
+	rec.Class = cfg.Replay.Class
+	return rec, nil
+}
+
+// augmentOptions собирает параметры аугментации; схватки берутся из записей
+// класса class каталога датасета
+func augmentOptions(cfg *config.Config, class string) dataset.AugmentOptions {
+	opts := dataset.AugmentOptions{
+		TimeWarp:            cfg.Augment.TimeWarp,
+		BaselineShift:       cfg.Augment.BaselineShift,
+		VariabilityMin:      cfg.Augment.VariabilityMin,
+		VariabilityMax:      cfg.Augment.VariabilityMax,
+		LossPerHour:         cfg.Augment.LossPerHour,
+		HalvingPerHour:      cfg.Augment.HalvingPerHour,
+		ContractionsPerHour: cfg.Augment.ContractionsPerHour,
+	}
+	if opts.ContractionsPerHour > 0 {
+		shapes, err := dataset.ClassShapes(cfg.Replay.DatasetDir, class)
+		if err != nil {
+			slog.Warn("Contraction mixing disabled", "class", class, "error", err)
+		}
+		opts.Shapes = shapes
+	}
+	return opts
+}
+
+// artifactsWrapper добавляет слой артефактов сигнала, если он включён в конфигурации
+func artifactsWrapper(cfg *config.Config) func(generator.DataGenerator) generator.DataGenerator {
+	return func(gen generator.DataGenerator) generator.DataGenerator {
+		if !cfg.Artifacts.Enabled {
+			return gen
+		}
+		return generatorAdapter.NewArtifactGenerator(gen, generatorAdapter.ArtifactConfig{
+			LossAsNaN:              cfg.Artifacts.LossValue == "nan",
+			SignalLossPerHour:      cfg.Artifacts.SignalLossPerHour,
+			SpikesPerHour:          cfg.Artifacts.SpikesPerHour,
+			HalvingDoublingPerHour: cfg.Artifacts.HalvingDoublingPerHour,
+			MaternalCapturePerHour: cfg.Artifacts.MaternalCapturePerHour,
+			UterusLossPerHour:      cfg.Artifacts.UterusLossPerHour,
+		})
+	}
+}
@@ -5,17 +5,34 @@ import (
 	"log"
 	"log/slog"
 	"net/http"
+	"slices"
 	"time"
 
 	"backend_gen/config"
-	generatorAdapter "backend_gen/internal/adapter/generator"
+	"backend_gen/internal/adapter/broadcast"
+	encodingAdapter "backend_gen/internal/adapter/encoding"
+	fhirAdapter "backend_gen/internal/adapter/fhir"
+	grpcAdapter "backend_gen/internal/adapter/grpc"
+	hl7Adapter "backend_gen/internal/adapter/hl7"
+	httpBatchAdapter "backend_gen/internal/adapter/httpbatch"
+	mqttAdapter "backend_gen/internal/adapter/mqtt"
 	wsAdapter "backend_gen/internal/adapter/websocket"
+	faultsHandler "backend_gen/internal/handlers/faults"
 	"backend_gen/internal/handlers/health"
+	marksHandler "backend_gen/internal/handlers/marks"
+	scenariosHandler "backend_gen/internal/handlers/scenarios"
+	stateHandler "backend_gen/internal/handlers/state"
+	streamHandler "backend_gen/internal/handlers/stream"
 	wsHandler "backend_gen/internal/handlers/websocket"
+	"backend_gen/internal/models/dto"
+	"backend_gen/internal/ports/export"
 	"backend_gen/internal/ports/generator"
 	"backend_gen/internal/ports/websocket"
 	"backend_gen/internal/usecase"
+	faultsUC "backend_gen/internal/usecase/faults"
 	healthUC "backend_gen/internal/usecase/health"
+	scenariosUC "backend_gen/internal/usecase/scenarios"
+	streamUC "backend_gen/internal/usecase/stream"
 	wsUC "backend_gen/internal/usecase/websocket"
 
 	"github.com/go-chi/chi/v5"
@@ -30,11 +47,21 @@ type Server struct {
 
 	// adapters
 	wsClient      websocket.Client
+	endpoint      string
 	dataGenerator generator.DataGenerator
+	scenarios     generator.ScenarioCatalog
+	encoders      map[string]websocket.Encoder
+	// transportEncodings форматы, которые переносит транспорт (nil = любые)
+	transportEncodings []string
+	broadcaster        websocket.Broadcaster
+	recorder           export.Recorder
 
 	// usecases
 	healthUC         usecase.HealthUseCase
 	websocketUseCase usecase.WebSocketUseCase
+	faultsUseCase    usecase.FaultsUseCase
+	scenariosUseCase usecase.ScenariosUseCase
+	streamUseCase    usecase.StreamUseCase
 }
 
 func New(cfg *config.Config) (*Server, error) {
@@ -46,21 +73,172 @@ func New(cfg *config.Config) (*Server, error) {
 }
 
 func (s *Server) init() error {
-	s.initAdapters()
+	if err := s.initAdapters(); err != nil {
+		return err
+	}
 	s.initUseCases()
 	s.initRouter()
 	s.initHTTPServer()
 	return nil
 }
 
-func (s *Server) initAdapters() {
-	s.wsClient = wsAdapter.NewClient()
-	s.dataGenerator = generatorAdapter.NewCTGGenerator(s.cfg.Generator.HypoxiaMode)
+func (s *Server) initAdapters() error {
+	s.encoders = encodingAdapter.Encoders()
+	s.encoders[hl7Adapter.FormatHL7] = hl7Adapter.NewEncoder(hl7Adapter.Config{
+		SendingApp:        s.cfg.HL7.SendingApp,
+		SendingFacility:   s.cfg.HL7.SendingFacility,
+		ReceivingApp:      s.cfg.HL7.ReceivingApp,
+		ReceivingFacility: s.cfg.HL7.ReceivingFacility,
+		PatientID:         s.cfg.HL7.PatientID,
+		SensorID:          s.cfg.Server.SensorID,
+	})
+	s.broadcaster = broadcast.NewHub()
+	if s.cfg.WebSocket.Encoding == "" {
+		s.cfg.WebSocket.Encoding = encodingAdapter.FormatJSON
+	}
+
+	newClient, err := s.initTransport()
+	if err != nil {
+		return err
+	}
+	if len(s.transportEncodings) > 0 && !slices.Contains(s.transportEncodings, s.cfg.WebSocket.Encoding) {
+		slog.Warn("Transport does not support the configured encoding, overriding",
+			"transport", s.cfg.Transport.Type, "encoding", s.cfg.WebSocket.Encoding, "supported", s.transportEncodings)
+		s.cfg.WebSocket.Encoding = s.transportEncodings[0]
+	}
+	// Клиент всегда обёрнут инъектором сбоев, чтобы их можно было включить во время сессии
+	s.wsClient = wsAdapter.NewFaultClient(newClient, websocket.FaultConfig{
+		Enabled:         s.cfg.Faults.Enabled,
+		Latency:         time.Duration(s.cfg.Faults.LatencyMs) * time.Millisecond,
+		Jitter:          time.Duration(s.cfg.Faults.JitterMs) * time.Millisecond,
+		DropRate:        s.cfg.Faults.DropRate,
+		DuplicateRate:   s.cfg.Faults.DuplicateRate,
+		ReorderRate:     s.cfg.Faults.ReorderRate,
+		DisconnectEvery: time.Duration(s.cfg.Faults.DisconnectEverySec * float64(time.Second)),
+		DisconnectRate:  s.cfg.Faults.DisconnectRate,
+		ReconnectAfter:  time.Duration(s.cfg.Faults.ReconnectAfterMs) * time.Millisecond,
+	})
+	if _, ok := s.encoders[s.cfg.WebSocket.Encoding]; !ok {
+		return fmt.Errorf("unknown encoding %q", s.cfg.WebSocket.Encoding)
+	}
+
+	if s.recorder, err = newRecorder(s.cfg); err != nil {
+		return err
+	}
+
+	s.dataGenerator, s.scenarios, err = NewGenerators(s.cfg)
+	return err
+}
+
+// initTransport возвращает конструктор клиента выбранного транспорта и задаёт
+// адрес бэкенда. Инъектор сбоев создаёт новый клиент на каждое подключение
+func (s *Server) initTransport() (func() websocket.Client, error) {
+	switch s.cfg.Transport.Type {
+	case "", "websocket":
+		s.endpoint = fmt.Sprintf("ws://%s:%s/ws/sensor?sensor_id=%s",
+			s.cfg.WebSocket.Addr, s.cfg.WebSocket.Port, s.cfg.Server.SensorID)
+		return wsAdapter.NewClient, nil
+	case "mqtt":
+		if s.cfg.MQTT.QoS < 0 || s.cfg.MQTT.QoS > 2 {
+			return nil, fmt.Errorf("mqtt qos must be 0, 1 or 2, got %d", s.cfg.MQTT.QoS)
+		}
+		if s.cfg.MQTT.Topic == "" {
+			return nil, fmt.Errorf("mqtt topic is required")
+		}
+		addr := fmt.Sprintf("%s:%s", s.cfg.MQTT.Addr, s.cfg.MQTT.Port)
+		s.endpoint = "tcp://" + addr
+		return func() websocket.Client {
+			return mqttAdapter.NewClient(mqttAdapter.Config{
+				SensorID:     s.cfg.Server.SensorID,
+				ClientID:     s.cfg.MQTT.ClientID,
+				Topic:        s.cfg.MQTT.Topic,
+				StatusTopic:  s.cfg.MQTT.StatusTopic,
+				QoS:          byte(s.cfg.MQTT.QoS),
+				RetainStatus: s.cfg.MQTT.RetainStatus,
+				KeepAlive:    time.Duration(s.cfg.MQTT.KeepAliveSec) * time.Second,
+			})
+		}, nil
+	case "grpc":
+		s.transportEncodings = grpcAdapter.Encodings
+		scheme := "http"
+		if s.cfg.GRPC.TLS {
+			scheme = "https"
+		}
+		s.endpoint = fmt.Sprintf("%s://%s:%s", scheme, s.cfg.GRPC.Addr, s.cfg.GRPC.Port)
+		return func() websocket.Client {
+			return grpcAdapter.NewClient(grpcAdapter.Config{
+				SensorID: s.cfg.Server.SensorID,
+				Buffer:   s.cfg.GRPC.Buffer,
+				Insecure: s.cfg.GRPC.Insecure,
+			})
+		}, nil
+	case "http":
+		s.transportEncodings = httpBatchAdapter.Encodings
+		if s.cfg.HTTPBatch.URL == "" {
+			return nil, fmt.Errorf("http batch url is required")
+		}
+		s.endpoint = s.cfg.HTTPBatch.URL
+		return func() websocket.Client {
+			return httpBatchAdapter.NewClient(httpBatchAdapter.Config{
+				SensorID:     s.cfg.Server.SensorID,
+				Interval:     time.Duration(s.cfg.HTTPBatch.IntervalMs) * time.Millisecond,
+				MaxBatch:     s.cfg.HTTPBatch.MaxBatch,
+				MaxBuffer:    s.cfg.HTTPBatch.MaxBuffer,
+				Retries:      s.cfg.HTTPBatch.Retries,
+				RetryBackoff: time.Duration(s.cfg.HTTPBatch.RetryBackoffMs) * time.Millisecond,
+				Timeout:      time.Duration(s.cfg.HTTPBatch.TimeoutMs) * time.Millisecond,
+				FlushTimeout: time.Duration(s.cfg.HTTPBatch.FlushTimeoutMs) * time.Millisecond,
+			})
+		}, nil
+	case "mllp":
+		s.transportEncodings = hl7Adapter.Encodings
+		s.endpoint = fmt.Sprintf("mllp://%s:%s", s.cfg.HL7.Addr, s.cfg.HL7.Port)
+		return func() websocket.Client {
+			return hl7Adapter.NewClient(hl7Adapter.ClientConfig{
+				AckTimeout: time.Duration(s.cfg.HL7.AckTimeoutMs) * time.Millisecond,
+				Retries:    s.cfg.HL7.Retries,
+			})
+		}, nil
+	case "fhir":
+		s.transportEncodings = fhirAdapter.Encodings
+		if s.cfg.FHIR.URL == "" {
+			return nil, fmt.Errorf("fhir url is required")
+		}
+		s.endpoint = s.cfg.FHIR.URL
+		return func() websocket.Client {
+			return fhirAdapter.NewClient(fhirAdapter.ClientConfig{
+				Observation: fhirAdapter.Config{
+					SensorID:  s.cfg.Server.SensorID,
+					PatientID: s.cfg.FHIR.PatientID,
+					Window:    time.Duration(s.cfg.FHIR.WindowSec * float64(time.Second)),
+				},
+				Retries:      s.cfg.FHIR.Retries,
+				RetryBackoff: time.Duration(s.cfg.FHIR.RetryBackoffMs) * time.Millisecond,
+				Timeout:      time.Duration(s.cfg.FHIR.TimeoutMs) * time.Millisecond,
+			})
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown transport %q", s.cfg.Transport.Type)
 }
 
 func (s *Server) initUseCases() {
 	s.healthUC = healthUC.NewHealthUseCase()
-	s.websocketUseCase = wsUC.NewWebSocketUseCase(s.wsClient, s.dataGenerator)
+	if injector, ok := s.wsClient.(websocket.FaultInjector); ok {
+		s.faultsUseCase = faultsUC.NewFaultsUseCase(injector)
+	}
+	s.scenariosUseCase = scenariosUC.NewScenariosUseCase(s.scenarios)
+	s.streamUseCase = streamUC.NewStreamUseCase(s.broadcaster, s.encoders)
+	s.websocketUseCase = wsUC.NewWebSocketUseCase(
+		s.wsClient,
+		s.broadcaster,
+		s.recorder,
+		s.dataGenerator,
+		s.scenarios,
+		s.encoders,
+		s.cfg.WebSocket.Encoding,
+		s.transportEncodings,
+		s.cfg.Generator.Annotations,
+	)
 }
 
 func (s *Server) initHTTPServer() {
@@ -77,25 +255,37 @@ func (s *Server) initRouter() {
 	s.router = chi.NewRouter()
 	s.router.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "PATCH", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "POST", "PATCH", "OPTIONS"},
 		AllowedHeaders:   []string{"Content-Type", "Content-Length"},
 		AllowCredentials: false,
 		MaxAge:           300,
 	}))
 
+	onSocket := wsHandler.OnSocket(
+		s.websocketUseCase,
+		s.faultsUseCase,
+		s.endpoint,
+		s.cfg.Server.SensorToken,
+		dto.Profile{
+			GestationalWeeks: s.cfg.Profile.GestationalWeeks,
+			MaternalAge:      s.cfg.Profile.MaternalAge,
+			Parity:           s.cfg.Profile.Parity,
+		},
+	)
+
 	s.router.Route("/api", func(r chi.Router) {
 		r.Get("/health", health.NewHealthHandler(s.healthUC))
-		r.Get(
-			"/on",
-			wsHandler.OnSocket(
-				s.websocketUseCase,
-				s.cfg.Server.SensorID,
-				s.cfg.Server.SensorToken,
-				s.cfg.WebSocket.Addr,
-				s.cfg.WebSocket.Port,
-			),
-		)
+		r.Get("/on", onSocket)
 		r.Get("/off", wsHandler.OffSocket(s.websocketUseCase))
+		r.Get("/status", wsHandler.Status(s.websocketUseCase))
+		r.Get("/faults", faultsHandler.GetFaults(s.faultsUseCase))
+		r.Patch("/faults", faultsHandler.SetFaults(s.faultsUseCase))
+		r.Post("/marks", marksHandler.AddMark(s.websocketUseCase))
+		r.Post("/state", stateHandler.SetFetalState(s.websocketUseCase))
+		r.Get("/scenarios", scenariosHandler.ListScenarios(s.scenariosUseCase))
+		r.Get("/scenarios/{name}/on", onSocket)
+		r.Get("/stream/sse", streamHandler.SSE(s.streamUseCase))
+		r.Get("/stream/ws", streamHandler.WebSocket(s.streamUseCase))
 	})
 }
 
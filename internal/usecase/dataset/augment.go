@@ -0,0 +1,93 @@
+package dataset
+
+import (
+	"backend_gen/internal/adapter/dataset"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"path/filepath"
+	"strconv"
+)
+
+// AugmentDatasetOptions параметры расширения датасета
+type AugmentDatasetOptions struct {
+	// Classes классы, записи которых размножаются
+	Classes []string
+	// Variants число вариантов каждой записи
+	Variants int
+	Seed     uint64
+	// Augment параметры преобразований; Shapes собираются по каждому классу
+	Augment dataset.AugmentOptions
+}
+
+// AugmentResult итог расширения датасета
+type AugmentResult struct {
+	Recordings int
+	Variants   map[string]int // класс -> записано вариантов
+	Shapes     map[string]int // класс -> найдено схваток для смешивания
+	Skipped    []string
+}
+
+// AugmentDataset записывает в out варианты записей классов opts.Classes из root
+// в той же структуре каталогов. Варианты лежат в каталоге исходного пациента
+// с ID <id>-aug<n>, поэтому разбиение по пациентам не разносит их по выборкам.
+// Результат зависит только от seed
+func AugmentDataset(root, out string, opts AugmentDatasetOptions) (*AugmentResult, error) {
+	if opts.Variants <= 0 {
+		return nil, fmt.Errorf("number of variants must be positive")
+	}
+	// Повторный запуск по тому же каталогу размножил бы уже созданные варианты
+	if filepath.Clean(root) == filepath.Clean(out) {
+		return nil, fmt.Errorf("output directory must differ from dataset root")
+	}
+	files, err := dataset.ScanFiles(root)
+	if err != nil {
+		return nil, err
+	}
+	patients := make(map[string][]string)
+	for _, file := range files {
+		if file.Channel != dataset.ChannelFHR {
+			continue
+		}
+		if list := patients[file.Class]; len(list) == 0 || list[len(list)-1] != file.Patient {
+			patients[file.Class] = append(list, file.Patient)
+		}
+	}
+
+	rng := rand.New(rand.NewPCG(opts.Seed, opts.Seed^0x9e3779b97f4a7c15))
+	result := &AugmentResult{Variants: make(map[string]int), Shapes: make(map[string]int)}
+	for _, class := range opts.Classes {
+		augment := opts.Augment
+		if augment.ContractionsPerHour > 0 {
+			if augment.Shapes, err = dataset.ClassShapes(root, class); err != nil {
+				return nil, err
+			}
+			result.Shapes[class] = len(augment.Shapes)
+		}
+
+		for _, patient := range patients[class] {
+			ids, err := dataset.ListRecordings(root, class, patient)
+			if err != nil {
+				return nil, err
+			}
+			for _, id := range ids {
+				rec, err := dataset.LoadRecording(root, class, patient, id)
+				if err != nil {
+					slog.Warn("Recording skipped", "class", class, "patient", patient, "recording", id, "error", err)
+					result.Skipped = append(result.Skipped, class+"/"+patient+"/"+id)
+					continue
+				}
+				result.Recordings++
+				for n := 1; n <= opts.Variants; n++ {
+					variant := dataset.Augment(rec, augment, rng)
+					variant.ID = id + "-aug" + strconv.Itoa(n)
+					if err := dataset.WriteRecording(out, variant); err != nil {
+						return nil, fmt.Errorf("failed to write variant of %s/%s/%s: %w", class, patient, id, err)
+					}
+					result.Variants[class]++
+				}
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,271 @@
+package dataset
+
+import (
+	"backend_gen/internal/adapter/dataset"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Имена выборок
+const (
+	SplitTrain = "train"
+	SplitVal   = "val"
+	SplitTest  = "test"
+)
+
+// SplitNames выборки в порядке распределения пациентов
+var SplitNames = []string{SplitTrain, SplitVal, SplitTest}
+
+// Labels метки классов
+var Labels = map[string]int{
+	dataset.ClassRegular: 0,
+	dataset.ClassHypoxia: 1,
+}
+
+// Channels каналы сегмента в порядке хранения
+var Channels = []string{"fhr", "uterus"}
+
+// SplitOptions параметры разбиения датасета
+type SplitOptions struct {
+	Seed uint64
+	// Доли пациентов в выборках validation и test; остальные - train
+	Val, Test float64
+	// Window и Stride длина и шаг сегментов, секунды (отсчёты раз в секунду)
+	Window, Stride int
+	// MaxMissing сегменты с большей долей потери сигнала FHR отбрасываются
+	MaxMissing float64
+}
+
+// Segment окно записи с меткой класса. Потеря сигнала - NaN
+type Segment struct {
+	Class     string
+	Label     int
+	Patient   string
+	Recording string
+	Start     int // секунда начала от начала записи
+	Missing   float64
+	FHR       []float64
+	Uterus    []float64
+}
+
+// SplitResult распределение пациентов и сегменты выборок
+type SplitResult struct {
+	// Patients пациенты (класс/номер) каждой выборки
+	Patients map[string][]string
+	Segments map[string][]Segment
+	// Groups пациенты с общими ID записей, оставленные в одной выборке
+	Groups [][]string
+	// Dropped число сегментов, отброшенных по MaxMissing
+	Dropped int
+	// Skipped записи, которые не удалось прочитать
+	Skipped []string
+}
+
+// Split делит пациентов на выборки со стратификацией по классу и нарезает их
+// записи на сегменты. Пациенты с общими ID записей (возможно, один и тот же
+// человек в разных каталогах) попадают в одну выборку. Результат зависит только от seed
+func Split(root string, opts SplitOptions) (*SplitResult, error) {
+	if opts.Val < 0 || opts.Test < 0 || opts.Val+opts.Test >= 1 {
+		return nil, fmt.Errorf("validation and test fractions must be non-negative and sum to less than 1")
+	}
+	if opts.Window <= 0 || opts.Stride <= 0 {
+		return nil, fmt.Errorf("window and stride must be positive")
+	}
+
+	files, err := dataset.ScanFiles(root)
+	if err != nil {
+		return nil, err
+	}
+	groups := groupPatients(files)
+	if len(groups) == 0 {
+		return nil, fmt.Errorf("no recordings found under %s", root)
+	}
+
+	// Страты - наборы классов групп; внутри страты группы перемешиваются и делятся по долям
+	strata := make(map[string][][]string)
+	for _, group := range groups {
+		strata[groupStratum(group)] = append(strata[groupStratum(group)], group)
+	}
+	names := make([]string, 0, len(strata))
+	for name := range strata {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rng := rand.New(rand.NewPCG(opts.Seed, opts.Seed^0x9e3779b97f4a7c15))
+	result := &SplitResult{Patients: make(map[string][]string), Segments: make(map[string][]Segment)}
+	for _, name := range names {
+		stratum := strata[name]
+		rng.Shuffle(len(stratum), func(i, j int) { stratum[i], stratum[j] = stratum[j], stratum[i] })
+
+		n := len(stratum)
+		val := int(math.Round(float64(n) * opts.Val))
+		test := int(math.Round(float64(n) * opts.Test))
+		// При достаточном числе групп каждая запрошенная выборка получает хотя бы одну
+		if n >= 3 {
+			if opts.Val > 0 && val == 0 {
+				val = 1
+			}
+			if opts.Test > 0 && test == 0 {
+				test = 1
+			}
+		}
+		for i, group := range stratum {
+			split := SplitTrain
+			switch {
+			case i < test:
+				split = SplitTest
+			case i < test+val:
+				split = SplitVal
+			}
+			result.Patients[split] = append(result.Patients[split], group...)
+		}
+	}
+	for _, group := range groups {
+		if len(group) > 1 {
+			result.Groups = append(result.Groups, group)
+		}
+	}
+
+	for _, split := range SplitNames {
+		patients := result.Patients[split]
+		sort.Slice(patients, func(i, j int) bool { return lessPatientKey(patients[i], patients[j]) })
+		for _, patient := range patients {
+			class, number, _ := strings.Cut(patient, "/")
+			segments, err := patientSegments(root, class, number, opts, result)
+			if err != nil {
+				return nil, err
+			}
+			result.Segments[split] = append(result.Segments[split], segments...)
+		}
+	}
+	return result, nil
+}
+
+// groupPatients объединяет пациентов (класс/номер) с общими ID записей
+func groupPatients(files []dataset.File) [][]string {
+	parent := make(map[string]string)
+	var find func(string) string
+	find = func(x string) string {
+		if parent[x] != x {
+			parent[x] = find(parent[x])
+		}
+		return parent[x]
+	}
+
+	owner := make(map[string]string) // ID записи -> первый пациент с ней
+	for _, file := range files {
+		if file.Channel != dataset.ChannelFHR {
+			continue
+		}
+		patient := file.Class + "/" + file.Patient
+		if _, ok := parent[patient]; !ok {
+			parent[patient] = patient
+		}
+		if other, ok := owner[file.ID]; ok {
+			parent[find(patient)] = find(other)
+		} else {
+			owner[file.ID] = patient
+		}
+	}
+
+	members := make(map[string][]string)
+	for patient := range parent {
+		root := find(patient)
+		members[root] = append(members[root], patient)
+	}
+	groups := make([][]string, 0, len(members))
+	for _, group := range members {
+		sort.Slice(group, func(i, j int) bool { return lessPatientKey(group[i], group[j]) })
+		groups = append(groups, group)
+	}
+	// Порядок групп до перемешивания фиксирован, чтобы результат зависел только от seed
+	sort.Slice(groups, func(i, j int) bool { return lessPatientKey(groups[i][0], groups[j][0]) })
+	return groups
+}
+
+// groupStratum возвращает набор классов группы, например regular или hypoxia+regular
+func groupStratum(group []string) string {
+	var classes []string
+	for _, patient := range group {
+		class, _, _ := strings.Cut(patient, "/")
+		if !slices.Contains(classes, class) {
+			classes = append(classes, class)
+		}
+	}
+	sort.Strings(classes)
+	return strings.Join(classes, "+")
+}
+
+// patientSegments нарезает записи пациента на окна
+func patientSegments(root, class, patient string, opts SplitOptions, result *SplitResult) ([]Segment, error) {
+	ids, err := dataset.ListRecordings(root, class, patient)
+	if err != nil {
+		return nil, err
+	}
+
+	var segments []Segment
+	for _, id := range ids {
+		rec, err := dataset.LoadRecording(root, class, patient, id)
+		if err != nil {
+			slog.Warn("Recording skipped", "class", class, "patient", patient, "recording", id, "error", err)
+			result.Skipped = append(result.Skipped, class+"/"+patient+"/"+id)
+			continue
+		}
+
+		// Отсчёты в CSV начинаются с time_sec = 1
+		duration := int(rec.Duration())
+		for start := 0; start+opts.Window <= duration; start += opts.Stride {
+			segment := Segment{
+				Class:     class,
+				Label:     Labels[class],
+				Patient:   patient,
+				Recording: id,
+				Start:     start,
+				FHR:       sample(rec.FHR, start, opts.Window),
+				Uterus:    sample(rec.Uterus, start, opts.Window),
+			}
+			missing := 0
+			for _, v := range segment.FHR {
+				if math.IsNaN(v) {
+					missing++
+				}
+			}
+			segment.Missing = float64(missing) / float64(opts.Window)
+			if segment.Missing > opts.MaxMissing {
+				result.Dropped++
+				continue
+			}
+			segments = append(segments, segment)
+		}
+	}
+	return segments, nil
+}
+
+// sample возвращает n посекундных отсчётов ряда с секунды start; пропуски и нули - NaN
+func sample(s dataset.Series, start, n int) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		v, ok := s.At(float64(start + i + 1))
+		if !ok || v == 0 {
+			v = math.NaN()
+		}
+		values[i] = v
+	}
+	return values
+}
+
+// lessPatientKey сравнивает ключи класс/номер: по классу, затем по номеру
+func lessPatientKey(a, b string) bool {
+	classA, patientA, _ := strings.Cut(a, "/")
+	classB, patientB, _ := strings.Cut(b, "/")
+	if classA != classB {
+		return slices.Index(dataset.Classes, classA) < slices.Index(dataset.Classes, classB)
+	}
+	return lessPatient(patientA, patientB)
+}
@@ -0,0 +1,181 @@
+package dataset
+
+import (
+	"backend_gen/internal/adapter/dataset"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Форматы файлов сегментов
+const (
+	FormatNPY = "npy" // <split>_X.npy (сегменты, каналы, отсчёты) float32 и <split>_y.npy int64
+	FormatCSV = "csv" // <split>_X.csv: строка на сегмент, колонки fhr_0.., uterus_0..
+)
+
+// Manifest описание выгрузки для воспроизводимости
+type Manifest struct {
+	Seed         uint64                   `json:"seed"`
+	Fractions    map[string]float64       `json:"fractions"`
+	WindowSec    int                      `json:"window_sec"`
+	StrideSec    int                      `json:"stride_sec"`
+	SampleRateHz float64                  `json:"sample_rate_hz"`
+	MaxMissing   float64                  `json:"max_missing"`
+	Format       string                   `json:"format"`
+	Channels     []string                 `json:"channels"`
+	Labels       map[string]int           `json:"labels"`
+	Splits       map[string]ManifestSplit `json:"splits"`
+	// PatientGroups пациенты с общими ID записей, оставленные в одной выборке
+	PatientGroups [][]string `json:"patient_groups,omitempty"`
+	Dropped       int        `json:"dropped_segments"`
+	Skipped       []string   `json:"skipped_recordings,omitempty"`
+}
+
+// ManifestSplit состав выборки
+type ManifestSplit struct {
+	Patients []string       `json:"patients"`
+	Segments int            `json:"segments"`
+	ByClass  map[string]int `json:"segments_by_class"`
+	Files    []string       `json:"files"`
+}
+
+// ExportSplit записывает выборки в dir: файлы сегментов в формате format,
+// индекс <split>_segments.csv и manifest.json
+func ExportSplit(result *SplitResult, opts SplitOptions, dir, format string) (*Manifest, error) {
+	if format != FormatNPY && format != FormatCSV {
+		return nil, fmt.Errorf("unknown split format %q", format)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{
+		Seed: opts.Seed,
+		Fractions: map[string]float64{
+			SplitTrain: 1 - opts.Val - opts.Test,
+			SplitVal:   opts.Val,
+			SplitTest:  opts.Test,
+		},
+		WindowSec:     opts.Window,
+		StrideSec:     opts.Stride,
+		SampleRateHz:  1,
+		MaxMissing:    opts.MaxMissing,
+		Format:        format,
+		Channels:      Channels,
+		Labels:        Labels,
+		Splits:        make(map[string]ManifestSplit),
+		PatientGroups: result.Groups,
+		Dropped:       result.Dropped,
+		Skipped:       result.Skipped,
+	}
+
+	for _, split := range SplitNames {
+		segments := result.Segments[split]
+		entry := ManifestSplit{
+			Patients: result.Patients[split],
+			Segments: len(segments),
+			ByClass:  make(map[string]int),
+		}
+		for _, s := range segments {
+			entry.ByClass[s.Class]++
+		}
+
+		index := split + "_segments.csv"
+		if err := writeSegmentIndex(filepath.Join(dir, index), split, segments); err != nil {
+			return nil, err
+		}
+		files, err := writeSegments(dir, split, format, segments, opts.Window)
+		if err != nil {
+			return nil, err
+		}
+		entry.Files = append(files, index)
+		manifest.Splits[split] = entry
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "manifest.json"), append(data, '\n'), 0o644); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// writeSegmentIndex пишет описание сегментов: строка индекса совпадает с номером сегмента в данных
+func writeSegmentIndex(path, split string, segments []Segment) error {
+	var b strings.Builder
+	b.WriteString("segment_id,class,label,patient,recording,start_sec,missing_fraction\n")
+	for i, s := range segments {
+		fmt.Fprintf(&b, "%s-%d,%s,%d,%s,%s,%d,%.3f\n", split, i, s.Class, s.Label, s.Patient, s.Recording, s.Start, s.Missing)
+	}
+	return os.WriteFile(path, []byte(b.String()), 0o644)
+}
+
+// writeSegments пишет отсчёты и метки сегментов, возвращает имена файлов
+func writeSegments(dir, split, format string, segments []Segment, window int) ([]string, error) {
+	if format == FormatNPY {
+		x := make([]float32, 0, len(segments)*len(Channels)*window)
+		y := make([]int64, 0, len(segments))
+		for _, s := range segments {
+			for _, channel := range [][]float64{s.FHR, s.Uterus} {
+				for _, v := range channel {
+					x = append(x, float32(v))
+				}
+			}
+			y = append(y, int64(s.Label))
+		}
+		xName, yName := split+"_X.npy", split+"_y.npy"
+		if err := dataset.WriteNPY(filepath.Join(dir, xName), []int{len(segments), len(Channels), window}, x); err != nil {
+			return nil, err
+		}
+		if err := dataset.WriteNPY(filepath.Join(dir, yName), []int{len(segments)}, y); err != nil {
+			return nil, err
+		}
+		return []string{xName, yName}, nil
+	}
+
+	// CSV читается numpy.loadtxt(..., delimiter=",", skiprows=1); метки - в индексе
+	name := split + "_X.csv"
+	file, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		return nil, err
+	}
+	w := bufio.NewWriter(file)
+	for c, channel := range Channels {
+		for i := range window {
+			if c > 0 || i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(channel + "_" + strconv.Itoa(i))
+		}
+	}
+	w.WriteByte('\n')
+	for _, s := range segments {
+		first := true
+		for _, channel := range [][]float64{s.FHR, s.Uterus} {
+			for _, v := range channel {
+				if !first {
+					w.WriteByte(',')
+				}
+				first = false
+				if math.IsNaN(v) {
+					w.WriteString("nan")
+				} else {
+					w.WriteString(strconv.FormatFloat(v, 'f', 2, 64))
+				}
+			}
+		}
+		w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return []string{name}, file.Close()
+}
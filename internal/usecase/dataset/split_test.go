@@ -0,0 +1,136 @@
+package dataset
+
+import (
+	"backend_gen/internal/adapter/dataset"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// splitDataset 10 пациентов regular и 5 hypoxia по одной записи на 120 с,
+// у regular/1 FHR из одних нулей. regular/11 и hypoxia/6 делят ID записи
+func splitDataset(t *testing.T) string {
+	root := t.TempDir()
+	add := func(class string, patient int, id string, fhr float64) {
+		writeRecording(t, root, &dataset.Recording{
+			Class: class, Patient: strconv.Itoa(patient), ID: id,
+			FHR:    series(120, func(int) float64 { return fhr }),
+			Uterus: series(120, func(int) float64 { return 10 }),
+		})
+	}
+	for n := 1; n <= 10; n++ {
+		fhr := 140.0
+		if n == 1 {
+			fhr = 0
+		}
+		add(dataset.ClassRegular, n, strconv.Itoa(1000+n), fhr)
+	}
+	for n := 1; n <= 5; n++ {
+		add(dataset.ClassHypoxia, n, strconv.Itoa(2000+n), 120)
+	}
+	add(dataset.ClassRegular, 11, "3000", 140)
+	add(dataset.ClassHypoxia, 6, "3000", 120)
+	return root
+}
+
+func TestSplitStratified(t *testing.T) {
+	root := splitDataset(t)
+	opts := SplitOptions{Seed: 7, Val: 0.2, Test: 0.2, Window: 60, Stride: 60, MaxMissing: 0.5}
+	result, err := Split(root, opts)
+	if err != nil {
+		t.Fatalf("split: %v", err)
+	}
+
+	// Доли считаются внутри каждого класса; смешанная группа из одной
+	// пары пациентов целиком уходит в train
+	want := map[string]map[string]int{
+		SplitTrain: {dataset.ClassRegular: 7, dataset.ClassHypoxia: 4},
+		SplitVal:   {dataset.ClassRegular: 2, dataset.ClassHypoxia: 1},
+		SplitTest:  {dataset.ClassRegular: 2, dataset.ClassHypoxia: 1},
+	}
+	seen := make(map[string]string)
+	for _, split := range SplitNames {
+		counts := make(map[string]int)
+		for _, patient := range result.Patients[split] {
+			if other, ok := seen[patient]; ok {
+				t.Errorf("patient %s is in %s and %s", patient, other, split)
+			}
+			seen[patient] = split
+			class, _, _ := strings.Cut(patient, "/")
+			counts[class]++
+		}
+		if !reflect.DeepEqual(counts, want[split]) {
+			t.Errorf("%s patients by class = %v, want %v", split, counts, want[split])
+		}
+
+		// По два окна на запись, кроме отброшенных окон regular/1
+		segments := 2 * len(result.Patients[split])
+		if seen["regular/1"] == split {
+			segments -= 2
+		}
+		if len(result.Segments[split]) != segments {
+			t.Errorf("%s has %d segments, want %d", split, len(result.Segments[split]), segments)
+		}
+		for _, s := range result.Segments[split] {
+			if s.Label != Labels[s.Class] || len(s.FHR) != 60 || len(s.Uterus) != 60 {
+				t.Errorf("segment %s/%s@%d: label %d, %d/%d samples", s.Class, s.Patient, s.Start, s.Label, len(s.FHR), len(s.Uterus))
+			}
+		}
+	}
+	if seen["regular/11"] != SplitTrain || seen["hypoxia/6"] != SplitTrain {
+		t.Errorf("shared group split into %s and %s", seen["regular/11"], seen["hypoxia/6"])
+	}
+	if wantGroups := [][]string{{"regular/11", "hypoxia/6"}}; !reflect.DeepEqual(result.Groups, wantGroups) {
+		t.Errorf("groups = %v, want %v", result.Groups, wantGroups)
+	}
+	if result.Dropped != 2 {
+		t.Errorf("dropped = %d, want 2", result.Dropped)
+	}
+
+	// Результат зависит только от seed
+	again, err := Split(root, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(again.Patients, result.Patients) {
+		t.Errorf("same seed gave %v, then %v", result.Patients, again.Patients)
+	}
+}
+
+func TestExportSplitNPY(t *testing.T) {
+	opts := SplitOptions{Seed: 7, Val: 0.2, Test: 0.2, Window: 60, Stride: 60, MaxMissing: 0.5}
+	result, err := Split(splitDataset(t), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	manifest, err := ExportSplit(result, opts, dir, FormatNPY)
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	for _, split := range SplitNames {
+		n := len(result.Segments[split])
+		if manifest.Splits[split].Segments != n {
+			t.Errorf("%s manifest segments = %d, want %d", split, manifest.Splits[split].Segments, n)
+		}
+		for name, shape := range map[string]string{
+			split + "_X.npy": fmt.Sprintf("'shape': (%d, 2, 60)", n),
+			split + "_y.npy": fmt.Sprintf("'shape': (%d,)", n),
+		} {
+			data, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(data[:128]), shape) {
+				t.Errorf("%s header %q does not contain %s", name, data[10:128], shape)
+			}
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "manifest.json")); err != nil {
+		t.Error(err)
+	}
+}
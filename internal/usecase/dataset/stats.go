@@ -0,0 +1,233 @@
+package dataset
+
+import (
+	"backend_gen/internal/adapter/dataset"
+	"math"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+)
+
+// Summary сводная статистика группы записей. Показатели усредняются
+// с весом по длительности записей
+type Summary struct {
+	Recordings int     `json:"recordings"`
+	Hours      float64 `json:"hours"`
+	// BaselineBPM базальный ритм: медиана достоверных отсчётов FHR
+	BaselineBPM float64 `json:"baseline_bpm"`
+	// VariabilityBPM амплитуда вариабельности: размах FHR за минуту
+	VariabilityBPM float64 `json:"variability_bpm"`
+	// ContractionsPer10Min частота схваток по каналу тонуса
+	ContractionsPer10Min float64 `json:"contractions_per_10min"`
+	// SignalLossPct доля потери сигнала FHR: пропуски, нули и значения вне диапазона
+	SignalLossPct float64 `json:"signal_loss_pct"`
+}
+
+// PatientStats статистика пациента
+type PatientStats struct {
+	Class   string `json:"class"`
+	Patient string `json:"patient"`
+	Summary
+}
+
+// Stats статистика датасета по классам и пациентам
+type Stats struct {
+	Total    Summary            `json:"total"`
+	Classes  map[string]Summary `json:"classes"`
+	Patients []PatientStats     `json:"patients"`
+	// Skipped записи без читаемого канала FHR
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+// recordingStats показатели одной записи
+type recordingStats struct {
+	duration    float64
+	baseline    float64
+	variability float64
+	lost        float64 // секунды потери сигнала FHR
+	// Схватки считаются только по записям с каналом тонуса
+	contractions   int
+	uterusDuration float64
+}
+
+// accumulator накапливает взвешенные суммы показателей группы
+type accumulator struct {
+	recordings        int
+	duration          float64
+	baseline          float64
+	variability       float64
+	variabilityWeight float64
+	lost              float64
+	contractions      int
+	uterusDuration    float64
+}
+
+func (a *accumulator) add(r recordingStats) {
+	a.recordings++
+	a.duration += r.duration
+	a.baseline += r.baseline * r.duration
+	if !math.IsNaN(r.variability) {
+		a.variability += r.variability * r.duration
+		a.variabilityWeight += r.duration
+	}
+	a.lost += r.lost
+	a.contractions += r.contractions
+	a.uterusDuration += r.uterusDuration
+}
+
+func (a *accumulator) summary() Summary {
+	s := Summary{Recordings: a.recordings, Hours: round(a.duration / 3600)}
+	if a.duration > 0 {
+		s.BaselineBPM = round(a.baseline / a.duration)
+		s.SignalLossPct = round(a.lost / a.duration * 100)
+	}
+	if a.variabilityWeight > 0 {
+		s.VariabilityBPM = round(a.variability / a.variabilityWeight)
+	}
+	if a.uterusDuration > 0 {
+		s.ContractionsPer10Min = round(float64(a.contractions) / a.uterusDuration * 600)
+	}
+	return s
+}
+
+// ComputeStats считает статистику по записям каталогов классов в root.
+// Достоверность отсчётов FHR определяется диапазоном rules
+func ComputeStats(root string, rules Rules) (*Stats, error) {
+	files, err := dataset.ScanFiles(root)
+	if err != nil {
+		return nil, err
+	}
+
+	// Записи с каналами, в порядке обхода
+	var keys []recordingKey
+	channels := make(map[recordingKey]map[dataset.Channel]string)
+	for _, file := range files {
+		if file.Channel == "" {
+			continue
+		}
+		key := recordingKey{file.Class, file.Patient, file.ID}
+		if channels[key] == nil {
+			channels[key] = make(map[dataset.Channel]string)
+			keys = append(keys, key)
+		}
+		channels[key][file.Channel] = file.Path
+	}
+
+	stats := &Stats{Classes: make(map[string]Summary)}
+	var total accumulator
+	classes := make(map[string]*accumulator)
+	patients := make(map[[2]string]*accumulator)
+
+	for _, key := range keys {
+		r, ok := recordingStatsOf(channels[key], rules)
+		if !ok {
+			stats.Skipped = append(stats.Skipped, filepath.ToSlash(filepath.Join(key.class, key.patient, key.id)))
+			continue
+		}
+		total.add(r)
+		if classes[key.class] == nil {
+			classes[key.class] = &accumulator{}
+		}
+		classes[key.class].add(r)
+		patient := [2]string{key.class, key.patient}
+		if patients[patient] == nil {
+			patients[patient] = &accumulator{}
+		}
+		patients[patient].add(r)
+	}
+
+	stats.Total = total.summary()
+	for class, a := range classes {
+		stats.Classes[class] = a.summary()
+	}
+	for patient, a := range patients {
+		stats.Patients = append(stats.Patients, PatientStats{Class: patient[0], Patient: patient[1], Summary: a.summary()})
+	}
+	sort.Slice(stats.Patients, func(i, j int) bool {
+		a, b := stats.Patients[i], stats.Patients[j]
+		if a.Class != b.Class {
+			return slices.Index(dataset.Classes, a.Class) < slices.Index(dataset.Classes, b.Class)
+		}
+		return lessPatient(a.Patient, b.Patient)
+	})
+	return stats, nil
+}
+
+// recordingStatsOf считает показатели записи; ok = false, если канал FHR не читается
+func recordingStatsOf(paths map[dataset.Channel]string, rules Rules) (recordingStats, bool) {
+	path, ok := paths[dataset.ChannelFHR]
+	if !ok {
+		return recordingStats{}, false
+	}
+	fhr, err := dataset.ReadSeries(path)
+	if err != nil || fhr.Duration() <= 0 {
+		return recordingStats{}, false
+	}
+
+	r := recordingStats{duration: fhr.Duration(), variability: math.NaN()}
+	valid := func(v float64) bool { return v >= rules.FHRMin && v <= rules.FHRMax }
+
+	var values []float64
+	minutes := make(map[int][2]float64) // минута -> {минимум, максимум}
+	counts := make(map[int]int)
+	previous := 0.0
+	for i, t := range fhr.Time {
+		if gap := t - previous - 1; gap > 0 {
+			r.lost += gap
+		}
+		previous = t
+		v := fhr.Value[i]
+		if !valid(v) {
+			r.lost++
+			continue
+		}
+		values = append(values, v)
+		minute := int(t / 60)
+		bounds, seen := minutes[minute]
+		if !seen {
+			bounds = [2]float64{v, v}
+		}
+		minutes[minute] = [2]float64{math.Min(bounds[0], v), math.Max(bounds[1], v)}
+		counts[minute]++
+	}
+	r.lost = math.Min(r.lost, r.duration)
+	if len(values) == 0 {
+		return recordingStats{}, false
+	}
+	r.baseline = dataset.Median(values)
+
+	// Размах считается по минутам, где есть хотя бы половина отсчётов
+	sum, n := 0.0, 0
+	for minute, bounds := range minutes {
+		if counts[minute] >= 30 {
+			sum += bounds[1] - bounds[0]
+			n++
+		}
+	}
+	if n > 0 {
+		r.variability = sum / float64(n)
+	}
+
+	if path, ok := paths[dataset.ChannelUterus]; ok {
+		if uterus, err := dataset.ReadSeries(path); err == nil && uterus.Duration() > 0 {
+			r.contractions = len(dataset.FindContractions(uterus, dataset.ContractionRise, dataset.ContractionMinDuration))
+			r.uterusDuration = uterus.Duration()
+		}
+	}
+	return r, true
+}
+
+// lessPatient сравнивает номера пациентов как числа, если это возможно
+func lessPatient(a, b string) bool {
+	x, errX := strconv.Atoi(a)
+	y, errY := strconv.Atoi(b)
+	if errX == nil && errY == nil {
+		return x < y
+	}
+	return a < b
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
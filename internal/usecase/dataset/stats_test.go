@@ -0,0 +1,112 @@
+package dataset
+
+import (
+	"backend_gen/internal/adapter/dataset"
+	"reflect"
+	"testing"
+)
+
+// series посекундный ряд t = 1..n; value < 0 - отсчёт пропущен
+func series(n int, value func(t int) float64) dataset.Series {
+	var s dataset.Series
+	for t := 1; t <= n; t++ {
+		if v := value(t); v >= 0 {
+			s.Time = append(s.Time, float64(t))
+			s.Value = append(s.Value, v)
+		}
+	}
+	return s
+}
+
+func writeRecording(t *testing.T, root string, rec *dataset.Recording) {
+	t.Helper()
+	if err := dataset.WriteRecording(root, rec); err != nil {
+		t.Fatalf("write recording: %v", err)
+	}
+}
+
+// statsDataset датасет с заранее посчитанными показателями:
+//   - regular/1/1: 10 минут, FHR чередует 135 и 145 (базальный 140, размах 10),
+//     пропуск 10 с и два нуля (потеря 12 с), две схватки по 60 с;
+//   - regular/1/2: FHR из одних нулей, пропускается;
+//   - hypoxia/2/1: 20 минут, FHR 120 без вариабельности, без канала тонуса
+func statsDataset(t *testing.T) string {
+	root := t.TempDir()
+	writeRecording(t, root, &dataset.Recording{
+		Class: dataset.ClassRegular, Patient: "1", ID: "1",
+		FHR: series(600, func(t int) float64 {
+			switch {
+			case t > 100 && t <= 110:
+				return -1
+			case t == 200 || t == 201:
+				return 0
+			}
+			return float64(135 + 10*(t%2))
+		}),
+		Uterus: series(600, func(t int) float64 {
+			if t >= 100 && t < 160 || t >= 400 && t < 460 {
+				return 30
+			}
+			return 10
+		}),
+	})
+	writeRecording(t, root, &dataset.Recording{
+		Class: dataset.ClassRegular, Patient: "1", ID: "2",
+		FHR:    series(600, func(int) float64 { return 0 }),
+		Uterus: series(600, func(int) float64 { return 10 }),
+	})
+	writeRecording(t, root, &dataset.Recording{
+		Class: dataset.ClassHypoxia, Patient: "2", ID: "1",
+		FHR: series(1200, func(int) float64 { return 120 }),
+	})
+	return root
+}
+
+func TestComputeStats(t *testing.T) {
+	stats, err := ComputeStats(statsDataset(t), DefaultRules())
+	if err != nil {
+		t.Fatalf("compute: %v", err)
+	}
+
+	regular := Summary{Recordings: 1, Hours: 0.17, BaselineBPM: 140, VariabilityBPM: 10, ContractionsPer10Min: 2, SignalLossPct: 2}
+	hypoxia := Summary{Recordings: 1, Hours: 0.33, BaselineBPM: 120}
+	// Итог взвешен по длительности: 600 с и 1200 с; схватки - только по записи с тонусом
+	total := Summary{Recordings: 2, Hours: 0.5, BaselineBPM: 126.67, VariabilityBPM: 3.33, ContractionsPer10Min: 2, SignalLossPct: 0.67}
+
+	if stats.Total != total {
+		t.Errorf("total = %+v\nwant  %+v", stats.Total, total)
+	}
+	if got := stats.Classes[dataset.ClassRegular]; got != regular {
+		t.Errorf("regular = %+v\nwant    %+v", got, regular)
+	}
+	if got := stats.Classes[dataset.ClassHypoxia]; got != hypoxia {
+		t.Errorf("hypoxia = %+v\nwant    %+v", got, hypoxia)
+	}
+	wantPatients := []PatientStats{
+		{Class: dataset.ClassRegular, Patient: "1", Summary: regular},
+		{Class: dataset.ClassHypoxia, Patient: "2", Summary: hypoxia},
+	}
+	if !reflect.DeepEqual(stats.Patients, wantPatients) {
+		t.Errorf("patients = %+v\nwant %+v", stats.Patients, wantPatients)
+	}
+	if want := []string{"regular/1/2"}; !reflect.DeepEqual(stats.Skipped, want) {
+		t.Errorf("skipped = %v, want %v", stats.Skipped, want)
+	}
+}
+
+func TestLessPatient(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want bool
+	}{
+		{"2", "10", true},
+		{"10", "2", false},
+		{"a", "b", true},
+		{"10", "a", true},
+	}
+	for _, c := range cases {
+		if got := lessPatient(c.a, c.b); got != c.want {
+			t.Errorf("lessPatient(%q, %q) = %v, want %v", c.a, c.b, got, c.want)
+		}
+	}
+}
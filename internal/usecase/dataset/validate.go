@@ -0,0 +1,339 @@
+package dataset
+
+import (
+	"backend_gen/internal/adapter/dataset"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Rules пороги проверок и очистки датасета
+type Rules struct {
+	// Физиологические диапазоны; ноль FHR - потеря сигнала и диапазоном не
+	// проверяется, тонус 0 - допустимое значение
+	FHRMin, FHRMax       float64
+	UterusMin, UterusMax float64
+	// MaxGap пропуск длиннее MaxGap секунд отмечается отдельно
+	MaxGap float64
+	// MaxLoss допустимая доля потери сигнала (пропуски и нули FHR)
+	MaxLoss float64
+	// MinDuration минимальная длительность записи, секунды
+	MinDuration float64
+	// LengthTolerance допустимая разница длительностей каналов записи, секунды
+	LengthTolerance float64
+	// StartDeviation отклонение FHR от базального ритма в начале записи,
+	// после которого отсчёты считаются артефактом установки датчика
+	StartDeviation float64
+}
+
+// DefaultRules пороги по умолчанию
+func DefaultRules() Rules {
+	return Rules{
+		FHRMin:          50,
+		FHRMax:          200,
+		UterusMin:       0,
+		UterusMax:       100,
+		MaxGap:          10,
+		MaxLoss:         0.2,
+		MinDuration:     300,
+		LengthTolerance: 30,
+		StartDeviation:  25,
+	}
+}
+
+// Уровни замечаний: с ошибками запись нельзя использовать без исправления
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Issue замечание к файлу или записи
+type Issue struct {
+	Path     string `json:"path"`
+	Severity string `json:"severity"`
+	Check    string `json:"check"`
+	Message  string `json:"message"`
+}
+
+// Report результат проверки датасета
+type Report struct {
+	Files      int     `json:"files"`
+	Recordings int     `json:"recordings"`
+	Issues     []Issue `json:"issues"`
+	// Cleaned число записанных очищенных файлов, Removed - удалённых отсчётов
+	Cleaned int `json:"cleaned"`
+	Removed int `json:"removed"`
+}
+
+// Count возвращает число замечаний уровня severity
+func (r *Report) Count(severity string) int {
+	n := 0
+	for _, issue := range r.Issues {
+		if issue.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+// ByCheck возвращает число замечаний по видам проверок
+func (r *Report) ByCheck() map[string]int {
+	counts := make(map[string]int)
+	for _, issue := range r.Issues {
+		counts[issue.Check]++
+	}
+	return counts
+}
+
+func (r *Report) add(path, severity, check, format string, args ...any) {
+	r.Issues = append(r.Issues, Issue{Path: path, Severity: severity, Check: check, Message: fmt.Sprintf(format, args...)})
+}
+
+// recordingKey запись пациента
+type recordingKey struct {
+	class, patient, id string
+}
+
+// Validate проверяет все файлы каталогов классов в root. Если cleanDir не пустой,
+// очищенные копии читаемых файлов записываются туда с той же структурой каталогов
+func Validate(root string, rules Rules, cleanDir string) (*Report, error) {
+	files, err := dataset.ScanFiles(root)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{Files: len(files)}
+
+	// Длительности каналов по записям и пациенты каждого ID
+	durations := make(map[recordingKey]map[dataset.Channel]float64)
+	owners := make(map[string][]string)
+
+	for _, file := range files {
+		rel := relative(root, file.Path)
+		if file.Channel == "" {
+			report.add(rel, SeverityWarning, "name", "file name does not match <id>_<channel>.csv")
+			continue
+		}
+
+		series, err := dataset.ReadSeries(file.Path)
+		if err != nil {
+			report.add(rel, SeverityError, "read", "%v", strings.TrimPrefix(err.Error(), file.Path+": "))
+			continue
+		}
+		fhr := file.Channel == dataset.ChannelFHR || file.Channel == dataset.ChannelFHR2
+		checkSeries(report, rel, series, fhr, rules)
+
+		key := recordingKey{file.Class, file.Patient, file.ID}
+		if durations[key] == nil {
+			durations[key] = make(map[dataset.Channel]float64)
+			owners[file.ID] = append(owners[file.ID], file.Class+"/"+file.Patient)
+		}
+		durations[key][file.Channel] = series.Duration()
+
+		if cleanDir != "" && len(series.Time) > 0 {
+			cleaned, removed := Clean(series, fhr, rules)
+			if err := dataset.WriteSeries(filepath.Join(cleanDir, rel), cleaned); err != nil {
+				return nil, fmt.Errorf("failed to write cleaned copy of %s: %w", rel, err)
+			}
+			report.Cleaned++
+			report.Removed += removed
+		}
+	}
+
+	report.Recordings = len(durations)
+	keys := make([]recordingKey, 0, len(durations))
+	for key := range durations {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		return a.class+"/"+a.patient+"/"+a.id < b.class+"/"+b.patient+"/"+b.id
+	})
+	for _, key := range keys {
+		checkRecording(report, key, durations[key], owners[key.id], rules)
+	}
+
+	sort.SliceStable(report.Issues, func(i, j int) bool { return report.Issues[i].Path < report.Issues[j].Path })
+	return report, nil
+}
+
+// checkSeries проверяет один файл канала
+func checkSeries(report *Report, path string, s dataset.Series, fhr bool, rules Rules) {
+	if len(s.Time) == 0 {
+		report.add(path, SeverityError, "empty", "file has no samples")
+		return
+	}
+
+	unordered := 0
+	for i := 1; i < len(s.Time); i++ {
+		if s.Time[i] <= s.Time[i-1] {
+			unordered++
+		}
+	}
+	if unordered > 0 {
+		report.add(path, SeverityError, "time_order", "time_sec is not increasing at %d samples", unordered)
+	}
+
+	low, high, unit := rules.UterusMin, rules.UterusMax, "mmHg"
+	if fhr {
+		low, high, unit = rules.FHRMin, rules.FHRMax, "bpm"
+	}
+	outside, firstOutside, zeros := 0, -1, 0
+	for i, v := range s.Value {
+		switch {
+		case fhr && v == 0:
+			zeros++
+		case v < low || v > high || math.IsNaN(v):
+			outside++
+			if firstOutside < 0 {
+				firstOutside = i
+			}
+		}
+	}
+	if outside > 0 {
+		report.add(path, SeverityWarning, "range", "%d samples outside %g..%g %s (first %g at %gs)",
+			outside, low, high, unit, s.Value[firstOutside], s.Time[firstOutside])
+	}
+
+	if fhr {
+		if n, baseline := startArtifact(s, rules); n > 0 {
+			report.add(path, SeverityWarning, "start_artifact", "first %d samples deviate from baseline %.0f bpm (starts at %g bpm)",
+				n, baseline, s.Value[0])
+		}
+	}
+
+	// Потеря сигнала: пропуски во времени и нулевые значения FHR. Интервал
+	// до полутора шагов отсчётов пропуском не считается
+	step := s.Step
+	if step <= 0 {
+		step = 1
+	}
+	missing, gaps, longest, longestAt := 0.0, 0, 0.0, 0.0
+	for i := 1; i < len(s.Time); i++ {
+		interval := s.Time[i] - s.Time[i-1]
+		if interval <= 1.5*step {
+			continue
+		}
+		gap := interval - step
+		missing += gap
+		if gap > rules.MaxGap {
+			gaps++
+		}
+		if gap > longest {
+			longest, longestAt = gap, s.Time[i-1]
+		}
+	}
+	if gaps > 0 {
+		report.add(path, SeverityWarning, "gaps", "%d gaps longer than %gs, longest %gs at %gs", gaps, rules.MaxGap, longest, longestAt)
+	}
+	if duration := s.Duration(); duration > 0 {
+		if loss := (missing + float64(zeros)*step) / duration; loss > rules.MaxLoss {
+			report.add(path, SeverityWarning, "signal_loss", "%.0f%% of signal lost (%d zero samples, %gs of gaps)",
+				loss*100, zeros, missing)
+		}
+		if duration < rules.MinDuration {
+			report.add(path, SeverityWarning, "duration", "recording is %gs long, expected at least %gs", duration, rules.MinDuration)
+		}
+	}
+}
+
+// checkRecording проверяет согласованность каналов записи и уникальность ID
+func checkRecording(report *Report, key recordingKey, durations map[dataset.Channel]float64, owners []string, rules Rules) {
+	path := key.class + "/" + key.patient + "/" + key.id
+
+	if len(owners) > 1 {
+		report.add(path, SeverityError, "duplicate_id", "recording ID is used by %s", strings.Join(owners, ", "))
+	}
+
+	pairs := []struct {
+		fhr, uterus dataset.Channel
+		severity    string
+	}{
+		{dataset.ChannelFHR, dataset.ChannelUterus, SeverityError},
+		{dataset.ChannelFHR2, dataset.ChannelUterus2, SeverityWarning},
+	}
+	for _, pair := range pairs {
+		fhr, hasFHR := durations[pair.fhr]
+		uterus, hasUterus := durations[pair.uterus]
+		switch {
+		case hasFHR && !hasUterus:
+			report.add(path, pair.severity, "missing_channel", "%s has no %s", dataset.FileName(key.id, pair.fhr), dataset.FileName(key.id, pair.uterus))
+		case !hasFHR && hasUterus:
+			report.add(path, pair.severity, "missing_channel", "%s has no %s", dataset.FileName(key.id, pair.uterus), dataset.FileName(key.id, pair.fhr))
+		case hasFHR && hasUterus && math.Abs(fhr-uterus) > rules.LengthTolerance:
+			report.add(path, SeverityWarning, "length_mismatch", "channels %s and %s differ in length: %gs vs %gs",
+				pair.fhr, pair.uterus, fhr, uterus)
+		}
+	}
+}
+
+// startArtifact возвращает число отсчётов в начале записи, отклоняющихся от
+// базального ритма первых двух минут больше чем на StartDeviation
+func startArtifact(s dataset.Series, rules Rules) (int, float64) {
+	const (
+		window   = 120 // отсчётов для оценки базального ритма
+		maxCount = 30  // более длинное отклонение - уже не артефакт установки
+	)
+	var values []float64
+	for _, v := range s.Value[:min(window, len(s.Value))] {
+		if v != 0 {
+			values = append(values, v)
+		}
+	}
+	if len(values) < 10 {
+		return 0, 0
+	}
+	baseline := dataset.Median(values)
+
+	n := 0
+	for n < len(s.Value) && n < maxCount && (s.Value[n] == 0 || math.Abs(s.Value[n]-baseline) > rules.StartDeviation) {
+		n++
+	}
+	if n == maxCount {
+		return 0, baseline
+	}
+	return n, baseline
+}
+
+// Clean возвращает очищенную копию ряда и число удалённых отсчётов: отсчёты
+// упорядочиваются по времени без повторов, нули FHR и значения вне диапазона
+// становятся пропусками, артефакт установки датчика в начале FHR отрезается
+func Clean(s dataset.Series, fhr bool, rules Rules) (dataset.Series, int) {
+	order := make([]int, len(s.Time))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return s.Time[order[a]] < s.Time[order[b]] })
+
+	skip := 0
+	if fhr {
+		skip, _ = startArtifact(s, rules)
+	}
+	low, high := rules.UterusMin, rules.UterusMax
+	if fhr {
+		low, high = rules.FHRMin, rules.FHRMax
+	}
+
+	cleaned := dataset.Series{Step: s.Step}
+	for _, i := range order {
+		t, v := s.Time[i], s.Value[i]
+		if i < skip || (fhr && v == 0) || v < low || v > high || math.IsNaN(v) {
+			continue
+		}
+		if n := len(cleaned.Time); n > 0 && t <= cleaned.Time[n-1] {
+			continue
+		}
+		cleaned.Time = append(cleaned.Time, t)
+		cleaned.Value = append(cleaned.Value, v)
+	}
+	return cleaned, len(s.Time) - len(cleaned.Time)
+}
+
+// relative возвращает путь относительно корня датасета
+func relative(root, path string) string {
+	if rel, err := filepath.Rel(root, path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
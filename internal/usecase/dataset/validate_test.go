@@ -0,0 +1,163 @@
+package dataset
+
+import (
+	"backend_gen/internal/adapter/dataset"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// issueSet замечания в виде путь -> виды проверок
+func issueSet(report *Report) map[string][]string {
+	set := make(map[string][]string)
+	for _, issue := range report.Issues {
+		set[issue.Path] = append(set[issue.Path], issue.Check)
+	}
+	return set
+}
+
+func TestValidate(t *testing.T) {
+	root := t.TempDir()
+	// Чистая запись: тонус 0 - допустимое значение, а не потеря сигнала
+	writeRecording(t, root, &dataset.Recording{
+		Class: dataset.ClassRegular, Patient: "1", ID: "1",
+		FHR:    series(600, func(int) float64 { return 140 }),
+		Uterus: series(600, func(int) float64 { return 0 }),
+	})
+	// Пропуск 20 с, нули FHR и значения вне диапазона
+	writeRecording(t, root, &dataset.Recording{
+		Class: dataset.ClassRegular, Patient: "1", ID: "2",
+		FHR: series(600, func(t int) float64 {
+			switch {
+			case t > 200 && t <= 220:
+				return -1
+			case t > 300 && t <= 420:
+				return 0
+			case t == 500:
+				return 250
+			}
+			return 140
+		}),
+		Uterus: series(600, func(int) float64 { return 10 }),
+	})
+	// Канал FHR без тонуса и тот же ID у другого пациента
+	fhrOnly := series(600, func(int) float64 { return 140 })
+	if err := dataset.WriteSeries(filepath.Join(root, dataset.ClassHypoxia, "2", dataset.DirFHR, dataset.FileName("1", dataset.ChannelFHR)), fhrOnly); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, dataset.ClassHypoxia, "2", dataset.DirFHR, "notes.csv"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := Validate(root, DefaultRules(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Files != 6 || report.Recordings != 3 {
+		t.Errorf("files = %d, recordings = %d, want 6 and 3", report.Files, report.Recordings)
+	}
+	want := map[string][]string{
+		"hypoxia/2/1":             {"duplicate_id", "missing_channel"},
+		"hypoxia/2/bpm/notes.csv": {"name"},
+		"regular/1/1":             {"duplicate_id"},
+		"regular/1/bpm/2_1.csv":   {"range", "gaps", "signal_loss"},
+	}
+	if got := issueSet(report); !reflect.DeepEqual(got, want) {
+		t.Errorf("issues = %v, want %v", got, want)
+	}
+	if report.Count(SeverityError) != 3 {
+		t.Errorf("errors = %d, want 3: %+v", report.Count(SeverityError), report.Issues)
+	}
+}
+
+func TestValidateCleanCopy(t *testing.T) {
+	root, cleanDir := t.TempDir(), t.TempDir()
+	writeRecording(t, root, &dataset.Recording{
+		Class: dataset.ClassRegular, Patient: "1", ID: "1",
+		FHR: series(400, func(t int) float64 {
+			if t%100 == 0 {
+				return 0
+			}
+			return 140
+		}),
+		Uterus: series(400, func(int) float64 { return 0 }),
+	})
+
+	report, err := Validate(root, DefaultRules(), cleanDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Удалены только нули FHR; тонус 0 сохранён
+	if report.Cleaned != 2 || report.Removed != 4 {
+		t.Errorf("cleaned = %d, removed = %d, want 2 and 4", report.Cleaned, report.Removed)
+	}
+	rec, err := dataset.LoadRecording(cleanDir, dataset.ClassRegular, "1", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.FHR.Time) != 396 || len(rec.Uterus.Time) != 400 {
+		t.Errorf("cleaned samples: FHR %d, uterus %d", len(rec.FHR.Time), len(rec.Uterus.Time))
+	}
+}
+
+func TestCheckSeriesStep(t *testing.T) {
+	// Отсчёты раз в 4 с: интервал равен шагу и пропуском не считается
+	s := dataset.Series{Step: 4}
+	for t := 4.0; t <= 600; t += 4 {
+		s.Time = append(s.Time, t)
+		s.Value = append(s.Value, 140)
+	}
+	report := &Report{}
+	checkSeries(report, "sparse", s, true, DefaultRules())
+	if len(report.Issues) != 0 {
+		t.Errorf("issues with step 4 s: %+v", report.Issues)
+	}
+
+	// Без шага ряд считается посекундным: три четверти времени потеряно
+	s.Step = 0
+	report = &Report{}
+	checkSeries(report, "sparse", s, true, DefaultRules())
+	if got := issueSet(report)["sparse"]; !reflect.DeepEqual(got, []string{"signal_loss"}) {
+		t.Errorf("issues without step = %v, want signal_loss", got)
+	}
+}
+
+func TestClean(t *testing.T) {
+	rules := DefaultRules()
+	s := dataset.Series{
+		Time:  []float64{3, 1, 2, 2, 4, 5},
+		Value: []float64{0, 140, 141, 150, 300, 0},
+		Step:  1,
+	}
+
+	// Порядок по времени, повтор t = 2 и значение вне диапазона удалены,
+	// нули FHR - потеря сигнала
+	fhr, removed := Clean(s, true, rules)
+	want := dataset.Series{Time: []float64{1, 2}, Value: []float64{140, 141}, Step: 1}
+	if !reflect.DeepEqual(fhr, want) || removed != 4 {
+		t.Errorf("FHR = %+v (removed %d), want %+v", fhr, removed, want)
+	}
+
+	// Для тонуса ноль - допустимое значение
+	s = dataset.Series{Time: []float64{3, 1, 2, 4}, Value: []float64{0, 10, 12, 150}, Step: 1}
+	uterus, removed := Clean(s, false, rules)
+	want = dataset.Series{Time: []float64{1, 2, 3}, Value: []float64{10, 12, 0}, Step: 1}
+	if !reflect.DeepEqual(uterus, want) || removed != 1 {
+		t.Errorf("uterus = %+v (removed %d), want %+v", uterus, removed, want)
+	}
+}
+
+func TestCleanStartArtifact(t *testing.T) {
+	// Первые 5 отсчётов - пульс матери до установки датчика на плод
+	s := series(300, func(t int) float64 {
+		if t <= 5 {
+			return 80
+		}
+		return 140
+	})
+	cleaned, removed := Clean(s, true, DefaultRules())
+	if removed != 5 || cleaned.Time[0] != 6 {
+		t.Errorf("removed %d, first sample at %v, want 5 and 6", removed, cleaned.Time[0])
+	}
+}
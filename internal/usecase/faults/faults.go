@@ -0,0 +1,61 @@
+package faults
+
+import (
+	"backend_gen/internal/models/dto"
+	"backend_gen/internal/ports/websocket"
+	"backend_gen/internal/usecase"
+	"fmt"
+	"time"
+)
+
+type faultsUseCase struct {
+	injector websocket.FaultInjector
+}
+
+func NewFaultsUseCase(injector websocket.FaultInjector) usecase.FaultsUseCase {
+	return &faultsUseCase{injector: injector}
+}
+
+func (uc *faultsUseCase) GetFaults() *dto.Faults {
+	cfg := uc.injector.Faults()
+	return &dto.Faults{
+		Enabled:            cfg.Enabled,
+		LatencyMs:          int(cfg.Latency.Milliseconds()),
+		JitterMs:           int(cfg.Jitter.Milliseconds()),
+		DropRate:           cfg.DropRate,
+		DuplicateRate:      cfg.DuplicateRate,
+		ReorderRate:        cfg.ReorderRate,
+		DisconnectEverySec: cfg.DisconnectEvery.Seconds(),
+		DisconnectRate:     cfg.DisconnectRate,
+		ReconnectAfterMs:   int(cfg.ReconnectAfter.Milliseconds()),
+	}
+}
+
+func (uc *faultsUseCase) SetFaults(faults *dto.Faults) error {
+	if faults.LatencyMs < 0 || faults.JitterMs < 0 || faults.ReconnectAfterMs < 0 || faults.DisconnectEverySec < 0 {
+		return fmt.Errorf("durations must not be negative")
+	}
+	for name, rate := range map[string]float64{
+		"dropRate":       faults.DropRate,
+		"duplicateRate":  faults.DuplicateRate,
+		"reorderRate":    faults.ReorderRate,
+		"disconnectRate": faults.DisconnectRate,
+	} {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("%s must be in range 0..1", name)
+		}
+	}
+
+	uc.injector.SetFaults(websocket.FaultConfig{
+		Enabled:         faults.Enabled,
+		Latency:         time.Duration(faults.LatencyMs) * time.Millisecond,
+		Jitter:          time.Duration(faults.JitterMs) * time.Millisecond,
+		DropRate:        faults.DropRate,
+		DuplicateRate:   faults.DuplicateRate,
+		ReorderRate:     faults.ReorderRate,
+		DisconnectEvery: time.Duration(faults.DisconnectEverySec * float64(time.Second)),
+		DisconnectRate:  faults.DisconnectRate,
+		ReconnectAfter:  time.Duration(faults.ReconnectAfterMs) * time.Millisecond,
+	})
+	return nil
+}
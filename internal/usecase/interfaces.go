@@ -1,6 +1,9 @@
 package usecase
 
-import "backend_gen/internal/models/dto"
+import (
+	"backend_gen/internal/models/dto"
+	"backend_gen/internal/ports/websocket"
+)
 
 type WebSocketUseCase interface {
 	Connect(url string, token string) error
@@ -9,6 +12,27 @@ type WebSocketUseCase interface {
 	SendMessage(message any) error
 	StartSendingMessages() error
 	StopSendingMessages()
+	AddMark(markType string, note string) error
+	SetProfile(profile dto.Profile) error
+	UseScenario(name string) error
+	UseEncoding(name string) error
+	SetFetalState(state string) error
+	Status() *dto.SessionStatus
+}
+
+// StreamUseCase локальные потоки сообщений текущей сессии для дашбордов
+type StreamUseCase interface {
+	Subscribe() (<-chan websocket.MessageData, func())
+	Encoder(name string) (websocket.Encoder, error)
+}
+
+type ScenariosUseCase interface {
+	ListScenarios() ([]dto.Scenario, error)
+}
+
+type FaultsUseCase interface {
+	GetFaults() *dto.Faults
+	SetFaults(faults *dto.Faults) error
 }
 
 type HealthUseCase interface {
@@ -0,0 +1,63 @@
+package offline
+
+import (
+	"backend_gen/internal/constants"
+	"backend_gen/internal/ports/export"
+	"backend_gen/internal/ports/generator"
+	"backend_gen/internal/ports/websocket"
+	"fmt"
+	"time"
+)
+
+// Step шаг генерации, как у живой сессии
+const Step = 120 * time.Millisecond
+
+// Options параметры офлайн генерации
+type Options struct {
+	Duration time.Duration
+	// Annotations добавляет эталонную разметку генератора в сообщения
+	Annotations bool
+	// Profile профиль пациентки (nil = профиль генератора по умолчанию)
+	Profile *generator.Profile
+}
+
+// Generate генерирует сессию длительностью opts.Duration без ожидания реального
+// времени и записывает сообщения во все writers. Возвращает число сообщений
+func Generate(gen generator.DataGenerator, opts Options, writers ...export.Writer) (int, error) {
+	if opts.Duration <= 0 {
+		return 0, fmt.Errorf("duration must be positive")
+	}
+	if opts.Profile != nil {
+		if aware, ok := gen.(generator.ProfileAware); ok {
+			aware.SetProfile(*opts.Profile)
+		}
+	}
+	gen.Reset()
+
+	count := 0
+	for t := Step; t <= opts.Duration; t += Step {
+		elapsed := t.Seconds()
+		message := websocket.MessageData{
+			SensorID:     constants.SensorUUID,
+			SecFromStart: elapsed,
+			Data:         gen.GenerateNext(elapsed),
+		}
+		// Буферы разметки очищаем всегда, как и в живой сессии
+		if source, ok := gen.(generator.AnnotationSource); ok {
+			if annotations := source.DrainAnnotations(); opts.Annotations {
+				message.Annotations = annotations
+			}
+		}
+		if source, ok := gen.(generator.MarkSource); ok {
+			message.Marks = source.DrainMarks()
+		}
+
+		for _, w := range writers {
+			if err := w.Write(message); err != nil {
+				return count, fmt.Errorf("failed to write message at %.2fs: %w", elapsed, err)
+			}
+		}
+		count++
+	}
+	return count, nil
+}
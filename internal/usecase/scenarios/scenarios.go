@@ -0,0 +1,43 @@
+package scenarios
+
+import (
+	"backend_gen/internal/models/dto"
+	"backend_gen/internal/ports/generator"
+	"backend_gen/internal/usecase"
+)
+
+type scenariosUseCase struct {
+	catalog generator.ScenarioCatalog
+}
+
+func NewScenariosUseCase(catalog generator.ScenarioCatalog) usecase.ScenariosUseCase {
+	return &scenariosUseCase{catalog: catalog}
+}
+
+func (uc *scenariosUseCase) ListScenarios() ([]dto.Scenario, error) {
+	infos, err := uc.catalog.List()
+	if err != nil {
+		return nil, err
+	}
+
+	scenarios := make([]dto.Scenario, 0, len(infos))
+	for _, info := range infos {
+		scenario := dto.Scenario{
+			Name:        info.Name,
+			Description: info.Description,
+			Expected:    info.Expected,
+			Preset:      info.Preset,
+			DurationSec: info.Duration.Seconds(),
+			Loop:        info.Loop,
+			Phases:      make([]dto.ScenarioPhase, 0, len(info.Phases)),
+		}
+		for _, phase := range info.Phases {
+			scenario.Phases = append(scenario.Phases, dto.ScenarioPhase{
+				Name:        phase.Name,
+				DurationSec: phase.Duration.Seconds(),
+			})
+		}
+		scenarios = append(scenarios, scenario)
+	}
+	return scenarios, nil
+}
@@ -0,0 +1,28 @@
+package stream
+
+import (
+	"backend_gen/internal/ports/websocket"
+	"backend_gen/internal/usecase"
+	"fmt"
+)
+
+type streamUseCase struct {
+	broadcaster websocket.Broadcaster
+	encoders    map[string]websocket.Encoder
+}
+
+func NewStreamUseCase(broadcaster websocket.Broadcaster, encoders map[string]websocket.Encoder) usecase.StreamUseCase {
+	return &streamUseCase{broadcaster: broadcaster, encoders: encoders}
+}
+
+func (uc *streamUseCase) Subscribe() (<-chan websocket.MessageData, func()) {
+	return uc.broadcaster.Subscribe()
+}
+
+func (uc *streamUseCase) Encoder(name string) (websocket.Encoder, error) {
+	encoder, ok := uc.encoders[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown encoding %q", name)
+	}
+	return encoder, nil
+}
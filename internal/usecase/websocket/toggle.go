@@ -2,21 +2,52 @@ package websocket
 
 import (
 	"backend_gen/internal/constants"
+	"backend_gen/internal/models/dto"
+	"backend_gen/internal/ports/export"
 	"backend_gen/internal/ports/generator"
 	"backend_gen/internal/ports/websocket"
 	"backend_gen/internal/usecase"
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"slices"
+	"sync"
 	"time"
 )
 
 type WebSocketUseCase struct {
-	client    websocket.Client
+	client websocket.Client
+	// broadcaster раздаёт сообщения сессии локальным SSE и WebSocket потокам
+	broadcaster websocket.Broadcaster
+	// recorder записывает сессии в файлы (nil - запись выключена)
+	recorder  export.Recorder
 	generator generator.DataGenerator
-	ticker    *time.Ticker
-	stopCh    chan bool
-	startTime time.Time
+	// defaultGenerator генератор из конфигурации, используемый без сценария
+	defaultGenerator generator.DataGenerator
+	scenarios        generator.ScenarioCatalog
+	// scenario имя сценария текущей сессии ("" = генератор из конфигурации)
+	scenario string
+	// Кодировщики сообщений по именам форматов и формат текущей сессии
+	encoders        map[string]websocket.Encoder
+	defaultEncoding string
+	encoder         websocket.Encoder
+	// transportEncodings форматы, которые переносит транспорт (nil = любые)
+	transportEncodings []string
+	// genMu защищает генератор, профиль и нормы: их меняют из control API во время отправки
+	genMu sync.Mutex
+	// annotations включает отправку эталонной разметки событий генератора
+	annotations bool
+	ticker      *time.Ticker
+	stopCh      chan bool
+	startTime   time.Time
+
+	// Отметки, внесённые через control API и ещё не отправленные
+	marksMu sync.Mutex
+	marks   []websocket.Mark
+
+	// Профиль пациентки текущей сессии и применённые генератором нормы
+	profile dto.Profile
+	norms   *dto.ProfileNorms
 }
 
 func (uc *WebSocketUseCase) Connect(url string, token string) error {
@@ -62,28 +93,70 @@ func (uc *WebSocketUseCase) SendMessage(message any) error {
 	return uc.client.SendMessage(jsonData)
 }
 
-func (uc *WebSocketUseCase) StartSendingMessages() error {
+// sendData кодирует сообщение форматом сессии и отправляет фреймом нужного типа
+func (uc *WebSocketUseCase) sendData(encoder websocket.Encoder, message websocket.MessageData) error {
 	if !uc.client.IsConnected() {
 		return fmt.Errorf("not connected")
 	}
+
+	data, err := encoder.Encode(message)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+	if encoder.Binary() {
+		return uc.client.SendBinary(data)
+	}
+	return uc.client.SendMessage(data)
+}
+
+// StartSendingMessages запускает генерацию. Сообщения всегда раздаются локальным
+// потокам и пишутся в запись сессии, а бэкенду отправляются, только пока он подключён
+func (uc *WebSocketUseCase) StartSendingMessages() error {
 	if uc.ticker != nil {
 		uc.StopSendingMessages()
 	}
 
+	// Горутина прошлой сессии может ещё дорабатывать тик
+	uc.genMu.Lock()
 	uc.generator.Reset()
+	uc.genMu.Unlock()
 	slog.Info("Generator reset, starting periodic message sending", "interval", "120ms")
 
 	//.12 сек
 	uc.ticker = time.NewTicker(120 * time.Millisecond)
 	uc.stopCh = make(chan bool)
+	uc.marksMu.Lock()
 	uc.startTime = time.Now()
+	uc.marks = nil
+	uc.marksMu.Unlock()
+	// Время наблюдения в HL7 отсчитывается от начала сессии; кодировщики
+	// общие с локальными потоками, поэтому начало получают все
+	for _, enc := range uc.encoders {
+		if session, ok := enc.(websocket.SessionEncoder); ok {
+			session.StartSession(uc.startTime)
+		}
+	}
 
+	// Ошибка записи не останавливает отправку данных
+	var recording export.Writer
+	if uc.recorder != nil {
+		var err error
+		if recording, err = uc.recorder.Open(uc.startTime); err != nil {
+			slog.Error("Failed to start session recording", "error", err)
+		}
+	}
+
+	// Горутина работает с копиями: StopSendingMessages обнуляет поля usecase,
+	// а UseScenario меняет генератор следующей сессии
+	ticker, stopCh, startTime, gen, encoder := uc.ticker, uc.stopCh, uc.startTime, uc.generator, uc.encoder
 	go func() {
+		defer func() { closeRecording(recording) }()
 		for {
 			select {
-			case <-uc.ticker.C:
-				elapsed := time.Since(uc.startTime).Seconds()
-				sensorData := uc.generator.GenerateNext(elapsed)
+			case <-ticker.C:
+				elapsed := time.Since(startTime).Seconds()
+				uc.genMu.Lock()
+				sensorData := gen.GenerateNext(elapsed)
 
 				// соо
 				message := websocket.MessageData{
@@ -91,11 +164,32 @@ func (uc *WebSocketUseCase) StartSendingMessages() error {
 					SecFromStart: elapsed,
 					Data:         sensorData,
 				}
+				// Буфер разметки очищаем всегда, даже если она не отправляется;
+				// в запись сессии разметка попадает всегда
+				annotations := drainAnnotations(gen)
+				if uc.annotations {
+					message.Annotations = annotations
+				}
+				message.Marks = uc.drainMarks(gen)
+				uc.genMu.Unlock()
 
-				if err := uc.SendMessage(message); err != nil {
-					slog.Error("Failed to send periodic JSON message", "error", err)
+				if recording != nil {
+					recorded := message
+					recorded.Annotations = annotations
+					if err := recording.Write(recorded); err != nil {
+						slog.Error("Failed to record message, recording stopped", "error", err)
+						closeRecording(recording)
+						recording = nil
+					}
 				}
-			case <-uc.stopCh:
+				uc.broadcaster.Publish(message)
+				if !uc.client.IsConnected() {
+					continue
+				}
+				if err := uc.sendData(encoder, message); err != nil {
+					slog.Error("Failed to send periodic message", "encoding", encoder.Name(), "error", err)
+				}
+			case <-stopCh:
 				slog.Info("Stopping periodic message sending")
 				return
 			}
@@ -114,13 +208,238 @@ func (uc *WebSocketUseCase) StopSendingMessages() {
 		close(uc.stopCh)
 		uc.stopCh = nil
 	}
+	uc.marksMu.Lock()
+	uc.startTime = time.Time{}
+	uc.marksMu.Unlock()
+	// Горутина отправки завершается асинхронно и может быть внутри тика
+	uc.genMu.Lock()
 	uc.generator.Reset()
+	uc.genMu.Unlock()
 	slog.Info("Generator stopped and reset")
 }
 
-func NewWebSocketUseCase(client websocket.Client, dataGenerator generator.DataGenerator) usecase.WebSocketUseCase {
+// UseScenario выбирает генератор следующей сессии: сценарий name из каталога
+// или генератор из конфигурации, если name пустое
+func (uc *WebSocketUseCase) UseScenario(name string) error {
+	if uc.busy() {
+		return fmt.Errorf("session is already running")
+	}
+	if name == "" {
+		uc.genMu.Lock()
+		uc.generator = uc.defaultGenerator
+		uc.genMu.Unlock()
+		uc.scenario = ""
+		return nil
+	}
+	if uc.scenarios == nil {
+		return fmt.Errorf("scenario catalog is not configured")
+	}
+
+	gen, err := uc.scenarios.Open(name)
+	if err != nil {
+		return err
+	}
+	uc.genMu.Lock()
+	uc.generator = gen
+	uc.genMu.Unlock()
+	uc.scenario = name
+	slog.Info("Scenario selected for next session", "scenario", name)
+	return nil
+}
+
+// SetProfile применяет профиль пациентки к генератору. Срок 0 - не задан
+// (конфигурация без блока profile): генератор считает беременность доношенной
+func (uc *WebSocketUseCase) SetProfile(profile dto.Profile) error {
+	if profile.GestationalWeeks != 0 && (profile.GestationalWeeks < 20 || profile.GestationalWeeks > 44) {
+		return fmt.Errorf("gestational age must be in range 20..44 weeks, got %.1f", profile.GestationalWeeks)
+	}
+	if profile.MaternalAge != 0 && (profile.MaternalAge < 12 || profile.MaternalAge > 65) {
+		return fmt.Errorf("maternal age must be in range 12..65, got %d", profile.MaternalAge)
+	}
+	if profile.Parity < 0 {
+		return fmt.Errorf("parity must not be negative")
+	}
+
+	uc.genMu.Lock()
+	defer uc.genMu.Unlock()
+
+	uc.profile = profile
+	uc.norms = nil
+	applied := false
+	if aware, ok := uc.generator.(generator.ProfileAware); ok {
+		var norms generator.ProfileNorms
+		norms, applied = aware.SetProfile(generator.Profile{
+			GestationalWeeks: profile.GestationalWeeks,
+			MaternalAge:      profile.MaternalAge,
+			Parity:           profile.Parity,
+		})
+		if applied {
+			uc.norms = &dto.ProfileNorms{
+				BaselineBPM:           norms.BaselineBPM,
+				Variability:           norms.Variability,
+				AccelerationAmplitude: norms.AccelerationAmplitude,
+				AccelerationDuration:  norms.AccelerationDuration,
+				MaternalBPM:           norms.MaternalBPM,
+			}
+		}
+	}
+	if !applied {
+		slog.Warn("Generator does not support patient profile, profile is informational only")
+	}
+	return nil
+}
+
+// UseEncoding выбирает формат сообщений следующей сессии ("" = формат по умолчанию)
+func (uc *WebSocketUseCase) UseEncoding(name string) error {
+	if uc.busy() {
+		return fmt.Errorf("session is already running")
+	}
+	if name == "" {
+		name = uc.defaultEncoding
+	}
+
+	encoder, ok := uc.encoders[name]
+	if !ok {
+		return fmt.Errorf("unknown encoding %q", name)
+	}
+	if len(uc.transportEncodings) > 0 && !slices.Contains(uc.transportEncodings, name) {
+		return fmt.Errorf("transport does not support encoding %q, supported: %v", name, uc.transportEncodings)
+	}
+	uc.encoder = encoder
+	return nil
+}
+
+// running сообщает, что генерация запущена
+func (uc *WebSocketUseCase) running() bool {
+	uc.marksMu.Lock()
+	defer uc.marksMu.Unlock()
+	return !uc.startTime.IsZero()
+}
+
+// busy сообщает, что сессия идёт: генерация запущена или бэкенд подключён
+func (uc *WebSocketUseCase) busy() bool {
+	return uc.running() || uc.client.IsConnected()
+}
+
+// SetFetalState меняет состояние плода во время сессии
+func (uc *WebSocketUseCase) SetFetalState(state string) error {
+	if !uc.running() {
+		return fmt.Errorf("generation is not running")
+	}
+
+	uc.genMu.Lock()
+	defer uc.genMu.Unlock()
+
+	control, ok := uc.generator.(generator.FetalStateControl)
+	if !ok {
+		return fmt.Errorf("generator does not support fetal state switching")
+	}
+	if err := control.SetFetalState(state); err != nil {
+		return err
+	}
+	slog.Info("Fetal state switch requested", "state", state)
+	return nil
+}
+
+// Status возвращает состояние текущей сессии
+func (uc *WebSocketUseCase) Status() *dto.SessionStatus {
+	status := &dto.SessionStatus{
+		Connected: uc.client.IsConnected(),
+		Scenario:  uc.scenario,
+		Encoding:  uc.encoder.Name(),
+	}
+
+	uc.genMu.Lock()
+	status.Profile, status.Norms = uc.profile, uc.norms
+	if control, ok := uc.generator.(generator.FetalStateControl); ok {
+		status.FetalState = control.FetalState()
+	}
+	uc.genMu.Unlock()
+
+	uc.marksMu.Lock()
+	defer uc.marksMu.Unlock()
+	if !uc.startTime.IsZero() {
+		status.Running = true
+		status.SecFromStart = time.Since(uc.startTime).Seconds()
+	}
+	return status
+}
+
+// AddMark добавляет отметку события (кнопка монитора) в текущую сессию.
+// Отметка уходит со следующим сообщением с временем нажатия
+func (uc *WebSocketUseCase) AddMark(markType string, note string) error {
+	uc.marksMu.Lock()
+	defer uc.marksMu.Unlock()
+
+	if uc.startTime.IsZero() {
+		return fmt.Errorf("generation is not running")
+	}
+	uc.marks = append(uc.marks, websocket.Mark{
+		Type:         markType,
+		Source:       websocket.MarkSourceManual,
+		SecFromStart: time.Since(uc.startTime).Seconds(),
+		Note:         note,
+	})
+	return nil
+}
+
+// drainMarks объединяет отметки генератора и внесённые через control API
+func (uc *WebSocketUseCase) drainMarks(gen generator.DataGenerator) []websocket.Mark {
+	var marks []websocket.Mark
+	if source, ok := gen.(generator.MarkSource); ok {
+		marks = source.DrainMarks()
+	}
+
+	uc.marksMu.Lock()
+	defer uc.marksMu.Unlock()
+	marks = append(marks, uc.marks...)
+	uc.marks = nil
+	return marks
+}
+
+// closeRecording дописывает и закрывает запись сессии
+func closeRecording(recording export.Writer) {
+	if recording == nil {
+		return
+	}
+	if err := recording.Close(); err != nil {
+		slog.Error("Failed to finish session recording", "error", err)
+		return
+	}
+	slog.Info("Session recording finished")
+}
+
+// drainAnnotations забирает эталонную разметку у генератора, если он её поддерживает
+func drainAnnotations(gen generator.DataGenerator) []websocket.Annotation {
+	source, ok := gen.(generator.AnnotationSource)
+	if !ok {
+		return nil
+	}
+	return source.DrainAnnotations()
+}
+
+func NewWebSocketUseCase(
+	client websocket.Client,
+	broadcaster websocket.Broadcaster,
+	recorder export.Recorder,
+	dataGenerator generator.DataGenerator,
+	scenarios generator.ScenarioCatalog,
+	encoders map[string]websocket.Encoder,
+	defaultEncoding string,
+	transportEncodings []string,
+	annotations bool,
+) usecase.WebSocketUseCase {
 	return &WebSocketUseCase{
-		client:    client,
-		generator: dataGenerator,
+		client:             client,
+		broadcaster:        broadcaster,
+		recorder:           recorder,
+		generator:          dataGenerator,
+		defaultGenerator:   dataGenerator,
+		scenarios:          scenarios,
+		encoders:           encoders,
+		defaultEncoding:    defaultEncoding,
+		encoder:            encoders[defaultEncoding],
+		transportEncodings: transportEncodings,
+		annotations:        annotations,
 	}
 }
@@ -0,0 +1,164 @@
+package websocket
+
+import (
+	"backend_gen/internal/adapter/broadcast"
+	"backend_gen/internal/ports/generator"
+	"backend_gen/internal/ports/websocket"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClient транспорт в памяти: считает отправленные сообщения
+type fakeClient struct {
+	mu        sync.Mutex
+	connected bool
+	sent      int
+}
+
+func (c *fakeClient) Connect(string, string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.connected = true
+	return nil
+}
+
+func (c *fakeClient) Disconnect() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.connected = false
+	return nil
+}
+
+func (c *fakeClient) IsConnected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.connected
+}
+
+func (c *fakeClient) SendMessage([]byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sent++
+	return nil
+}
+
+func (c *fakeClient) SendBinary(message []byte) error { return c.SendMessage(message) }
+
+func (c *fakeClient) Sent() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.sent
+}
+
+type constGenerator struct{}
+
+func (constGenerator) GenerateNext(float64) websocket.SensorData {
+	return websocket.SensorData{BPMChild: 140, Uterus: 10}
+}
+func (constGenerator) Reset()                                       {}
+func (constGenerator) SetParameters(generator.GenerationParameters) {}
+
+type jsonEncoder struct{}
+
+func (jsonEncoder) Name() string { return "json" }
+func (jsonEncoder) Binary() bool { return false }
+func (jsonEncoder) Encode(m websocket.MessageData) ([]byte, error) {
+	return json.Marshal(m)
+}
+
+func newTestUseCase(client websocket.Client, hub websocket.Broadcaster) *WebSocketUseCase {
+	return NewWebSocketUseCase(client, hub, nil, constGenerator{}, nil,
+		map[string]websocket.Encoder{"json": jsonEncoder{}}, "json", nil, false).(*WebSocketUseCase)
+}
+
+func receive(t *testing.T, messages <-chan websocket.MessageData) websocket.MessageData {
+	t.Helper()
+	select {
+	case m := <-messages:
+		return m
+	case <-time.After(2 * time.Second):
+		t.Fatal("no message broadcast")
+	}
+	return websocket.MessageData{}
+}
+
+func TestLocalSessionWithoutBackend(t *testing.T) {
+	client := &fakeClient{}
+	hub := broadcast.NewHub()
+	uc := newTestUseCase(client, hub)
+	messages, unsubscribe := hub.Subscribe()
+	defer unsubscribe()
+
+	if err := uc.StartSendingMessages(); err != nil {
+		t.Fatalf("start without backend: %v", err)
+	}
+	if m := receive(t, messages); m.Data.BPMChild != 140 {
+		t.Errorf("broadcast data = %+v", m.Data)
+	}
+	if status := uc.Status(); !status.Running || status.Connected {
+		t.Errorf("status = %+v, want running without backend", status)
+	}
+	// Параметры сессии нельзя менять, пока идёт генерация
+	if err := uc.UseEncoding(""); err == nil {
+		t.Error("encoding changed during a running session")
+	}
+
+	uc.StopSendingMessages()
+	if client.Sent() != 0 {
+		t.Errorf("sent %d messages without a backend", client.Sent())
+	}
+	if uc.Status().Running {
+		t.Error("generation still running after stop")
+	}
+}
+
+func TestSessionSendsToConnectedBackend(t *testing.T) {
+	client := &fakeClient{}
+	hub := broadcast.NewHub()
+	uc := newTestUseCase(client, hub)
+	messages, unsubscribe := hub.Subscribe()
+	defer unsubscribe()
+
+	if err := uc.Connect("ws://backend", "token"); err != nil {
+		t.Fatal(err)
+	}
+	if err := uc.StartSendingMessages(); err != nil {
+		t.Fatal(err)
+	}
+	defer uc.StopSendingMessages()
+	receive(t, messages)
+	receive(t, messages)
+	// Отправка идёт после раздачи: ко второму сообщению первое уже отправлено
+	if client.Sent() == 0 {
+		t.Error("nothing sent to the connected backend")
+	}
+}
+
+func TestUseEncodingRestrictedByTransport(t *testing.T) {
+	encoders := map[string]websocket.Encoder{"json": jsonEncoder{}, "protobuf": protobufEncoder{}}
+	uc := NewWebSocketUseCase(&fakeClient{}, broadcast.NewHub(), nil, constGenerator{}, nil,
+		encoders, "protobuf", []string{"protobuf"}, false)
+
+	// Транспорт переносит только protobuf: другой формат отклоняется до начала сессии
+	if err := uc.UseEncoding("json"); err == nil {
+		t.Error("json accepted by a protobuf-only transport")
+	}
+	if err := uc.UseEncoding("xml"); err == nil {
+		t.Error("unknown encoding accepted")
+	}
+	for _, name := range []string{"protobuf", ""} {
+		if err := uc.UseEncoding(name); err != nil {
+			t.Errorf("UseEncoding(%q): %v", name, err)
+		}
+	}
+	if got := uc.Status().Encoding; got != "protobuf" {
+		t.Errorf("encoding = %q", got)
+	}
+}
+
+type protobufEncoder struct{ jsonEncoder }
+
+func (protobufEncoder) Name() string { return "protobuf" }
+func (protobufEncoder) Binary() bool { return true }